@@ -0,0 +1,139 @@
+package blockchain
+
+import (
+	"encoding/binary"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/store"
+)
+
+// BloomSectionSize is the number of consecutive block heights covered by one sectioned bloom
+// filter. A range scan can skip a whole section if its bloom filter cannot match the query.
+const BloomSectionSize = 4096
+
+// BloomIndexEntry is the bloom filter covering the logs of a block height or a section.
+type BloomIndexEntry struct {
+	Bloom core.Bloom
+}
+
+// heightBloomKey constructs the DB key for the bloom filter of the given height. The filter
+// covers the logs of all the blocks executed at the height, including the blocks on forks
+// that were later abandoned, so it can only produce false positives.
+func heightBloomKey(height uint64) common.Bytes {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, height)
+	return append(common.Bytes("bloom/h/"), buf...)
+}
+
+// sectionBloomKey constructs the DB key for the bloom filter of the given section.
+func sectionBloomKey(section uint64) common.Bytes {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, section)
+	return append(common.Bytes("bloom/s/"), buf...)
+}
+
+// bloomIndexStartKey is the DB key of the first height with bloom indexes. Blocks below
+// that height were executed before the node maintained the indexes.
+func bloomIndexStartKey() common.Bytes {
+	return common.Bytes("bloom/start")
+}
+
+// BloomIndexStartEntry records the first height with bloom indexes.
+type BloomIndexStartEntry struct {
+	Height uint64
+}
+
+// addLogsToBloomIndex adds the logs of a transaction in the given block to the bloom indexes.
+func (ch *Chain) addLogsToBloomIndex(block *core.Block, logs []*types.Log) {
+	ch.bloomMu.Lock()
+	defer ch.bloomMu.Unlock()
+
+	if ch.bloomIndexStart == nil {
+		entry := &BloomIndexStartEntry{}
+		err := ch.store.Get(bloomIndexStartKey(), entry)
+		if err == store.ErrKeyNotFound {
+			entry.Height = block.Height
+			err = ch.store.Put(bloomIndexStartKey(), entry)
+		}
+		if err != nil {
+			logger.Panic(err)
+		}
+		ch.bloomIndexStart = &entry.Height
+	}
+
+	if len(logs) == 0 {
+		return
+	}
+	bloom := types.LogsBloom(logs)
+	ch.mergeBloom(heightBloomKey(block.Height), bloom)
+	ch.mergeBloom(sectionBloomKey(block.Height/BloomSectionSize), bloom)
+}
+
+func (ch *Chain) mergeBloom(key common.Bytes, bloom core.Bloom) {
+	entry := &BloomIndexEntry{}
+	err := ch.store.Get(key, entry)
+	if err != nil && err != store.ErrKeyNotFound {
+		logger.Panic(err)
+	}
+	entry.Bloom.Or(bloom)
+	err = ch.store.Put(key, entry)
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+// getBloomIndexStart returns the first height with bloom indexes, and false if no
+// index has been built yet.
+func (ch *Chain) getBloomIndexStart() (uint64, bool) {
+	ch.bloomMu.Lock()
+	defer ch.bloomMu.Unlock()
+
+	if ch.bloomIndexStart != nil {
+		return *ch.bloomIndexStart, true
+	}
+	entry := &BloomIndexStartEntry{}
+	err := ch.store.Get(bloomIndexStartKey(), entry)
+	if err != nil {
+		if err != store.ErrKeyNotFound {
+			logger.Error(err)
+		}
+		return 0, false
+	}
+	ch.bloomIndexStart = &entry.Height
+	return entry.Height, true
+}
+
+// FindHeightBloom returns the bloom filter covering the logs of the blocks at the given height.
+// The second return value is false if the height is not covered by the index, in which case
+// the caller needs to examine the receipts directly.
+func (ch *Chain) FindHeightBloom(height uint64) (core.Bloom, bool) {
+	start, ok := ch.getBloomIndexStart()
+	if !ok || height < start {
+		return core.Bloom{}, false
+	}
+	entry := &BloomIndexEntry{}
+	err := ch.store.Get(heightBloomKey(height), entry)
+	if err != nil && err != store.ErrKeyNotFound {
+		logger.Error(err)
+		return core.Bloom{}, false
+	}
+	return entry.Bloom, true
+}
+
+// FindSectionBloom returns the bloom filter covering the logs of all the blocks in the given
+// section. The second return value is false if the section is not fully covered by the index.
+func (ch *Chain) FindSectionBloom(section uint64) (core.Bloom, bool) {
+	start, ok := ch.getBloomIndexStart()
+	if !ok || section*BloomSectionSize < start {
+		return core.Bloom{}, false
+	}
+	entry := &BloomIndexEntry{}
+	err := ch.store.Get(sectionBloomKey(section), entry)
+	if err != nil && err != store.ErrKeyNotFound {
+		logger.Error(err)
+		return core.Bloom{}, false
+	}
+	return entry.Bloom, true
+}
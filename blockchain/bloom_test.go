@@ -0,0 +1,64 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	chain := CreateTestChain()
+
+	// No index has been built yet
+	_, indexed := chain.FindHeightBloom(10)
+	assert.False(indexed)
+
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	topic := common.HexToHash("0x01")
+
+	block1 := core.CreateTestBlock("b1", "")
+	block1.Height = 10
+	tx := &types.SmartContractTx{
+		From:     types.TxInput{Address: other, Sequence: 1},
+		GasLimit: 100000,
+		GasPrice: big.NewInt(1),
+	}
+	logs := []*types.Log{{Address: contract, Topics: []common.Hash{topic}}}
+	chain.AddTxReceipt(block1, tx, logs, nil, nil, common.Address{}, 21000, nil)
+
+	bloom, indexed := chain.FindHeightBloom(10)
+	assert.True(indexed)
+	assert.True(bloom.ContainsBytes(contract.Bytes()))
+	assert.True(bloom.ContainsBytes(topic.Bytes()))
+	assert.False(bloom.ContainsBytes(other.Bytes()))
+
+	// Heights above the index start without logs have an empty bloom
+	bloom, indexed = chain.FindHeightBloom(11)
+	assert.True(indexed)
+	assert.Equal(core.Bloom{}, bloom)
+
+	// Heights below the index start are not covered
+	_, indexed = chain.FindHeightBloom(9)
+	assert.False(indexed)
+
+	block2 := core.CreateTestBlock("b2", "")
+	block2.Height = BloomSectionSize + 5
+	logs = []*types.Log{{Address: other}}
+	chain.AddTxReceipt(block2, tx, logs, nil, nil, common.Address{}, 21000, nil)
+
+	// The first section starts below the index start, so it is not fully covered
+	_, indexed = chain.FindSectionBloom(0)
+	assert.False(indexed)
+
+	bloom, indexed = chain.FindSectionBloom(1)
+	assert.True(indexed)
+	assert.True(bloom.ContainsBytes(other.Bytes()))
+	assert.False(bloom.ContainsBytes(contract.Bytes()))
+}
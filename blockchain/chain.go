@@ -25,6 +25,9 @@ type Chain struct {
 	root    common.Hash
 
 	mu *sync.RWMutex
+
+	bloomMu         sync.Mutex // protects the bloom indexes
+	bloomIndexStart *uint64
 }
 
 // NewChain creates a new Chain instance.
@@ -184,6 +184,8 @@ func (ch *Chain) AddTxReceipt(block *core.Block, tx types.Tx, logs []*types.Log,
 	if err != nil {
 		logger.Panic(err)
 	}
+
+	ch.addLogsToBloomIndex(block, logs)
 }
 
 // FindTxReceiptByHash looks up transaction receipt by hash.
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/signer"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signerCmd represents the signer command
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Start the external signer serving the node.",
+	Long: `Start the external signer holding the node key, so that the key never lives on the node host.
+The node connects to the signer with signer.remoteAddress, and both sides authenticate each other
+with certificates issued by the same CA.`,
+	Example: `script signer --config=../privatenet/signer --listen=unix:///var/run/script/signer.sock`,
+	Run:     runSigner,
+}
+
+func init() {
+	signerCmd.Flags().String("listen", "", "Address to listen at, tcp://host:port or unix:///path/to/socket")
+	viper.BindPFlag(common.CfgSignerListenAddress, signerCmd.Flags().Lookup("listen"))
+	RootCmd.AddCommand(signerCmd)
+}
+
+func runSigner(cmd *cobra.Command, args []string) {
+	listenAddress := viper.GetString(common.CfgSignerListenAddress)
+	if listenAddress == "" {
+		log.Fatalf("The address to listen at is not specified")
+	}
+
+	privKey, err := loadOrCreateKey()
+	if err != nil {
+		log.Fatalf("Failed to load or create key: %v", err)
+	}
+	localSigner, err := signer.NewLocalSigner(privKey)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+
+	server, err := signer.NewServer(localSigner, listenAddress, getSignerTLSConfig())
+	if err != nil {
+		log.Fatalf("Failed to start signer at %v: %v", listenAddress, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		signal.Stop(c)
+		cancel()
+	}()
+
+	log.WithFields(log.Fields{
+		"address": privKey.PublicKey().Address().Hex(),
+		"listen":  listenAddress,
+	}).Info("Signer started")
+
+	server.Start(ctx)
+	server.Wait()
+	log.Infof("Signer stopped")
+}
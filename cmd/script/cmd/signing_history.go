@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/scripttoken/script/consensus"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var signingHistoryFile string
+
+// signingHistoryCmd represents the signing history command
+var signingHistoryCmd = &cobra.Command{
+	Use:   "signing_history",
+	Short: "Manage the slashing protection database of the node key.",
+	Long: `Manage the slashing protection database of the node key. When migrating the node key to another
+machine, export the signing history on the old machine after stopping the node, and import it on
+the new machine before starting the node.`,
+}
+
+// signingHistoryExportCmd represents the signing history export command
+var signingHistoryExportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Export the signing history.",
+	Example: `script signing_history export --config=../privatenet/node --file=signing_history.json`,
+	Run:     runSigningHistoryExport,
+}
+
+// signingHistoryImportCmd represents the signing history import command
+var signingHistoryImportCmd = &cobra.Command{
+	Use:     "import",
+	Short:   "Import the signing history exported on another machine.",
+	Example: `script signing_history import --config=../privatenet/node --file=signing_history.json`,
+	Run:     runSigningHistoryImport,
+}
+
+func init() {
+	signingHistoryExportCmd.Flags().StringVar(&signingHistoryFile, "file", "", "File to export the signing history to")
+	signingHistoryExportCmd.MarkFlagRequired("file")
+	signingHistoryImportCmd.Flags().StringVar(&signingHistoryFile, "file", "", "File to import the signing history from")
+	signingHistoryImportCmd.MarkFlagRequired("file")
+
+	signingHistoryCmd.AddCommand(signingHistoryExportCmd)
+	signingHistoryCmd.AddCommand(signingHistoryImportCmd)
+	RootCmd.AddCommand(signingHistoryCmd)
+}
+
+func runSigningHistoryExport(cmd *cobra.Command, args []string) {
+	historyPath := getSigningHistoryPath()
+	history, err := consensus.NewSigningHistory(historyPath)
+	if err != nil {
+		log.Fatalf("Failed to open the signing history %v: %v", historyPath, err)
+	}
+	defer history.Close()
+
+	file, err := os.OpenFile(signingHistoryFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("Failed to create %v: %v", signingHistoryFile, err)
+	}
+	defer file.Close()
+
+	if err := history.Export(file); err != nil {
+		log.Fatalf("Failed to export the signing history: %v", err)
+	}
+	log.Infof("Exported the signing history to %v", signingHistoryFile)
+}
+
+func runSigningHistoryImport(cmd *cobra.Command, args []string) {
+	historyPath := getSigningHistoryPath()
+	history, err := consensus.NewSigningHistory(historyPath)
+	if err != nil {
+		log.Fatalf("Failed to open the signing history %v: %v", historyPath, err)
+	}
+	defer history.Close()
+
+	file, err := os.Open(signingHistoryFile)
+	if err != nil {
+		log.Fatalf("Failed to open %v: %v", signingHistoryFile, err)
+	}
+	defer file.Close()
+
+	if err := history.Import(file); err != nil {
+		log.Fatalf("Failed to import the signing history: %v", err)
+	}
+	log.Infof("Imported the signing history from %v", signingHistoryFile)
+}
@@ -21,6 +21,7 @@ import (
 	msg "github.com/scripttoken/script/p2p/messenger"
 	msgl "github.com/scripttoken/script/p2pl/messenger"
 	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/signer"
 	"github.com/scripttoken/script/snapshot"
 	"github.com/scripttoken/script/store/database/backend"
 	"github.com/scripttoken/script/store/rollingdb"
@@ -52,6 +53,12 @@ func runStart(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to load or create key: %v", err)
 	}
 
+	// With an external signer, the key in the local keystore only serves as the p2p identity
+	nodeSigner, err := newSigner(privKey)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+
 	// Open database
 	dbPath := viper.GetString(common.CfgDataPath)
 	if dbPath == "" {
@@ -138,6 +145,7 @@ func runStart(cmd *cobra.Command, args []string) {
 	params := &node.Params{
 		ChainID:             root.ChainID,
 		PrivateKey:          privKey,
+		Signer:              nodeSigner,
 		Root:                root,
 		NetworkOld:          networkOld,
 		Network:             network,
@@ -146,6 +154,8 @@ func runStart(cmd *cobra.Command, args []string) {
 		SnapshotPath:        snapshotPath,
 		ChainImportDirPath:  chainImportDirPath,
 		ChainCorrectionPath: chainCorrectionPath,
+		DataPath:            dbPath,
+		SigningHistoryPath:  getSigningHistoryPath(),
 	}
 
 	n := node.NewNode(params)
@@ -186,6 +196,39 @@ func runStart(cmd *cobra.Command, args []string) {
 	printExitBanner()
 }
 
+// newSigner returns the external signer if configured, or signs with the given key otherwise.
+func newSigner(privKey *crypto.PrivateKey) (core.Signer, error) {
+	remoteAddress := viper.GetString(common.CfgSignerRemoteAddress)
+	if remoteAddress == "" {
+		return signer.NewLocalSigner(privKey)
+	}
+	timeout := time.Duration(viper.GetInt(common.CfgSignerTimeoutSecs)) * time.Second
+	return signer.NewRemoteSigner(remoteAddress, getSignerTLSConfig(), timeout)
+}
+
+func getSignerTLSConfig() signer.TLSConfig {
+	return signer.TLSConfig{
+		CACertFile: viper.GetString(common.CfgSignerTLSCACert),
+		CertFile:   viper.GetString(common.CfgSignerTLSCert),
+		KeyFile:    viper.GetString(common.CfgSignerTLSKey),
+		ServerName: viper.GetString(common.CfgSignerTLSServerName),
+	}
+}
+
+// getSigningHistoryPath returns the path of the slashing protection database. It is kept with
+// the key rather than the data, so that it survives a wiped data directory.
+func getSigningHistoryPath() string {
+	historyPath := viper.GetString(common.CfgConsensusSigningHistoryPath)
+	if historyPath != "" {
+		return historyPath
+	}
+	keyPath := viper.GetString(common.CfgKeyPath)
+	if keyPath == "" {
+		keyPath = cfgPath
+	}
+	return path.Join(keyPath, "signing_history")
+}
+
 func loadOrCreateKey() (*crypto.PrivateKey, error) {
 	keyPath := viper.GetString(common.CfgKeyPath)
 	if keyPath == "" {
@@ -6,15 +6,17 @@ import (
 
 // Common flags used in Call sub commands.
 var (
-	chainIDFlag  string
-	fromFlag     string
-	toFlag       string
-	seqFlag      uint64
-	valueFlag    uint64
-	gasPriceFlag string
-	gasLimitFlag uint64
-	dataFlag     string
-	verboseFlag  bool
+	chainIDFlag   string
+	fromFlag      string
+	toFlag        string
+	seqFlag       uint64
+	valueFlag     uint64
+	gasPriceFlag  string
+	gasLimitFlag  uint64
+	dataFlag      string
+	heightFlag    uint64
+	blockHashFlag string
+	verboseFlag   bool
 )
 
 // CallCmd represents the call command
@@ -18,6 +18,7 @@ var (
 	sourceFlag           string
 	holderFlag           string
 	withdrawnOnlyFlag    bool
+	statsFlag            bool
 )
 
 // QueryCmd represents the query command
@@ -39,5 +40,6 @@ func init() {
 	QueryCmd.AddCommand(srdrsCmd)
 	QueryCmd.AddCommand(stakeReturnsCmd)
 	QueryCmd.AddCommand(peersCmd)
+	QueryCmd.AddCommand(mempoolCmd)
 	QueryCmd.AddCommand(versionCmd)
 }
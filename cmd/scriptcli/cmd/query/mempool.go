@@ -0,0 +1,55 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/scripttoken/script/cmd/scriptcli/cmd/utils"
+	"github.com/scripttoken/script/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// mempoolCmd represents the mempool command.
+// Example:
+//
+//	scriptcli query mempool --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+//	scriptcli query mempool --stats
+var mempoolCmd = &cobra.Command{
+	Use:   "mempool",
+	Short: "Get the transactions in the mempool",
+	Long:  `Get the pending and queued transactions in the mempool grouped by sender, or the mempool statistics.`,
+	Example: `scriptcli query mempool --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+scriptcli query mempool --stats`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+		var res *rpcc.RPCResponse
+		var err error
+		if statsFlag {
+			res, err = client.Call("script.GetMempoolStats", rpc.GetMempoolStatsArgs{})
+		} else {
+			res, err = client.Call("script.GetMempoolContent", rpc.GetMempoolContentArgs{
+				Address: addressFlag,
+			})
+		}
+		if err != nil {
+			utils.Error("Failed to get mempool details: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.Error("Failed to retrieve mempool details: %v\n", res.Error)
+		}
+		json, err := json.MarshalIndent(res.Result, "", "    ")
+		if err != nil {
+			utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+		}
+		fmt.Println(string(json))
+	},
+}
+
+func init() {
+	mempoolCmd.Flags().StringVar(&addressFlag, "address", "", "Only show the transactions of the address")
+	mempoolCmd.Flags().BoolVar(&statsFlag, "stats", false, "Show the mempool statistics instead")
+}
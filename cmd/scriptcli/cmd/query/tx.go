@@ -19,7 +19,7 @@ import (
 var txCmd = &cobra.Command{
 	Use:     "tx",
 	Short:   "Get transaction details",
-	Long:    `Get transaction details.`,
+	Long:    `Get transaction details. For a transaction not found in the chain, the outcome tells why it left the mempool, e.g. evicted, replaced, expired or included.`,
 	Example: `scriptcli query tx --hash=0x2fe41732b40ca852e9c36f52b278dde78f0fe34f28f9c94083112aa6a0624b8c`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
@@ -34,6 +34,34 @@ const (
 	CfgConsensusEdgeNodeVoteQueueSize = "consensus.edgeNodeVoteQueueSize"
 	// CfgConsensusPassThroughGuardianVote defines the how guardian vote is handled.
 	CfgConsensusPassThroughGuardianVote = "consensus.passThroughGuardianVote"
+	// CfgConsensusSigningHistoryPath defines the path of the slashing protection database (default to
+	// signing_history under the key path).
+	CfgConsensusSigningHistoryPath = "consensus.signingHistoryPath"
+	// CfgConsensusVRFProposerEnabled sets whether the block proposers prove their eligibility with a VRF
+	// from the HeightEnableVRFProposer fork onward. All the validators of a chain must use the same setting.
+	CfgConsensusVRFProposerEnabled = "consensus.vrfProposerEnabled"
+	// CfgConsensusWALEnabled sets whether to record the consensus messages in a write-ahead log under
+	// the data path, which is replayed after the node restarts.
+	CfgConsensusWALEnabled = "consensus.walEnabled"
+	// CfgConsensusWALMaxSize sets the size in bytes beyond which the consensus WAL stops recording the
+	// received messages until the next block is finalized.
+	CfgConsensusWALMaxSize = "consensus.walMaxSize"
+
+	// CfgSignerRemoteAddress sets the address of the external signer holding the node key, either
+	// tcp://host:port or unix:///path/to/socket. Empty to sign with the key in the local keystore.
+	CfgSignerRemoteAddress = "signer.remoteAddress"
+	// CfgSignerListenAddress sets the address the external signer process listens at.
+	CfgSignerListenAddress = "signer.listenAddress"
+	// CfgSignerTLSCACert sets the certificate of the CA issuing the node and the signer certificates.
+	CfgSignerTLSCACert = "signer.tlsCACert"
+	// CfgSignerTLSCert sets the certificate presented to the signer, or to the node by the signer.
+	CfgSignerTLSCert = "signer.tlsCert"
+	// CfgSignerTLSKey sets the private key of the certificate.
+	CfgSignerTLSKey = "signer.tlsKey"
+	// CfgSignerTLSServerName sets the name in the signer certificate (default to the host of the signer address).
+	CfgSignerTLSServerName = "signer.tlsServerName"
+	// CfgSignerTimeoutSecs sets the timeout of the signing requests to the external signer.
+	CfgSignerTimeoutSecs = "signer.timeoutSecs"
 
 	// CfgStorageRollingEnabled indicates whether rolling is enabled
 	CfgStorageRollingEnabled = "storage.stateRollingEnabled"
@@ -124,6 +152,75 @@ const (
 	CfgRPCGetBlocksHeavyQueryThreshold = "rpc.getBlocksHeavyQueryThreshold"
 	CfgRPCMaxHeavyGetBlocksQueryCount  = "rpc.maxHeavyGetBlocksQueryCount"
 	CfgRPCIdleTimeoutSecs              = "rpc.idleTimeoutSecs"
+	// CfgRPCSubscriptionBufferSize sets the number of notifications buffered for each websocket
+	// connection. Connections falling further behind are dropped.
+	CfgRPCSubscriptionBufferSize = "rpc.subscriptionBufferSize"
+	// CfgRPCMaxSubscriptionsPerConn limits the number of subscriptions of a websocket connection.
+	CfgRPCMaxSubscriptionsPerConn = "rpc.maxSubscriptionsPerConn"
+
+	// CfgMempoolMinReplacementFeeBump sets the minimum increase, in percent, of the effective gas price
+	// for a transaction to replace the pending transaction with the same sequence from the same account.
+	CfgMempoolMinReplacementFeeBump = "mempool.minReplacementFeeBump"
+	// CfgMempoolMaxReplacementsPerAccount sets the maximum number of replacements by one account between
+	// two blocks, since each replacement screens all the pending transactions again.
+	CfgMempoolMaxReplacementsPerAccount = "mempool.maxReplacementsPerAccount"
+	// CfgMempoolMaxReplacementsPerBlock sets the maximum number of replacements by all the accounts
+	// between two blocks, so that the accounts cannot be multiplied to screen the pending transactions
+	// again without limit.
+	CfgMempoolMaxReplacementsPerBlock = "mempool.maxReplacementsPerBlock"
+	// CfgMempoolMaxNumTxs sets the maximum number of pending transactions in the mempool.
+	CfgMempoolMaxNumTxs = "mempool.maxNumTxs"
+	// CfgMempoolMaxNumTxsPerAccount sets the maximum number of pending transactions of one account.
+	CfgMempoolMaxNumTxsPerAccount = "mempool.maxNumTxsPerAccount"
+	// CfgMempoolMaxBytes sets the maximum total size in bytes of the pending transactions.
+	CfgMempoolMaxBytes = "mempool.maxBytes"
+	// CfgMempoolMaxNumFutureTxs sets the maximum number of queued transactions whose sequence is
+	// ahead of the next expected sequence of their accounts.
+	CfgMempoolMaxNumFutureTxs = "mempool.maxNumFutureTxs"
+	// CfgMempoolFutureTxTTLSecs sets how long a queued transaction waits for its sequence gap to close.
+	CfgMempoolFutureTxTTLSecs = "mempool.futureTxTTLSecs"
+	// CfgMempoolJournalEnabled sets whether to record the accepted transactions in a journal under the
+	// data path, which is replayed after the node restarts and has synced.
+	CfgMempoolJournalEnabled = "mempool.journalEnabled"
+	// CfgMempoolJournalCompactionIntervalSecs sets how often the journal is rewritten to only contain
+	// the transactions currently in the mempool.
+	CfgMempoolJournalCompactionIntervalSecs = "mempool.journalCompactionIntervalSecs"
+	// CfgMempoolGossipAnnounceThreshold sets the size in bytes above which a transaction is announced
+	// to the peers by hash for them to fetch, instead of being pushed in full.
+	CfgMempoolGossipAnnounceThreshold = "mempool.gossipAnnounceThreshold"
+	// CfgMempoolPeerTxRateLimit sets the number of transaction messages per second accepted from a peer.
+	CfgMempoolPeerTxRateLimit = "mempool.peerTxRateLimit"
+	// CfgMempoolPeerPenaltyThreshold sets the penalty, accumulated by relaying invalid transactions or
+	// exceeding the rate limit, at which the transactions from a peer are temporarily ignored.
+	CfgMempoolPeerPenaltyThreshold = "mempool.peerPenaltyThreshold"
+	// CfgMempoolBlockBuilder selects the strategy to assemble the transactions of the proposed blocks:
+	// "fee_greedy", "fifo" or "reserved_lanes".
+	CfgMempoolBlockBuilder = "mempool.blockBuilder"
+	// CfgMempoolBlockGasLimit sets the maximum total gas of the regular transactions in a proposed block.
+	// Zero means no limit.
+	CfgMempoolBlockGasLimit = "mempool.blockGasLimit"
+	// CfgMempoolStakeLaneShare sets the share, in percent, of each proposed block reserved for the stake
+	// deposit and withdrawal transactions by the "reserved_lanes" block builder.
+	CfgMempoolStakeLaneShare = "mempool.stakeLaneShare"
+	// CfgMempoolServicePaymentLaneShare sets the share, in percent, of each proposed block reserved for
+	// the service payment transactions by the "reserved_lanes" block builder.
+	CfgMempoolServicePaymentLaneShare = "mempool.servicePaymentLaneShare"
+	// CfgMempoolMinGasPrice sets the node-local minimum effective gas price, in decimal or hexadecimal
+	// wei, of the transactions accepted into the mempool and included in the proposed blocks.
+	CfgMempoolMinGasPrice = "mempool.minGasPrice"
+	// CfgMempoolLocalAddresses lists the local accounts, e.g. the operational accounts of the node,
+	// whose transactions are exempt from the minimum gas price, are never evicted, and are included
+	// in the proposed blocks before the other transactions.
+	CfgMempoolLocalAddresses = "mempool.localAddresses"
+	// CfgMempoolTxOutcomeRetentionSecs sets how long the reason a transaction left the mempool, e.g.
+	// evicted, replaced, expired or included, is retained for the queries.
+	CfgMempoolTxOutcomeRetentionSecs = "mempool.txOutcomeRetentionSecs"
+	// CfgMempoolNumPreverifyWorkers sets the number of workers decoding the gossiped transactions and
+	// verifying their signatures concurrently. Zero means one worker per CPU.
+	CfgMempoolNumPreverifyWorkers = "mempool.numPreverifyWorkers"
+	// CfgMempoolPreverifyQueueSize sets the number of gossiped transactions waiting for the workers,
+	// above which the incoming transactions are dropped.
+	CfgMempoolPreverifyQueueSize = "mempool.preverifyQueueSize"
 
 	// CfgLogLevels sets the log level.
 	CfgLogLevels = "log.levels"
@@ -168,11 +265,20 @@ func init() {
 	viper.SetDefault(CfgConsensusMessageQueueSize, 512)
 	viper.SetDefault(CfgConsensusEdgeNodeVoteQueueSize, 100000)
 	viper.SetDefault(CfgConsensusPassThroughGuardianVote, false)
+	viper.SetDefault(CfgConsensusSigningHistoryPath, "")
+	viper.SetDefault(CfgConsensusVRFProposerEnabled, true)
+	viper.SetDefault(CfgConsensusWALEnabled, true)
+	viper.SetDefault(CfgConsensusWALMaxSize, 64*1024*1024) // 64 MB
 
 	viper.SetDefault(CfgSyncMessageQueueSize, 512)
 	viper.SetDefault(CfgSyncDownloadByHash, false)
 	viper.SetDefault(CfgSyncDownloadByHeader, true)
 
+	viper.SetDefault(CfgSignerRemoteAddress, "")
+	viper.SetDefault(CfgSignerListenAddress, "")
+	viper.SetDefault(CfgSignerTLSServerName, "")
+	viper.SetDefault(CfgSignerTimeoutSecs, 5)
+
 	viper.SetDefault(CfgStorageRollingEnabled, true)
 	viper.SetDefault(CfgStorageStatePruningEnabled, true)
 	viper.SetDefault(CfgStorageStatePruningInterval, 16)
@@ -211,6 +317,31 @@ func init() {
 	viper.SetDefault(CfgRPCGetBlocksHeavyQueryThreshold, 500)
 	viper.SetDefault(CfgRPCMaxHeavyGetBlocksQueryCount, 30)
 	viper.SetDefault(CfgRPCIdleTimeoutSecs, 1)
+	viper.SetDefault(CfgRPCSubscriptionBufferSize, 256)
+	viper.SetDefault(CfgRPCMaxSubscriptionsPerConn, 32)
+
+	viper.SetDefault(CfgMempoolMinReplacementFeeBump, 10)
+	viper.SetDefault(CfgMempoolMaxReplacementsPerAccount, 4)
+	viper.SetDefault(CfgMempoolMaxReplacementsPerBlock, 64)
+	viper.SetDefault(CfgMempoolMaxNumTxs, 25600)
+	viper.SetDefault(CfgMempoolMaxNumTxsPerAccount, 256)
+	viper.SetDefault(CfgMempoolMaxBytes, 32*1024*1024) // 32 MB
+	viper.SetDefault(CfgMempoolMaxNumFutureTxs, 4096)
+	viper.SetDefault(CfgMempoolFutureTxTTLSecs, 60)
+	viper.SetDefault(CfgMempoolJournalEnabled, false)
+	viper.SetDefault(CfgMempoolJournalCompactionIntervalSecs, 600)
+	viper.SetDefault(CfgMempoolGossipAnnounceThreshold, 1024)
+	viper.SetDefault(CfgMempoolPeerTxRateLimit, 200)
+	viper.SetDefault(CfgMempoolPeerPenaltyThreshold, 100)
+	viper.SetDefault(CfgMempoolBlockBuilder, "fee_greedy")
+	viper.SetDefault(CfgMempoolBlockGasLimit, 0)
+	viper.SetDefault(CfgMempoolStakeLaneShare, 10)
+	viper.SetDefault(CfgMempoolServicePaymentLaneShare, 10)
+	viper.SetDefault(CfgMempoolMinGasPrice, "0")
+	viper.SetDefault(CfgMempoolLocalAddresses, []string{})
+	viper.SetDefault(CfgMempoolTxOutcomeRetentionSecs, 3600)
+	viper.SetDefault(CfgMempoolNumPreverifyWorkers, 0)
+	viper.SetDefault(CfgMempoolPreverifyQueueSize, 4096)
 
 	viper.SetDefault(CfgLogLevels, "*:debug")
 	viper.SetDefault(CfgLogPrintSelfID, false)
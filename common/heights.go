@@ -39,6 +39,12 @@ const HeightSupportWrappedScript uint64 = 1 // approximate time: 7pm Sep 28, 202
 // HeightEnableMetachainSupport specifies the block height to enable Script Metachain support (i.e. Mainnet 4.0)
 const HeightEnableMetachainSupport uint64 = 1 // approximate time: 7pm Nov 3, 2022 PT
 
+// HeightEnableDoubleSignSlashing specifies the block height to enable slashing the validators which signed conflicting blocks
+const HeightEnableDoubleSignSlashing uint64 = 30000000
+
+// HeightEnableVRFProposer specifies the block height from which the block proposers prove their eligibility with a VRF
+const HeightEnableVRFProposer uint64 = 30000000
+
 // CheckpointInterval defines the interval between checkpoints.
 const CheckpointInterval = int64(100)
 
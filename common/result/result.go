@@ -4,6 +4,10 @@ import "fmt"
 
 type Info map[string]interface{}
 
+// InfoExpectedSequence is the key of the next expected account sequence in the Info of
+// a result with the CodeInvalidSequence error code
+const InfoExpectedSequence = "expectedSequence"
+
 // Result represents the result of a function execution
 type Result struct {
 	Code    ErrorCode
@@ -37,6 +41,17 @@ func (res Result) WithErrorCode(code ErrorCode) Result {
 	return res
 }
 
+// WithInfo attaches the extra information to the result
+func (res Result) WithInfo(key string, value interface{}) Result {
+	info := make(Info)
+	for k, v := range res.Info {
+		info[k] = v
+	}
+	info[key] = value
+	res.Info = info
+	return res
+}
+
 // WithMessage appends the result's message with the given message
 func (res Result) WithMessage(message string) Result {
 	res.Message += message
@@ -409,6 +409,9 @@ const (
 
 	// ChannelIDAggregatedEliteEdgeNodeVotes indicates the channel for Elite Edge Node aggregated vote messages
 	ChannelIDAggregatedEliteEdgeNodeVotes
+
+	// ChannelIDDoubleSignProof indicates the channel for the proofs of validators signing conflicting blocks
+	ChannelIDDoubleSignProof
 )
 
 // P2POptEnum defines the p2p network
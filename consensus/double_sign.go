@@ -0,0 +1,241 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/dispatcher"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/rlp"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxNumDoubleSignProofs is the maximum number of double sign proofs waiting to be included in a block
+const maxNumDoubleSignProofs = 256
+
+// DoubleSignProofPool keeps the double sign proofs waiting to be included in a block, at most one per
+// offence, i.e. per offender and height.
+type DoubleSignProofPool struct {
+	mu     *sync.Mutex
+	proofs map[common.Hash]*core.DoubleSignProof // offence key -> proof
+}
+
+// NewDoubleSignProofPool creates a new instance of DoubleSignProofPool.
+func NewDoubleSignProofPool() *DoubleSignProofPool {
+	return &DoubleSignProofPool{
+		mu:     &sync.Mutex{},
+		proofs: make(map[common.Hash]*core.DoubleSignProof),
+	}
+}
+
+// Add adds the proof to the pool, and returns false if the offence is already known or the pool is full.
+func (p *DoubleSignProofPool) Add(proof *core.DoubleSignProof) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := proof.OffenceKey()
+	if _, ok := p.proofs[key]; ok {
+		return false
+	}
+	if len(p.proofs) >= maxNumDoubleSignProofs {
+		return false
+	}
+	p.proofs[key] = proof
+	return true
+}
+
+// Remove removes the proof of the given offence from the pool.
+func (p *DoubleSignProofPool) Remove(offenceKey common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.proofs, offenceKey)
+}
+
+// Size returns the number of proofs in the pool.
+func (p *DoubleSignProofPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.proofs)
+}
+
+// Prune removes the proofs of the offences at heights below minHeight.
+func (p *DoubleSignProofPool) Prune(minHeight uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, proof := range p.proofs {
+		if proof.Height() < minHeight {
+			delete(p.proofs, key)
+		}
+	}
+}
+
+// GetAll returns the proofs in the pool, ordered by height.
+func (p *DoubleSignProofPool) GetAll() []*core.DoubleSignProof {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ret := make([]*core.DoubleSignProof, 0, len(p.proofs))
+	for _, proof := range p.proofs {
+		ret = append(ret, proof)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Height() != ret[j].Height() {
+			return ret[i].Height() < ret[j].Height()
+		}
+		return ret[i].Offender.Hex() < ret[j].Offender.Hex()
+	})
+	return ret
+}
+
+// GetDoubleSignProofs returns the double sign proofs to be included in the next block.
+func (e *ConsensusEngine) GetDoubleSignProofs() []*core.DoubleSignProof {
+	e.doubleSignProofs.Prune(e.minDoubleSignProofHeight())
+	return e.doubleSignProofs.GetAll()
+}
+
+// minDoubleSignProofHeight returns the lowest height of the offences which can still be slashed.
+func (e *ConsensusEngine) minDoubleSignProofHeight() uint64 {
+	lfbHeight := e.GetLastFinalizedBlock().Height
+	if lfbHeight <= core.MaxDoubleSignProofAge {
+		return 0
+	}
+	return lfbHeight - core.MaxDoubleSignProofAge
+}
+
+// removeSlashedDoubleSignProofs removes the proofs whose offences are slashed by the newly finalized
+// blocks, i.e. the given block and its ancestors above the previous last finalized height.
+func (e *ConsensusEngine) removeSlashedDoubleSignProofs(block *core.ExtendedBlock, prevFinalizedHeight uint64) {
+	if e.doubleSignProofs.Size() == 0 {
+		return
+	}
+	for block != nil && block.Height > prevFinalizedHeight {
+		for _, rawTx := range block.Txs {
+			tx, err := types.TxFromBytes(rawTx)
+			if err != nil {
+				continue
+			}
+			if slashTx, ok := tx.(*types.DoubleSignSlashTx); ok {
+				e.doubleSignProofs.Remove(slashTx.Proof.OffenceKey())
+			}
+		}
+		parent, err := e.chain.FindBlock(block.Parent)
+		if err != nil {
+			return
+		}
+		block = parent
+	}
+}
+
+// hasValidatorStake returns whether the address has validator stake as of the last finalized block.
+// The offenders without validator stake cannot be slashed, so their proofs are not worth keeping.
+func (e *ConsensusEngine) hasValidatorStake(address common.Address) bool {
+	vcp, err := e.ledger.GetFinalizedValidatorCandidatePool(e.GetLastFinalizedBlock().Hash(), false)
+	if err != nil || vcp == nil {
+		return false
+	}
+	return vcp.FindStakeDelegate(address) != nil
+}
+
+// checkDoubleSignVote checks whether the voter also voted for another block at the same height in
+// the same epoch.
+func (e *ConsensusEngine) checkDoubleSignVote(vote core.Vote) {
+	block, err := e.chain.FindBlock(vote.Block)
+	if err != nil {
+		// The block has not been received yet, its votes are checked once it is processed.
+		return
+	}
+	for _, other := range e.chain.FindBlocksByHeight(block.Height) {
+		if other.Hash() == block.Hash() {
+			continue
+		}
+		for _, otherVote := range e.chain.FindVotesByHash(other.Hash()).Votes() {
+			if otherVote.ID != vote.ID || otherVote.Epoch != vote.Epoch {
+				continue
+			}
+			proof := core.NewVoteDoubleSignProof(vote, block.BlockHeader, otherVote, other.BlockHeader)
+			e.addDoubleSignProof(proof)
+		}
+	}
+}
+
+// checkDoubleSignBlock checks whether the proposer of the block proposed another block at the same
+// height in the same epoch, and whether the votes received before the block conflict with other votes.
+func (e *ConsensusEngine) checkDoubleSignBlock(block *core.ExtendedBlock) {
+	for _, other := range e.chain.FindBlocksByHeight(block.Height) {
+		if other.Hash() == block.Hash() {
+			continue
+		}
+		if other.Proposer != block.Proposer || other.Epoch != block.Epoch {
+			continue
+		}
+		if other.Signature == nil || !other.Signature.Verify(other.SignBytes(), other.Proposer) {
+			continue
+		}
+		proof := core.NewProposalDoubleSignProof(block.BlockHeader, other.BlockHeader)
+		e.addDoubleSignProof(proof)
+	}
+
+	for _, vote := range e.chain.FindVotesByHash(block.Hash()).Votes() {
+		e.checkDoubleSignVote(vote)
+	}
+}
+
+// handleDoubleSignProof handles a double sign proof received from a peer.
+func (e *ConsensusEngine) handleDoubleSignProof(proof *core.DoubleSignProof) {
+	if res := proof.Validate(e.chain.ChainID); res.IsError() {
+		e.logger.WithFields(log.Fields{
+			"proof": proof,
+			"err":   res.String(),
+		}).Warn("Ignoring invalid double sign proof")
+		return
+	}
+	e.addDoubleSignProof(proof)
+}
+
+// addDoubleSignProof adds the proof to the pool, and gossips it if the offence was not known yet and
+// the offender has validator stake.
+func (e *ConsensusEngine) addDoubleSignProof(proof *core.DoubleSignProof) {
+	if proof.Height() < e.minDoubleSignProofHeight() {
+		return
+	}
+	if !e.hasValidatorStake(proof.Offender) {
+		e.logger.WithFields(log.Fields{
+			"offender": proof.Offender.Hex(),
+			"height":   proof.Height(),
+		}).Debug("Ignoring double sign proof against an address without validator stake")
+		return
+	}
+	if !e.doubleSignProofs.Add(proof) {
+		return
+	}
+
+	e.logger.WithFields(log.Fields{
+		"offender": proof.Offender.Hex(),
+		"height":   proof.Height(),
+		"proof":    proof,
+	}).Warn("Detected a validator signing conflicting blocks")
+
+	e.broadcastDoubleSignProof(proof)
+}
+
+func (e *ConsensusEngine) broadcastDoubleSignProof(proof *core.DoubleSignProof) {
+	if e.replaying {
+		// The proof is kept in the pool, and proposed once the node is live again.
+		return
+	}
+	payload, err := rlp.EncodeToBytes(proof)
+	if err != nil {
+		e.logger.WithFields(log.Fields{"proof": proof}).Error("Failed to encode double sign proof")
+		return
+	}
+	proofMsg := dispatcher.DataResponse{
+		ChannelID: common.ChannelIDDoubleSignProof,
+		Payload:   payload,
+	}
+	e.dispatcher.SendData([]string{}, proofMsg)
+}
@@ -0,0 +1,214 @@
+package consensus
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	dp "github.com/scripttoken/script/dispatcher"
+	"github.com/scripttoken/script/ledger/types"
+	p2psim "github.com/scripttoken/script/p2p/simulation"
+	p2ptypes "github.com/scripttoken/script/p2p/types"
+	p2plmsg "github.com/scripttoken/script/p2pl/messenger"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/scripttoken/script/store/kvstore"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestDoubleSignProof(privKey *crypto.PrivateKey, height uint64) *core.DoubleSignProof {
+	headers := []*core.BlockHeader{}
+	for i := int64(0); i < 2; i++ {
+		header := &core.BlockHeader{
+			ChainID:   "testchain",
+			Epoch:     height,
+			Height:    height,
+			Parent:    common.HexToHash("a0"),
+			Timestamp: big.NewInt(i),
+			Proposer:  privKey.PublicKey().Address(),
+		}
+		header.Signature, _ = privKey.Sign(header.SignBytes())
+		headers = append(headers, header)
+	}
+	return core.NewProposalDoubleSignProof(headers[0], headers[1])
+}
+
+func TestDoubleSignProofPool(t *testing.T) {
+	require := require.New(t)
+
+	privKey1, _, _ := crypto.GenerateKeyPair()
+	privKey2, _, _ := crypto.GenerateKeyPair()
+
+	pool := NewDoubleSignProofPool()
+	require.True(pool.Add(createTestDoubleSignProof(privKey1, 20)))
+	require.True(pool.Add(createTestDoubleSignProof(privKey2, 10)))
+	require.True(pool.Add(createTestDoubleSignProof(privKey1, 30)))
+
+	// Only one proof is kept per offence
+	require.False(pool.Add(createTestDoubleSignProof(privKey1, 20)))
+
+	proofs := pool.GetAll()
+	require.Equal(3, len(proofs))
+	require.Equal(uint64(10), proofs[0].Height())
+	require.Equal(uint64(20), proofs[1].Height())
+	require.Equal(uint64(30), proofs[2].Height())
+
+	pool.Remove(createTestDoubleSignProof(privKey1, 30).OffenceKey())
+	require.Equal(2, pool.Size())
+	require.True(pool.Add(createTestDoubleSignProof(privKey1, 30)))
+
+	pool.Prune(20)
+	proofs = pool.GetAll()
+	require.Equal(2, len(proofs))
+	require.Equal(uint64(20), proofs[0].Height())
+	require.Equal(privKey1.PublicKey().Address(), proofs[0].Offender)
+}
+
+func TestDoubleSignProofRequiresValidatorStake(t *testing.T) {
+	require := require.New(t)
+
+	validatorKey, _, _ := crypto.GenerateKeyPair()
+	otherKey, _, _ := crypto.GenerateKeyPair()
+	engine, _ := newDoubleSignTestEngine(validatorKey, "ds_stake_root", p2psim.NewSimnet())
+
+	// The proofs against the addresses without validator stake could never be slashed
+	engine.handleDoubleSignProof(createTestDoubleSignProof(otherKey, 5))
+	require.Equal(0, engine.doubleSignProofs.Size())
+
+	engine.handleDoubleSignProof(createTestDoubleSignProof(validatorKey, 5))
+	require.Equal(1, engine.doubleSignProofs.Size())
+}
+
+func TestSlashedDoubleSignProofsRemoved(t *testing.T) {
+	require := require.New(t)
+
+	validatorKey, _, _ := crypto.GenerateKeyPair()
+	engine, chain := newDoubleSignTestEngine(validatorKey, "ds_slashed_root", p2psim.NewSimnet())
+
+	proof1 := createTestDoubleSignProof(validatorKey, 5)
+	proof2 := createTestDoubleSignProof(validatorKey, 6)
+	proof3 := createTestDoubleSignProof(validatorKey, 7)
+	for _, proof := range []*core.DoubleSignProof{proof1, proof2, proof3} {
+		engine.handleDoubleSignProof(proof)
+	}
+	require.Equal(3, engine.doubleSignProofs.Size())
+
+	// The slash txs of proof1 and proof2 are included in two blocks finalized at once
+	b1 := newDoubleSignTestBlock(t, chain.Root().BlockHeader, validatorKey, proof1)
+	b2 := newDoubleSignTestBlock(t, b1.BlockHeader, validatorKey, proof2)
+	_, err := chain.AddBlock(b1)
+	require.Nil(err)
+	eb2, err := chain.AddBlock(b2)
+	require.Nil(err)
+
+	engine.removeSlashedDoubleSignProofs(eb2, chain.Root().Height)
+	proofs := engine.doubleSignProofs.GetAll()
+	require.Equal(1, len(proofs))
+	require.Equal(proof3.OffenceKey(), proofs[0].OffenceKey())
+}
+
+func TestDoubleSignProofNotBroadcastWhileReplaying(t *testing.T) {
+	require := require.New(t)
+
+	validatorKey, _, _ := crypto.GenerateKeyPair()
+	interceptor := &doubleSignTestInterceptor{messages: make(chan p2ptypes.Message, 10)}
+	simnet := p2psim.NewSimnetWithHandler(interceptor)
+	engine, _ := newDoubleSignTestEngine(validatorKey, "ds_replay_root", simnet)
+	simnet.AddEndpoint("peer1")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	simnet.Start(ctx)
+
+	// The proofs found while replaying the WAL were gossiped before the restart
+	engine.replaying = true
+	engine.handleDoubleSignProof(createTestDoubleSignProof(validatorKey, 5))
+	engine.replaying = false
+	require.Equal(1, engine.doubleSignProofs.Size())
+	select {
+	case msg := <-interceptor.messages:
+		require.Fail("Unexpected message", "%v", msg)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	engine.handleDoubleSignProof(createTestDoubleSignProof(validatorKey, 6))
+	require.Equal(2, engine.doubleSignProofs.Size())
+	select {
+	case msg := <-interceptor.messages:
+		require.Equal(common.ChannelIDDoubleSignProof, msg.Content.(dp.DataResponse).ChannelID)
+	case <-time.After(time.Second):
+		require.Fail("Double sign proof not broadcast")
+	}
+}
+
+// doubleSignTestInterceptor collects the messages sent over the simulated network.
+type doubleSignTestInterceptor struct {
+	messages chan p2ptypes.Message
+}
+
+func (i *doubleSignTestInterceptor) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{common.ChannelIDDoubleSignProof}
+}
+
+func (i *doubleSignTestInterceptor) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	return p2ptypes.Message{PeerID: peerID, ChannelID: channelID, Content: rawMessageBytes}, nil
+}
+
+func (i *doubleSignTestInterceptor) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+func (i *doubleSignTestInterceptor) HandleMessage(msg p2ptypes.Message) error {
+	i.messages <- msg
+	return nil
+}
+
+// doubleSignTestLedger only provides the validator candidate pool.
+type doubleSignTestLedger struct {
+	core.Ledger
+	vcp *core.ValidatorCandidatePool
+}
+
+func (l *doubleSignTestLedger) GetFinalizedValidatorCandidatePool(blockHash common.Hash, isNext bool) (*core.ValidatorCandidatePool, error) {
+	return l.vcp, nil
+}
+
+func newDoubleSignTestEngine(validatorKey *crypto.PrivateKey, rootName string, simnet *p2psim.Simnet) (*ConsensusEngine, *blockchain.Chain) {
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock(rootName, "")
+	chain := blockchain.NewChain("testchain", store, root)
+
+	dispatcher := dp.NewDispatcher(simnet.AddEndpoint("peer0"), (*p2plmsg.Messenger)(nil))
+	engine := NewConsensusEngine(newTestSigner(validatorKey), store, chain, dispatcher, MockValidatorManager{PrivKey: validatorKey})
+
+	vcp := &core.ValidatorCandidatePool{}
+	validator := validatorKey.PublicKey().Address()
+	if err := vcp.DepositStake(validator, validator, core.MinValidatorStakeDeposit, 0); err != nil {
+		panic(err)
+	}
+	engine.SetLedger(&doubleSignTestLedger{vcp: vcp})
+	return engine, chain
+}
+
+func newDoubleSignTestBlock(t *testing.T, parent *core.BlockHeader, proposerKey *crypto.PrivateKey, proof *core.DoubleSignProof) *core.Block {
+	slashTx := &types.DoubleSignSlashTx{
+		Proposer: types.TxInput{Address: proposerKey.PublicKey().Address()},
+		Proof:    *proof,
+	}
+	rawTx, err := types.TxToBytes(slashTx)
+	require.Nil(t, err)
+
+	block := core.NewBlock()
+	block.ChainID = parent.ChainID
+	block.Height = parent.Height + 1
+	block.Epoch = parent.Epoch + 1
+	block.Parent = parent.Hash()
+	block.Proposer = proposerKey.PublicKey().Address()
+	block.Timestamp = big.NewInt(int64(block.Height))
+	block.Txs = []common.Bytes{rawTx}
+	return block
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/common/util"
 	"github.com/scripttoken/script/core"
-	"github.com/scripttoken/script/crypto/bls"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -22,8 +21,7 @@ const (
 type EliteEdgeNodeEngine struct {
 	logger *log.Entry
 
-	engine  *ConsensusEngine
-	privKey *bls.SecretKey
+	engine *ConsensusEngine
 
 	voteBookkeeper *EENVoteBookkeeper
 
@@ -40,11 +38,10 @@ type EliteEdgeNodeEngine struct {
 	mu          *sync.Mutex
 }
 
-func NewEliteEdgeNodeEngine(c *ConsensusEngine, privateKey *bls.SecretKey) *EliteEdgeNodeEngine {
+func NewEliteEdgeNodeEngine(c *ConsensusEngine) *EliteEdgeNodeEngine {
 	return &EliteEdgeNodeEngine{
-		logger:  util.GetLoggerForModule("elite edge node"),
-		engine:  c,
-		privKey: privateKey,
+		logger: util.GetLoggerForModule("elite edge node"),
+		engine: c,
 
 		voteBookkeeper: CreateEENVoteBookkeeper(DefaultMaxNumVotesCached),
 
@@ -2,15 +2,13 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/scripttoken/script/crypto/bls"
-
 	"github.com/scripttoken/script/blockchain"
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/common/result"
@@ -32,7 +30,7 @@ var _ core.ConsensusEngine = (*ConsensusEngine)(nil)
 type ConsensusEngine struct {
 	logger *log.Entry
 
-	privateKey *crypto.PrivateKey
+	signer core.Signer
 
 	chain            *blockchain.Chain
 	dispatcher       *dispatcher.Dispatcher
@@ -60,15 +58,23 @@ type ConsensusEngine struct {
 	blockProcessed bool
 
 	state *State
+
+	doubleSignProofs *DoubleSignProofPool
+
+	signingHistory *SigningHistory
+
+	wal                *WAL
+	replaying          bool // Whether the messages in the WAL are being replayed
+	walTruncatePending bool // Whether a block was finalized while replaying the WAL
 }
 
 // NewConsensusEngine creates a instance of ConsensusEngine.
-func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *blockchain.Chain, dispatcher *dispatcher.Dispatcher, validatorManager core.ValidatorManager) *ConsensusEngine {
+func NewConsensusEngine(signer core.Signer, db store.Store, chain *blockchain.Chain, dispatcher *dispatcher.Dispatcher, validatorManager core.ValidatorManager) *ConsensusEngine {
 	e := &ConsensusEngine{
 		chain:      chain,
 		dispatcher: dispatcher,
 
-		privateKey: privateKey,
+		signer: signer,
 
 		incoming:        make(chan interface{}, viper.GetInt(common.CfgConsensusMessageQueueSize)),
 		finalizedBlocks: make(chan *core.Block, viper.GetInt(common.CfgConsensusMessageQueueSize)),
@@ -82,17 +88,15 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *bl
 
 		voteTimerReady: false,
 		blockProcessed: false,
+
+		doubleSignProofs: NewDoubleSignProofPool(),
 	}
 
 	logger = util.GetLoggerForModule("consensus")
 	e.logger = logger
 
-	blsKey, err := bls.GenKey(strings.NewReader(common.Bytes2Hex(privateKey.PublicKey().ToBytes())))
-	if err != nil {
-		e.logger.Panic(err)
-	}
-	e.guardian = NewGuardianEngine(e, blsKey)
-	e.eliteEdgeNode = NewEliteEdgeNodeEngine(e, blsKey)
+	e.guardian = NewGuardianEngine(e, signer)
+	e.eliteEdgeNode = NewEliteEdgeNodeEngine(e)
 
 	e.logger.WithFields(log.Fields{"state": e.state}).Info("Starting state")
 
@@ -103,6 +107,26 @@ func (e *ConsensusEngine) SetLedger(ledger core.Ledger) {
 	e.ledger = ledger
 }
 
+// SetSigningHistory sets the slashing protection database consulted before signing votes and
+// proposals. Without it the engine only relies on the consensus state to avoid double signing.
+func (e *ConsensusEngine) SetSigningHistory(history *SigningHistory) {
+	e.signingHistory = history
+}
+
+// checkAndRecordSigning returns an error if signing the block could conflict with a previous signature.
+func (e *ConsensusEngine) checkAndRecordSigning(record SigningRecord) error {
+	if e.signingHistory == nil {
+		return nil
+	}
+	return e.signingHistory.CheckAndRecord(record)
+}
+
+// SetWAL sets the write-ahead log replayed on start to restore the state of the engine within
+// the epoch.
+func (e *ConsensusEngine) SetWAL(wal *WAL) {
+	e.wal = wal
+}
+
 // GetLedger returns the ledger instance attached to the consensus engine
 func (e *ConsensusEngine) GetLedger() core.Ledger {
 	return e.ledger
@@ -110,12 +134,12 @@ func (e *ConsensusEngine) GetLedger() core.Ledger {
 
 // ID returns the identifier of current node.
 func (e *ConsensusEngine) ID() string {
-	return e.privateKey.PublicKey().Address().Hex()
+	return e.signer.PublicKey().Address().Hex()
 }
 
-// PrivateKey returns the private key
-func (e *ConsensusEngine) PrivateKey() *crypto.PrivateKey {
-	return e.privateKey
+// Signer returns the signer of the node key
+func (e *ConsensusEngine) Signer() core.Signer {
+	return e.signer
 }
 
 // Chain return a pointer to the underlying chain store.
@@ -158,6 +182,9 @@ func (e *ConsensusEngine) Start(ctx context.Context) {
 
 	e.checkSyncStatus()
 
+	e.enterEpoch()
+	e.replayWAL()
+
 	e.wg.Add(1)
 	go e.mainLoop()
 }
@@ -260,7 +287,6 @@ func (e *ConsensusEngine) mainLoop() {
 	defer e.wg.Done()
 
 	for {
-		e.enterEpoch()
 		e.propose()
 	Epoch:
 		for {
@@ -274,12 +300,14 @@ func (e *ConsensusEngine) mainLoop() {
 					break Epoch
 				}
 			case <-e.voteTimer.C:
+				e.recordWAL(WALMessageVoteTimeout, nil)
 				e.voteTimerReady = true
 				if e.blockProcessed {
 					e.vote()
 				}
 			case <-e.epochTimer.C:
 				e.logger.WithFields(log.Fields{"e.epoch": e.GetEpoch()}).Debug("Epoch timeout. Repeating epoch")
+				e.recordWAL(WALMessageEpochTimeout, nil)
 				e.vote()
 				break Epoch
 			case <-e.guardianTimer.C:
@@ -300,6 +328,7 @@ func (e *ConsensusEngine) mainLoop() {
 				e.eliteEdgeNode.StartNewRound()
 			}
 		}
+		e.enterEpoch()
 	}
 }
 
@@ -340,13 +369,19 @@ func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 	switch m := msg.(type) {
 	case core.Vote:
 		e.logger.WithFields(log.Fields{"vote": m}).Debug("Received vote")
-		endEpoch = e.handleVote(m)
+		if e.validateVote(m) {
+			e.recordReceivedMessage(WALMessageVote, m)
+			endEpoch = e.handleValidatedVote(m)
+		}
 		e.checkCC(m.Block)
 		return endEpoch
 	case *core.Block:
 		e.logger.WithFields(log.Fields{
 			"block": m.BlockHeader,
 		}).Debug("Received block")
+		if e.shouldRecordBlock(m) {
+			e.recordReceivedMessage(WALMessageBlock, m)
+		}
 		e.handleBlock(m)
 	case *core.AggregatedVotes:
 		// e.logger.WithFields(log.Fields{"guardian vote": m}).Debug("Received guardian vote")
@@ -357,6 +392,9 @@ func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 	case *core.AggregatedEENVotes:
 		// e.logger.WithFields(log.Fields{"aggregated elite edge node vote": m}).Debug("Received agggregated elite edge node vote")
 		e.handleAggregatedEliteEdgeNodeVote(m)
+	case *core.DoubleSignProof:
+		e.logger.WithFields(log.Fields{"proof": m}).Debug("Received double sign proof")
+		e.handleDoubleSignProof(m)
 	default:
 		// Should not happen.
 		log.Errorf("Unknown message type: %v", m)
@@ -498,7 +536,16 @@ func (e *ConsensusEngine) validateBlock(block *core.Block, parent *core.Extended
 		}
 	}
 
-	if !e.shouldProposeByID(block.Parent, block.Epoch, block.Proposer.Hex()) {
+	if vrfManager, ok := e.vrfValidatorManager(block.Height); ok {
+		if err := vrfManager.VerifyEligibility(parent.BlockHeader, block.BlockHeader); err != nil {
+			e.logger.WithFields(log.Fields{
+				"block.Epoch":    block.Epoch,
+				"block.proposer": block.Proposer.Hex(),
+				"error":          err,
+			}).Warn("Invalid proposer eligibility proof")
+			return result.Error("Invalid proposer")
+		}
+	} else if !e.shouldProposeByID(block.Parent, block.Epoch, block.Proposer.Hex()) {
 		e.logger.WithFields(log.Fields{
 			"block.Epoch":    block.Epoch,
 			"block.proposer": block.Proposer.Hex(),
@@ -741,6 +788,8 @@ func (e *ConsensusEngine) handleNormalBlock(eb *core.ExtendedBlock) {
 	}
 	validateBlockTime := time.Since(start1)
 
+	e.checkDoubleSignBlock(eb)
+
 	for _, vote := range block.HCC.Votes.Votes() {
 		e.handleVote(vote)
 	}
@@ -820,7 +869,7 @@ func (e *ConsensusEngine) handleNormalBlock(eb *core.ExtendedBlock) {
 }
 
 func (e *ConsensusEngine) shouldVote(block common.Hash) bool {
-	return e.shouldVoteByID(e.privateKey.PublicKey().Address(), block)
+	return e.shouldVoteByID(e.signer.PublicKey().Address(), block)
 }
 
 func (e *ConsensusEngine) shouldVoteByID(id common.Address, block common.Hash) bool {
@@ -830,6 +879,11 @@ func (e *ConsensusEngine) shouldVoteByID(id common.Address, block common.Hash) b
 }
 
 func (e *ConsensusEngine) vote() {
+	if e.replaying {
+		// The votes signed before the restart are restored from the WAL.
+		return
+	}
+
 	tip := e.GetTipToVote()
 
 	if !e.shouldVote(tip.Hash()) {
@@ -837,6 +891,7 @@ func (e *ConsensusEngine) vote() {
 	}
 
 	var vote core.Vote
+	var err error
 	lastVote := e.state.GetLastVote()
 	shouldRepeatVote := false
 	if lastVote.Height != 0 && lastVote.Height >= tip.Height {
@@ -865,10 +920,16 @@ func (e *ConsensusEngine) vote() {
 			log.Panic(err)
 		}
 		// Recreating vote so that it has updated epoch and signature.
-		vote = e.createVote(block.Block)
+		vote, err = e.createVote(block.Block)
 	} else {
-		vote = e.createVote(tip.Block)
-		e.state.SetLastVote(vote)
+		vote, err = e.createVote(tip.Block)
+		if err == nil {
+			e.state.SetLastVote(vote)
+		}
+	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Refused to sign vote")
+		return
 	}
 	e.logger.WithFields(log.Fields{
 		"vote": vote,
@@ -881,6 +942,9 @@ func (e *ConsensusEngine) vote() {
 }
 
 func (e *ConsensusEngine) broadcastVote(vote core.Vote) {
+	if e.replaying {
+		return
+	}
 	payload, err := rlp.EncodeToBytes(vote)
 	if err != nil {
 		e.logger.WithFields(log.Fields{"vote": vote}).Error("Failed to encode vote")
@@ -893,15 +957,29 @@ func (e *ConsensusEngine) broadcastVote(vote core.Vote) {
 	e.dispatcher.SendData([]string{}, voteMsg)
 }
 
-func (e *ConsensusEngine) createVote(block *core.Block) core.Vote {
+func (e *ConsensusEngine) createVote(block *core.Block) (core.Vote, error) {
 	vote := core.Vote{
 		Block:  block.Hash(),
 		Height: block.Height,
-		ID:     e.privateKey.PublicKey().Address(),
+		ID:     e.signer.PublicKey().Address(),
 		Epoch:  e.GetEpoch(),
 	}
-	vote.Sign(e.privateKey)
-	return vote
+	err := e.checkAndRecordSigning(SigningRecord{
+		Type:   SigningTypeVote,
+		Height: vote.Height,
+		Epoch:  vote.Epoch,
+		Hash:   vote.Block,
+	})
+	if err != nil {
+		return core.Vote{}, err
+	}
+	if err := e.signer.SignVote(&vote); err != nil {
+		return core.Vote{}, err
+	}
+	if err := e.recordWAL(WALMessageSignedVote, vote); err != nil {
+		return core.Vote{}, err
+	}
+	return vote, nil
 }
 
 func (e *ConsensusEngine) validateVote(vote core.Vote) bool {
@@ -919,13 +997,18 @@ func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 	if !e.validateVote(vote) {
 		return
 	}
+	return e.handleValidatedVote(vote)
+}
 
+func (e *ConsensusEngine) handleValidatedVote(vote core.Vote) (endEpoch bool) {
 	// Save vote.
 	err := e.state.AddVote(&vote)
 	if err != nil {
 		e.logger.WithFields(log.Fields{"err": err}).Panic("Failed to add vote")
 	}
 
+	e.checkDoubleSignVote(vote)
+
 	// Update epoch.
 	lfb := e.state.GetLastFinalizedBlock()
 	nextValidators := e.validatorManager.GetNextValidatorSet(lfb.Hash())
@@ -951,15 +1034,19 @@ func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 				}
 			}
 
+			fields := log.Fields{
+				"e.epoch":      e.GetEpoch,
+				"nextEpoch":    nextEpoch,
+				"epochVoteSet": currentEpochVotes,
+			}
 			tip := e.GetTipToExtend()
-			expectedProposer := e.validatorManager.GetNextProposer(tip.Hash(), nextEpoch)
+			if _, ok := e.vrfValidatorManager(tip.Height + 1); !ok {
+				// Proposers proving their eligibility with a VRF are not known in advance.
+				expectedProposer := e.validatorManager.GetNextProposer(tip.Hash(), nextEpoch)
+				fields["expectedProposer"] = expectedProposer.ID().Hex()
+			}
 
-			e.logger.WithFields(log.Fields{
-				"e.epoch":          e.GetEpoch,
-				"nextEpoch":        nextEpoch,
-				"epochVoteSet":     currentEpochVotes,
-				"expectedProposer": expectedProposer.ID().Hex(),
-			}).Debug("Majority votes for current epoch. Moving to new epoch")
+			e.logger.WithFields(fields).Debug("Majority votes for current epoch. Moving to new epoch")
 			e.state.SetEpoch(nextEpoch)
 
 			e.checkSyncStatus()
@@ -1036,7 +1123,7 @@ func (e *ConsensusEngine) GetTip(includePendingBlockingLeaf bool) *core.Extended
 			continue
 		}
 
-		if curr.Height > candidate.Height {
+		if curr.Height > candidate.Height || (curr.Height == candidate.Height && e.isPreferredTip(curr, candidate)) {
 			candidate = curr
 		}
 
@@ -1054,6 +1141,16 @@ func (e *ConsensusEngine) GetTip(includePendingBlockingLeaf bool) *core.Extended
 	return candidate
 }
 
+// isPreferredTip returns whether the block is preferred over the candidate of the same height. Several validators can
+// be eligible to propose with a VRF in an epoch, in which case the lowest VRF output wins, so that the validators
+// extend and vote for the same block.
+func (e *ConsensusEngine) isPreferredTip(block *core.ExtendedBlock, candidate *core.ExtendedBlock) bool {
+	if _, ok := e.vrfValidatorManager(block.Height); !ok {
+		return false
+	}
+	return hasLowerVRFOutput(block.BlockHeader, candidate.BlockHeader)
+}
+
 func (e *ConsensusEngine) handleGuardianVote(v *core.AggregatedVotes) {
 	e.guardian.HandleVote(v)
 }
@@ -1143,6 +1240,7 @@ func (e *ConsensusEngine) finalizeBlock(block *core.ExtendedBlock) error {
 
 	e.logger.WithFields(log.Fields{"block.Hash": block.Hash().Hex(), "block.Height": block.Height}).Info("Finalizing block")
 
+	prevFinalizedHeight := e.state.GetLastFinalizedBlock().Height
 	e.state.SetLastFinalizedBlock(block)
 	e.ledger.FinalizeState(block.Height, block.StateHash)
 
@@ -1157,9 +1255,14 @@ func (e *ConsensusEngine) finalizeBlock(block *core.ExtendedBlock) error {
 	// duplicate TX in fork.
 	e.chain.AddTxsToIndex(block, true)
 
+	// The slashed offences need not be proposed again
+	e.removeSlashedDoubleSignProofs(block, prevFinalizedHeight)
+
+	e.truncateWAL()
+
 	// Guardians and Elite Edge Nodes to vote for checkpoint blocks.
 	if common.IsCheckPointHeight(block.Height) {
-		e.guardian.StartNewBlock(block.Hash())
+		e.guardian.StartNewBlock(block.Hash(), block.Height)
 		e.eliteEdgeNode.StartNewBlock(block.Hash())
 		e.resetGuardianTimer()
 	}
@@ -1182,6 +1285,25 @@ func (e *ConsensusEngine) shouldPropose(tip *core.ExtendedBlock, epoch uint64) b
 		}).Debug("shouldPropose=false: epoch is behind")
 		return false
 	}
+	if vrfManager, ok := e.vrfValidatorManager(tip.Height + 1); ok {
+		proof, err := vrfManager.ProveEligibility(tip.BlockHeader, epoch, e.signer)
+		if err != nil {
+			e.logger.WithFields(log.Fields{
+				"tip":   tip.Hash().Hex(),
+				"epoch": epoch,
+				"error": err,
+			}).Warn("shouldPropose=false: failed to prove eligibility")
+			return false
+		}
+		if proof == nil {
+			e.logger.WithFields(log.Fields{
+				"tip":   tip.Hash().Hex(),
+				"epoch": epoch,
+			}).Debug("shouldPropose=false: not eligible")
+			return false
+		}
+		return true
+	}
 	if !e.shouldProposeByID(tip.Hash(), epoch, e.ID()) {
 		e.logger.WithFields(log.Fields{
 			"tip":   tip.Hash().Hex(),
@@ -1192,6 +1314,16 @@ func (e *ConsensusEngine) shouldPropose(tip *core.ExtendedBlock, epoch uint64) b
 	return true
 }
 
+// vrfValidatorManager returns the validator manager if the proposer of the block at the given height proves its
+// eligibility with a VRF.
+func (e *ConsensusEngine) vrfValidatorManager(height uint64) (core.VRFValidatorManager, bool) {
+	vrfManager, ok := e.validatorManager.(core.VRFValidatorManager)
+	if !ok || !vrfManager.IsVRFEnabled(height) {
+		return nil, false
+	}
+	return vrfManager, true
+}
+
 func (e *ConsensusEngine) shouldIncludeValidatorUpdateTxs(tip *core.ExtendedBlock) bool {
 	// Check if majority has greater block height.
 	epochVotes, err := e.state.GetEpochVotes()
@@ -1254,8 +1386,18 @@ func (e *ConsensusEngine) createProposal(shouldIncludeValidatorUpdateTxs bool) (
 	block.Epoch = e.GetEpoch()
 	block.Parent = tip.Hash()
 	block.Height = tip.Height + 1
-	block.Proposer = e.privateKey.PublicKey().Address()
+	block.Proposer = e.signer.PublicKey().Address()
 	block.Timestamp = big.NewInt(time.Now().Unix())
+	if vrfManager, ok := e.vrfValidatorManager(block.Height); ok {
+		proof, err := vrfManager.ProveEligibility(tip.BlockHeader, block.Epoch, e.signer)
+		if err != nil {
+			return core.Proposal{}, fmt.Errorf("Failed to prove eligibility: %v", err)
+		}
+		if proof == nil {
+			return core.Proposal{}, errors.New("Not eligible to propose")
+		}
+		block.VRFProof = proof
+	}
 	block.HCC.BlockHash = e.state.GetHighestCCBlock().Hash()
 	hccValidators := e.validatorManager.GetValidatorSet(block.HCC.BlockHash)
 	block.HCC.Votes = e.chain.FindVotesByHash(block.HCC.BlockHash).UniqueVoter().FilterByValidators(hccValidators)
@@ -1280,11 +1422,18 @@ func (e *ConsensusEngine) createProposal(shouldIncludeValidatorUpdateTxs bool) (
 	block.StateHash = newRoot
 
 	// Sign block.
-	sig, err := e.privateKey.Sign(block.SignBytes())
+	err := e.checkAndRecordSigning(SigningRecord{
+		Type:   SigningTypeProposal,
+		Height: block.Height,
+		Epoch:  block.Epoch,
+		Hash:   crypto.Keccak256Hash(block.SignBytes()), // The block hash covers the signature
+	})
 	if err != nil {
-		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to sign vote")
+		return core.Proposal{}, fmt.Errorf("Refused to sign block: %v", err)
+	}
+	if err := e.signer.SignProposal(block.BlockHeader); err != nil {
+		return core.Proposal{}, fmt.Errorf("Failed to sign block: %v", err)
 	}
-	block.SetSignature(sig)
 
 	proposal := core.Proposal{
 		Block:      block,
@@ -1304,6 +1453,9 @@ func (e *ConsensusEngine) createProposal(shouldIncludeValidatorUpdateTxs bool) (
 	}
 	proposal.Votes = lastCCVotes.Merge(epochVotes).UniqueVoterAndBlock().FilterByValidators(lastCCValidators)
 
+	if err := e.recordWAL(WALMessageSignedProposal, proposal); err != nil {
+		return core.Proposal{}, err
+	}
 	return proposal, nil
 }
 
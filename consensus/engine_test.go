@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"math/big"
+	"sort"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/core"
 	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/signer"
 	"github.com/scripttoken/script/store/database/backend"
 	"github.com/scripttoken/script/store/kvstore"
 	"github.com/stretchr/testify/assert"
@@ -35,11 +37,19 @@ func (m MockValidatorManager) GetValidatorSet(_ common.Hash) *core.ValidatorSet
 }
 
 func (m MockValidatorManager) GetNextValidatorSet(a common.Hash) *core.ValidatorSet {
-	return m.GetNextValidatorSet(a)
+	return m.GetValidatorSet(a)
 }
 
 func (m MockValidatorManager) SetConsensusEngine(consensus core.ConsensusEngine) {}
 
+func newTestSigner(privKey *crypto.PrivateKey) core.Signer {
+	localSigner, err := signer.NewLocalSigner(privKey)
+	if err != nil {
+		panic(err)
+	}
+	return localSigner
+}
+
 func TestSingleBlockValidation(t *testing.T) {
 	require := require.New(t)
 
@@ -53,7 +63,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	root.Epoch = 0
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	// Valid block
 	b1 := core.NewBlock()
@@ -226,7 +236,7 @@ func TestValidParent(t *testing.T) {
 	root.Epoch = 0
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
@@ -310,7 +320,7 @@ func TestChildBlockOfValidatorChange(t *testing.T) {
 	root.Epoch = 0
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
@@ -413,7 +423,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	root.Epoch = 0
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
@@ -574,7 +584,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	root.Epoch = 0
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
@@ -709,7 +719,7 @@ func TestTipSelection(t *testing.T) {
 	root := core.CreateTestBlock("root", "")
 	chain := blockchain.NewChain("testchain", store, root)
 
-	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
 
 	a1 := core.CreateTestBlock("a1", "root")
 	chain.AddBlock(a1)
@@ -742,3 +752,54 @@ func TestTipSelection(t *testing.T) {
 	tip = ce.GetTipToExtend()
 	assert.Equal(a2.Hash(), tip.Hash(), "should not select blocks with validator update that are higher than local HCC")
 }
+
+func TestVRFTipSelection(t *testing.T) {
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("vrf_root", "")
+	root.Height = common.HeightEnableVRFProposer - 1
+	chain := blockchain.NewChain("testchain", store, root)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, NewVRFValidatorManager(MockValidatorManager{PrivKey: privKey}))
+
+	// Competing blocks proposed by the validators eligible in the same epoch
+	blocks := []*core.Block{}
+	for i := 0; i < 3; i++ {
+		proposerKey, _, _ := crypto.GenerateKeyPair()
+		_, proof, err := newTestSigner(proposerKey).ProveVRF(vrfMessage(root.BlockHeader, 1))
+		require.Nil(err)
+		block := core.NewBlock()
+		block.ChainID = chain.ChainID
+		block.Height = common.HeightEnableVRFProposer
+		block.Epoch = 1
+		block.Parent = root.Hash()
+		block.Proposer = proposerKey.PublicKey().Address()
+		block.VRFProof = proof
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return hasLowerVRFOutput(blocks[i].BlockHeader, blocks[j].BlockHeader)
+	})
+
+	// The block with the lowest VRF output wins, whatever the order they are received in
+	for _, block := range []*core.Block{blocks[1], blocks[0], blocks[2]} {
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+		chain.MarkBlockValid(block.Hash())
+	}
+	require.Equal(blocks[0].Hash(), ce.GetTipToVote().Hash())
+	require.Equal(blocks[0].Hash(), ce.GetTipToExtend().Hash())
+
+	// A longer branch still wins over a lower VRF output
+	child := core.NewBlock()
+	child.ChainID = chain.ChainID
+	child.Height = blocks[2].Height + 1
+	child.Epoch = 2
+	child.Parent = blocks[2].Hash()
+	_, err := chain.AddBlock(child)
+	require.Nil(err)
+	chain.MarkBlockValid(child.Hash())
+	require.Equal(child.Hash(), ce.GetTipToVote().Hash())
+}
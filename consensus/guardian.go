@@ -7,7 +7,6 @@ import (
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/common/util"
 	"github.com/scripttoken/script/core"
-	"github.com/scripttoken/script/crypto/bls"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -20,8 +19,8 @@ const (
 type GuardianEngine struct {
 	logger *log.Entry
 
-	engine  *ConsensusEngine
-	privKey *bls.SecretKey
+	engine *ConsensusEngine
+	signer core.Signer
 
 	// State for current voting
 	block       common.Hash
@@ -36,11 +35,11 @@ type GuardianEngine struct {
 	mu       *sync.Mutex
 }
 
-func NewGuardianEngine(c *ConsensusEngine, privateKey *bls.SecretKey) *GuardianEngine {
+func NewGuardianEngine(c *ConsensusEngine, signer core.Signer) *GuardianEngine {
 	return &GuardianEngine{
-		logger:  util.GetLoggerForModule("guardian"),
-		engine:  c,
-		privKey: privateKey,
+		logger: util.GetLoggerForModule("guardian"),
+		engine: c,
+		signer: signer,
 
 		incoming: make(chan *core.AggregatedVotes, viper.GetInt(common.CfgConsensusMessageQueueSize)),
 		mu:       &sync.Mutex{},
@@ -51,7 +50,7 @@ func (g *GuardianEngine) isGuardian() bool {
 	return g.signerIndex >= 0
 }
 
-func (g *GuardianEngine) StartNewBlock(block common.Hash) {
+func (g *GuardianEngine) StartNewBlock(block common.Hash, height uint64) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
@@ -67,7 +66,7 @@ func (g *GuardianEngine) StartNewBlock(block common.Hash) {
 	}
 	g.gcp = gcp
 	g.gcpHash = gcp.Hash()
-	g.signerIndex = gcp.WithStake().Index(g.privKey.PublicKey())
+	g.signerIndex = gcp.WithStake().Index(g.signer.BLSPublicKey())
 
 	g.logger.WithFields(log.Fields{
 		"block":       block.Hex(),
@@ -76,8 +75,25 @@ func (g *GuardianEngine) StartNewBlock(block common.Hash) {
 	}).Debug("Starting new block")
 
 	if g.isGuardian() {
-		g.nextVote = core.NewAggregateVotes(block, gcp)
-		g.nextVote.Sign(g.privKey, g.signerIndex)
+		err = g.engine.checkAndRecordSigning(SigningRecord{
+			Type:   SigningTypeGuardianVote,
+			Height: height,
+			Hash:   block,
+		})
+		if err != nil {
+			g.logger.WithFields(log.Fields{"error": err}).Error("Refused to sign guardian vote")
+			g.nextVote = nil
+			g.currVote = nil
+			return
+		}
+		nextVote := core.NewAggregateVotes(block, gcp)
+		if err = g.signer.SignGuardianVote(nextVote, g.signerIndex); err != nil {
+			g.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign guardian vote")
+			g.nextVote = nil
+			g.currVote = nil
+			return
+		}
+		g.nextVote = nextVote
 		g.currVote = g.nextVote.Copy()
 	} else {
 		g.nextVote = nil
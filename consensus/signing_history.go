@@ -0,0 +1,256 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/recordfile"
+)
+
+// SigningTypeEnum is the type of the messages signed by the node key
+type SigningTypeEnum uint8
+
+const (
+	SigningTypeVote SigningTypeEnum = iota + 1
+	SigningTypeProposal
+	SigningTypeGuardianVote
+)
+
+var signingTypeNames = map[SigningTypeEnum]string{
+	SigningTypeVote:         "vote",
+	SigningTypeProposal:     "proposal",
+	SigningTypeGuardianVote: "guardian_vote",
+}
+
+func (t SigningTypeEnum) String() string {
+	if name, ok := signingTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+func parseSigningType(name string) (SigningTypeEnum, error) {
+	for t, n := range signingTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signing type: %v", name)
+}
+
+// SigningRecord records the latest block signed by the node key for a type of messages.
+type SigningRecord struct {
+	Type   SigningTypeEnum
+	Height uint64
+	Epoch  uint64
+	Hash   common.Hash // Empty if conflicting records were merged, in which case nothing can be signed at the height
+}
+
+func (r *SigningRecord) String() string {
+	return fmt.Sprintf("SigningRecord{type: %v, height: %v, epoch: %v, hash: %v}", r.Type, r.Height, r.Epoch, r.Hash.Hex())
+}
+
+// conflicts returns whether signing the block described by the record r could conflict with the previous signature.
+func (r *SigningRecord) conflicts(prev *SigningRecord) error {
+	if r.Epoch < prev.Epoch {
+		return fmt.Errorf("epoch %v is lower than the epoch of the last %v", r.Epoch, prev)
+	}
+	switch r.Type {
+	case SigningTypeProposal:
+		// A different block must not be proposed in the same epoch
+		if r.Epoch == prev.Epoch && r.Hash != prev.Hash {
+			return fmt.Errorf("block %v conflicts with the last %v", r.Hash.Hex(), prev)
+		}
+	default:
+		// Votes are only allowed on higher blocks, or on the same block again
+		if r.Height < prev.Height {
+			return fmt.Errorf("height %v is lower than the height of the last %v", r.Height, prev)
+		}
+		if r.Height == prev.Height && r.Hash != prev.Hash {
+			return fmt.Errorf("block %v conflicts with the last %v", r.Hash.Hex(), prev)
+		}
+	}
+	return nil
+}
+
+// merge combines the record with the record of the same type signed elsewhere, e.g. on another machine.
+func (r *SigningRecord) merge(other *SigningRecord) {
+	if other.Epoch > r.Epoch {
+		r.Epoch = other.Epoch
+	}
+	if other.Height > r.Height {
+		r.Height = other.Height
+		r.Hash = other.Hash
+	} else if other.Height == r.Height && other.Hash != r.Hash {
+		r.Hash = common.Hash{}
+	}
+}
+
+// SigningHistory is the slashing protection database. It is an append-only file recording the
+// blocks signed by the node key, kept apart from the chain data so that restarting from a stale
+// or wiped data directory does not lead to signing conflicting blocks.
+type SigningHistory struct {
+	mu      *sync.Mutex
+	file    *recordfile.File
+	records map[SigningTypeEnum]*SigningRecord
+}
+
+// NewSigningHistory loads the signing history at the given path, creating it if it does not exist.
+func NewSigningHistory(path string) (*SigningHistory, error) {
+	h := &SigningHistory{
+		mu:      &sync.Mutex{},
+		file:    recordfile.New(path),
+		records: make(map[SigningTypeEnum]*SigningRecord),
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	if err := h.rotate(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// CheckAndRecord verifies that the block described by the record can be signed without
+// conflicting with the previous signatures, and persists the record before returning.
+func (h *SigningHistory) CheckAndRecord(record SigningRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if prev, ok := h.records[record.Type]; ok {
+		if err := record.conflicts(prev); err != nil {
+			return err
+		}
+		if record.Height == prev.Height && record.Epoch == prev.Epoch && record.Hash == prev.Hash {
+			return nil
+		}
+	}
+	if err := h.append(&record); err != nil {
+		return err
+	}
+	h.records[record.Type] = &record
+	return nil
+}
+
+// Records returns the latest signing records.
+func (h *SigningHistory) Records() []SigningRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ret := []SigningRecord{}
+	for _, t := range []SigningTypeEnum{SigningTypeVote, SigningTypeProposal, SigningTypeGuardianVote} {
+		if record, ok := h.records[t]; ok {
+			ret = append(ret, *record)
+		}
+	}
+	return ret
+}
+
+// Close closes the signing history file.
+func (h *SigningHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.file.Close()
+}
+
+// signingRecordJSON is the interchange format of the signing records.
+type signingRecordJSON struct {
+	Type   string            `json:"type"`
+	Height common.JSONUint64 `json:"height"`
+	Epoch  common.JSONUint64 `json:"epoch"`
+	Hash   common.Hash       `json:"hash"`
+}
+
+type signingHistoryJSON struct {
+	Records []signingRecordJSON `json:"records"`
+}
+
+// Export writes the signing records in JSON, to be imported on another machine.
+func (h *SigningHistory) Export(w io.Writer) error {
+	exported := signingHistoryJSON{Records: []signingRecordJSON{}}
+	for _, record := range h.Records() {
+		exported.Records = append(exported.Records, signingRecordJSON{
+			Type:   record.Type.String(),
+			Height: common.JSONUint64(record.Height),
+			Epoch:  common.JSONUint64(record.Epoch),
+			Hash:   record.Hash,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(exported)
+}
+
+// Import merges the exported signing records into the history, so that nothing signed on
+// either machine can be contradicted.
+func (h *SigningHistory) Import(r io.Reader) error {
+	imported := signingHistoryJSON{}
+	if err := json.NewDecoder(r).Decode(&imported); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rj := range imported.Records {
+		t, err := parseSigningType(rj.Type)
+		if err != nil {
+			return err
+		}
+		record := &SigningRecord{
+			Type:   t,
+			Height: uint64(rj.Height),
+			Epoch:  uint64(rj.Epoch),
+			Hash:   rj.Hash,
+		}
+		if prev, ok := h.records[t]; ok {
+			prev.merge(record)
+		} else {
+			h.records[t] = record
+		}
+	}
+	return h.rotate()
+}
+
+// load reads the records in the file, and keeps the latest one of each type.
+func (h *SigningHistory) load() error {
+	return h.file.Read(func(stream *rlp.Stream) error {
+		record := &SigningRecord{}
+		if err := stream.Decode(record); err != nil {
+			return err
+		}
+		if prev, ok := h.records[record.Type]; ok {
+			prev.merge(record)
+		} else {
+			h.records[record.Type] = record
+		}
+		return nil
+	})
+}
+
+// append persists the record before the block is signed.
+func (h *SigningHistory) append(record *SigningRecord) error {
+	if err := h.file.Append(record); err != nil {
+		if err == recordfile.ErrClosed {
+			return fmt.Errorf("signing history %v is closed", h.file.Path())
+		}
+		return err
+	}
+	return h.file.Sync()
+}
+
+// rotate replaces the content of the file with the latest records, and reopens it for appending.
+func (h *SigningHistory) rotate() error {
+	records := []interface{}{}
+	for _, t := range []SigningTypeEnum{SigningTypeVote, SigningTypeProposal, SigningTypeGuardianVote} {
+		if record, ok := h.records[t]; ok {
+			records = append(records, record)
+		}
+	}
+	return h.file.Rewrite(records)
+}
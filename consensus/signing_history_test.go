@@ -0,0 +1,114 @@
+package consensus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningHistoryVotes(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signing_history")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	historyPath := path.Join(dir, "signing_history")
+
+	history, err := NewSigningHistory(historyPath)
+	require.Nil(err)
+
+	blockA := common.HexToHash("a1")
+	blockB := common.HexToHash("b1")
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 10, Epoch: 5, Hash: blockA}))
+
+	// Repeating the vote on the same block in a later epoch is allowed
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 10, Epoch: 6, Hash: blockA}))
+
+	// Voting on another block at the same or a lower height is not allowed
+	require.NotNil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 10, Epoch: 6, Hash: blockB}))
+	require.NotNil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 9, Epoch: 7, Hash: blockB}))
+
+	// Going back in epochs is not allowed
+	require.NotNil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 11, Epoch: 5, Hash: blockB}))
+
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 11, Epoch: 6, Hash: blockB}))
+	require.Nil(history.Close())
+
+	// The history survives restarts
+	history, err = NewSigningHistory(historyPath)
+	require.Nil(err)
+	defer history.Close()
+	require.NotNil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 11, Epoch: 6, Hash: blockA}))
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 11, Epoch: 6, Hash: blockB}))
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 12, Epoch: 7, Hash: blockA}))
+}
+
+func TestSigningHistoryProposals(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signing_history")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	history, err := NewSigningHistory(path.Join(dir, "signing_history"))
+	require.Nil(err)
+	defer history.Close()
+
+	blockA := common.HexToHash("a1")
+	blockB := common.HexToHash("b1")
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeProposal, Height: 10, Epoch: 5, Hash: blockA}))
+
+	// Proposing another block in the same epoch is not allowed
+	require.NotNil(history.CheckAndRecord(SigningRecord{Type: SigningTypeProposal, Height: 10, Epoch: 5, Hash: blockB}))
+
+	// Proposing another block at the same height in a later epoch is allowed
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeProposal, Height: 10, Epoch: 6, Hash: blockB}))
+
+	// Votes are tracked separately
+	require.Nil(history.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 10, Epoch: 6, Hash: blockB}))
+}
+
+func TestSigningHistoryExportImport(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signing_history")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	blockA := common.HexToHash("a1")
+	blockB := common.HexToHash("b1")
+
+	oldHistory, err := NewSigningHistory(path.Join(dir, "old"))
+	require.Nil(err)
+	defer oldHistory.Close()
+	require.Nil(oldHistory.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 20, Epoch: 8, Hash: blockA}))
+	require.Nil(oldHistory.CheckAndRecord(SigningRecord{Type: SigningTypeGuardianVote, Height: 100, Hash: blockA}))
+
+	newHistory, err := NewSigningHistory(path.Join(dir, "new"))
+	require.Nil(err)
+	defer newHistory.Close()
+	require.Nil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 10, Epoch: 9, Hash: blockB}))
+	require.Nil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeGuardianVote, Height: 100, Hash: blockB}))
+
+	exported := &bytes.Buffer{}
+	require.Nil(oldHistory.Export(exported))
+	require.Nil(newHistory.Import(exported))
+
+	// The highest height and epoch of both histories apply
+	require.NotNil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 20, Epoch: 9, Hash: blockB}))
+	require.NotNil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 21, Epoch: 8, Hash: blockB}))
+	require.Nil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeVote, Height: 20, Epoch: 9, Hash: blockA}))
+
+	// Nothing can be signed at a height where the histories conflict
+	require.NotNil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeGuardianVote, Height: 100, Hash: blockA}))
+	require.NotNil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeGuardianVote, Height: 100, Hash: blockB}))
+	require.Nil(newHistory.CheckAndRecord(SigningRecord{Type: SigningTypeGuardianVote, Height: 200, Hash: blockB}))
+
+	// Invalid records are rejected
+	require.NotNil(newHistory.Import(bytes.NewBufferString(`{"records":[{"type":"unknown","height":"1","epoch":"1","hash":"0x00"}]}`)))
+}
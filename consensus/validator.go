@@ -1,11 +1,15 @@
 package consensus
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"math/big"
 	"math/rand"
 
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -131,6 +135,135 @@ func (m *RotatingValidatorManager) GetNextValidatorSet(blockHash common.Hash) *c
 	return valSet
 }
 
+// -------------------------------- VRFValidatorManager ----------------------------------
+var _ core.VRFValidatorManager = &VRFValidatorManager{}
+
+// vrfExpectedProposers is the expected number of validators eligible to propose in the epoch following the parent
+// block. A higher number lowers the chance that no validator is eligible, at the cost of more competing proposals.
+// Each epoch elapsed without a block raises the expected number by as much, so that every validator eventually
+// becomes eligible and the chain does not stall. The competing proposals are settled by the lowest VRF output.
+const vrfExpectedProposers int64 = 2
+
+// VRFValidatorManager is an implementation of ValidatorManager interface where each validator privately checks its
+// eligibility to propose with a VRF over the seed of the parent block, using validator's stake as weight, and proves
+// it in the proposed block. Unlike with RotatingValidatorManager, the upcoming proposers cannot be computed in advance
+// and targeted. Below the fork height, proposers are selected by the underlying ValidatorManager.
+type VRFValidatorManager struct {
+	core.ValidatorManager
+
+	forkHeight uint64
+}
+
+// NewVRFValidatorManager creates an instance of VRFValidatorManager on top of the given ValidatorManager, which
+// provides the validator sets and the proposers below the fork height.
+func NewVRFValidatorManager(base core.ValidatorManager) *VRFValidatorManager {
+	m := &VRFValidatorManager{
+		ValidatorManager: base,
+		forkHeight:       common.HeightEnableVRFProposer,
+	}
+	return m
+}
+
+// IsVRFEnabled implements VRFValidatorManager interface.
+func (m *VRFValidatorManager) IsVRFEnabled(height uint64) bool {
+	return height >= m.forkHeight
+}
+
+// ProveEligibility implements VRFValidatorManager interface.
+func (m *VRFValidatorManager) ProveEligibility(parent *core.BlockHeader, epoch uint64, signer core.Signer) (common.Bytes, error) {
+	output, proof, err := signer.ProveVRF(vrfMessage(parent, epoch))
+	if err != nil {
+		return nil, err
+	}
+	if !m.isEligible(parent, epoch, signer.PublicKey().Address(), output) {
+		return nil, nil
+	}
+	return proof, nil
+}
+
+// VerifyEligibility implements VRFValidatorManager interface.
+func (m *VRFValidatorManager) VerifyEligibility(parent *core.BlockHeader, block *core.BlockHeader) error {
+	if len(block.VRFProof) == 0 {
+		return errors.New("VRF proof is missing")
+	}
+	if block.Signature == nil || block.Signature.IsEmpty() {
+		return errors.New("block is not signed")
+	}
+	pubKey, err := block.Signature.RecoverPublicKey(block.SignBytes())
+	if err != nil {
+		return err
+	}
+	if pubKey.Address() != block.Proposer {
+		return errors.New("block is not signed by the proposer")
+	}
+	output, err := pubKey.VRFVerify(vrfMessage(parent, block.Epoch), block.VRFProof)
+	if err != nil {
+		return err
+	}
+	if !m.isEligible(parent, block.Epoch, block.Proposer, output) {
+		return fmt.Errorf("proposer %v is not eligible in epoch %v", block.Proposer.Hex(), block.Epoch)
+	}
+	return nil
+}
+
+// isEligible returns whether the VRF output of the validator falls below its stake weighted threshold, which grows
+// with the number of epochs since the parent block.
+func (m *VRFValidatorManager) isEligible(parent *core.BlockHeader, epoch uint64, id common.Address, output common.Hash) bool {
+	valSet := m.GetNextValidatorSet(parent.Hash())
+	validator, err := valSet.GetValidator(id)
+	if err != nil {
+		return false
+	}
+
+	rounds := uint64(1)
+	if epoch > parent.Epoch {
+		rounds = epoch - parent.Epoch
+	}
+
+	// output / 2^256 < vrfExpectedProposers * rounds * stake / totalStake
+	lhs := new(big.Int).Mul(output.Big(), valSet.TotalStake())
+	rhs := new(big.Int).Mul(validator.Stake, big.NewInt(vrfExpectedProposers))
+	rhs.Mul(rhs, new(big.Int).SetUint64(rounds))
+	rhs.Lsh(rhs, 256)
+	return lhs.Cmp(rhs) < 0
+}
+
+// vrfMessage returns the VRF input for proposing on top of the parent block in the epoch. Including the epoch gives
+// a fresh draw when an epoch ends without a block.
+func vrfMessage(parent *core.BlockHeader, epoch uint64) common.Bytes {
+	seed := vrfSeed(parent)
+	msg := make(common.Bytes, common.HashLength+8)
+	copy(msg, seed[:])
+	binary.BigEndian.PutUint64(msg[common.HashLength:], epoch)
+	return msg
+}
+
+// vrfSeed returns the seed for the proposers of the child blocks, i.e. the VRF output of the block proposer. Blocks
+// without VRF proof, e.g. the ones below the fork height, use the block hash instead.
+func vrfSeed(block *core.BlockHeader) common.Hash {
+	if len(block.VRFProof) > 0 {
+		if output, err := crypto.VRFOutput(block.VRFProof); err == nil {
+			return output
+		}
+	}
+	return block.Hash()
+}
+
+// hasLowerVRFOutput returns whether the proposer of the block has a lower VRF output than the proposer of the other
+// block, which settles the competing proposals at the same height. Blocks without a valid proof rank last, and
+// blocks with the same output, i.e. signed by the same proposer, are ordered by hash.
+func hasLowerVRFOutput(block *core.BlockHeader, other *core.BlockHeader) bool {
+	output, err := crypto.VRFOutput(block.VRFProof)
+	otherOutput, otherErr := crypto.VRFOutput(other.VRFProof)
+	if err != nil || otherErr != nil {
+		return err == nil
+	}
+	if cmp := output.Big().Cmp(otherOutput.Big()); cmp != 0 {
+		return cmp < 0
+	}
+	return block.Hash().Big().Cmp(other.Hash().Big()) < 0
+}
+
 //
 // -------------------------------- Utilities ----------------------------------
 //
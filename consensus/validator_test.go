@@ -0,0 +1,214 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type stakeValidatorManager struct {
+	*FixedValidatorManager
+	valSet *core.ValidatorSet
+}
+
+func (m stakeValidatorManager) GetValidatorSet(_ common.Hash) *core.ValidatorSet {
+	return m.valSet
+}
+
+func (m stakeValidatorManager) GetNextValidatorSet(_ common.Hash) *core.ValidatorSet {
+	return m.valSet
+}
+
+func newVRFTestBlock(parent *core.BlockHeader, epoch uint64, privKey *crypto.PrivateKey, proof common.Bytes) *core.BlockHeader {
+	block := &core.BlockHeader{
+		ChainID:   parent.ChainID,
+		Epoch:     epoch,
+		Height:    parent.Height + 1,
+		Parent:    parent.Hash(),
+		Timestamp: big.NewInt(1000),
+		Proposer:  privKey.PublicKey().Address(),
+		VRFProof:  proof,
+	}
+	block.Signature, _ = privKey.Sign(block.SignBytes())
+	return block
+}
+
+func TestVRFValidatorManager(t *testing.T) {
+	require := require.New(t)
+
+	privKeys := []*crypto.PrivateKey{}
+	signers := []core.Signer{}
+	valSet := core.NewValidatorSet()
+	for i := 0; i < 4; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		require.Nil(err)
+		privKeys = append(privKeys, privKey)
+		signers = append(signers, newTestSigner(privKey))
+		valSet.AddValidator(core.NewValidator(privKey.PublicKey().Address().Hex(), big.NewInt(int64(1000*(i+1)))))
+	}
+	m := NewVRFValidatorManager(stakeValidatorManager{valSet: valSet})
+	require.False(m.IsVRFEnabled(common.HeightEnableVRFProposer - 1))
+	require.True(m.IsVRFEnabled(common.HeightEnableVRFProposer))
+
+	parent := &core.BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     3,
+		Height:    common.HeightEnableVRFProposer,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(900),
+	}
+
+	eligibleEpochs := 0
+	for epoch := uint64(4); epoch < 40; epoch++ {
+		eligible := false
+		for i, signer := range signers {
+			proof, err := m.ProveEligibility(parent, epoch, signer)
+			require.Nil(err)
+			block := newVRFTestBlock(parent, epoch, privKeys[i], proof)
+			if proof == nil {
+				// Validators which are not eligible cannot forge a proof
+				_, forgedProof, err := signer.ProveVRF(vrfMessage(parent, epoch))
+				require.Nil(err)
+				block = newVRFTestBlock(parent, epoch, privKeys[i], forgedProof)
+				require.NotNil(m.VerifyEligibility(parent, block))
+				continue
+			}
+			eligible = true
+			require.Nil(m.VerifyEligibility(parent, block))
+
+			// The proof only applies to the epoch and the parent it was computed for
+			require.NotNil(m.VerifyEligibility(parent, newVRFTestBlock(parent, epoch+100, privKeys[i], proof)))
+			otherParent := &core.BlockHeader{
+				ChainID:   parent.ChainID,
+				Epoch:     parent.Epoch,
+				Height:    parent.Height,
+				Parent:    common.HexToHash("b0"),
+				Timestamp: parent.Timestamp,
+			}
+			require.NotNil(m.VerifyEligibility(otherParent, block))
+
+			// The proof only applies to its prover
+			other := privKeys[(i+1)%len(privKeys)]
+			require.NotNil(m.VerifyEligibility(parent, newVRFTestBlock(parent, epoch, other, proof)))
+		}
+		if eligible {
+			eligibleEpochs++
+		}
+	}
+	require.True(eligibleEpochs > 0)
+
+	// Blocks without proof are rejected
+	require.NotNil(m.VerifyEligibility(parent, newVRFTestBlock(parent, 4, privKeys[0], nil)))
+
+	// Non-validators are never eligible
+	outsiderKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	for epoch := uint64(4); epoch < 40; epoch++ {
+		proof, err := m.ProveEligibility(parent, epoch, newTestSigner(outsiderKey))
+		require.Nil(err)
+		require.Nil(proof)
+	}
+
+	// The seed of the child blocks is the VRF output of the proposer
+	output, proof, err := signers[0].ProveVRF(vrfMessage(parent, 4))
+	require.Nil(err)
+	require.Equal(output, vrfSeed(newVRFTestBlock(parent, 4, privKeys[0], proof)))
+	require.Equal(parent.Hash(), vrfSeed(parent))
+}
+
+func TestVRFValidatorManagerSingleValidator(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	valSet := core.NewValidatorSet()
+	valSet.AddValidator(core.NewValidator(privKey.PublicKey().Address().Hex(), big.NewInt(1000)))
+	m := NewVRFValidatorManager(stakeValidatorManager{valSet: valSet})
+
+	parent := &core.BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     3,
+		Height:    common.HeightEnableVRFProposer,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(900),
+	}
+
+	// The only validator is eligible in every epoch
+	signer := newTestSigner(privKey)
+	for epoch := uint64(4); epoch < 20; epoch++ {
+		proof, err := m.ProveEligibility(parent, epoch, signer)
+		require.Nil(err)
+		require.NotNil(proof)
+		require.Nil(m.VerifyEligibility(parent, newVRFTestBlock(parent, epoch, privKey, proof)))
+	}
+}
+
+func TestVRFValidatorManagerEmptyEpochs(t *testing.T) {
+	require := require.New(t)
+
+	privKeys := []*crypto.PrivateKey{}
+	valSet := core.NewValidatorSet()
+	for i := 0; i < 4; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		require.Nil(err)
+		privKeys = append(privKeys, privKey)
+		valSet.AddValidator(core.NewValidator(privKey.PublicKey().Address().Hex(), big.NewInt(int64(1000*(i+1)))))
+	}
+	m := NewVRFValidatorManager(stakeValidatorManager{valSet: valSet})
+
+	parent := &core.BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     3,
+		Height:    common.HeightEnableVRFProposer,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(900),
+	}
+
+	// The eligibility grows with the epochs elapsed since the parent, so that all the validators are eligible
+	// once vrfExpectedProposers * epochs * stake reaches the total stake
+	for _, privKey := range privKeys {
+		epoch := parent.Epoch + 5
+		proof, err := m.ProveEligibility(parent, epoch, newTestSigner(privKey))
+		require.Nil(err)
+		require.NotNil(proof)
+		require.Nil(m.VerifyEligibility(parent, newVRFTestBlock(parent, epoch, privKey, proof)))
+	}
+}
+
+func TestVRFOutputTieBreak(t *testing.T) {
+	require := require.New(t)
+
+	parent := &core.BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     3,
+		Height:    common.HeightEnableVRFProposer,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(900),
+	}
+	blocks := []*core.BlockHeader{}
+	outputs := []common.Hash{}
+	for i := 0; i < 2; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		require.Nil(err)
+		output, proof, err := newTestSigner(privKey).ProveVRF(vrfMessage(parent, 4))
+		require.Nil(err)
+		blocks = append(blocks, newVRFTestBlock(parent, 4, privKey, proof))
+		outputs = append(outputs, output)
+	}
+
+	// The lowest VRF output wins
+	lower := outputs[0].Big().Cmp(outputs[1].Big()) < 0
+	require.Equal(lower, hasLowerVRFOutput(blocks[0], blocks[1]))
+	require.Equal(!lower, hasLowerVRFOutput(blocks[1], blocks[0]))
+	require.False(hasLowerVRFOutput(blocks[0], blocks[0]))
+
+	// The blocks without a valid proof rank last
+	noProof := &core.BlockHeader{ChainID: parent.ChainID, Epoch: 4, Height: parent.Height + 1, Parent: parent.Hash()}
+	require.True(hasLowerVRFOutput(blocks[0], noProof))
+	require.False(hasLowerVRFOutput(noProof, blocks[0]))
+	require.False(hasLowerVRFOutput(noProof, noProof))
+}
@@ -0,0 +1,410 @@
+package consensus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/recordfile"
+	log "github.com/sirupsen/logrus"
+)
+
+// WALMessageTypeEnum is the type of the messages recorded in the consensus WAL
+type WALMessageTypeEnum uint8
+
+const (
+	WALMessageBlock          WALMessageTypeEnum = iota + 1 // Block received for processing
+	WALMessageVote                                         // Vote received for processing
+	WALMessageVoteTimeout                                  // Minimal block interval elapsed in the epoch
+	WALMessageEpochTimeout                                 // Epoch timed out and is repeated
+	WALMessageSignedVote                                   // Vote signed by the node
+	WALMessageSignedProposal                               // Proposal signed by the node
+)
+
+var walMessageTypeNames = map[WALMessageTypeEnum]string{
+	WALMessageBlock:          "block",
+	WALMessageVote:           "vote",
+	WALMessageVoteTimeout:    "vote_timeout",
+	WALMessageEpochTimeout:   "epoch_timeout",
+	WALMessageSignedVote:     "signed_vote",
+	WALMessageSignedProposal: "signed_proposal",
+}
+
+func (t WALMessageTypeEnum) String() string {
+	if name, ok := walMessageTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+// WALRecord is a message recorded in the consensus WAL.
+type WALRecord struct {
+	Type    WALMessageTypeEnum
+	Epoch   uint64       // Epoch of the engine when the message was recorded
+	Payload common.Bytes // RLP encoded block, vote or proposal. Empty for timer events.
+}
+
+func (r *WALRecord) String() string {
+	return fmt.Sprintf("WALRecord{type: %v, epoch: %v, payload: %v bytes}", r.Type, r.Epoch, len(r.Payload))
+}
+
+// key identifies the message of the record, regardless of the epoch in which it was recorded.
+func (r *WALRecord) key() common.Hash {
+	return crypto.Keccak256Hash([]byte{byte(r.Type)}, r.Payload)
+}
+
+// WAL is the write-ahead log of the consensus engine. The messages are recorded before the engine
+// acts on them, and replayed on restart to restore the state of the engine within the epoch. The
+// log is truncated whenever a block is finalized, since the earlier messages are reflected in the
+// persisted chain and consensus state.
+type WAL struct {
+	mu       *sync.Mutex
+	file     *recordfile.File
+	maxSize  int64                    // Size beyond which the received messages are no longer recorded
+	recorded map[common.Hash]struct{} // Keys of the received messages in the WAL
+	dropping bool                     // Whether received messages were dropped since the last truncation
+}
+
+// NewWAL opens the WAL at the given path, creating it if it does not exist. Once the WAL reaches
+// maxSize bytes, the received messages are no longer recorded until it is truncated.
+func NewWAL(path string, maxSize int64) (*WAL, error) {
+	w := &WAL{
+		mu:       &sync.Mutex{},
+		file:     recordfile.New(path),
+		maxSize:  maxSize,
+		recorded: make(map[common.Hash]struct{}),
+	}
+	records, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	// Rewrite the readable records, so that a record partially written before the restart does
+	// not make the records appended after it unreadable.
+	if err := w.rewrite(records); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write persists the record before returning. The messages signed by the node and the timer events
+// are always written, whatever the size of the WAL.
+func (w *WAL) Write(record WALRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.write(&record)
+}
+
+// WriteReceived persists a message received from the peers, unless the same message is already
+// recorded or the WAL has reached its maximal size. The dropped messages are not lost, since the
+// peers send them again to the nodes lagging behind. Returns whether the record was written.
+func (w *WAL) WriteReceived(record WALRecord) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := record.key()
+	if _, ok := w.recorded[key]; ok {
+		return false, nil
+	}
+	if w.file.Size() >= w.maxSize {
+		if !w.dropping {
+			logger.Warnf("Consensus WAL %v reached its maximal size of %v bytes, not recording the received messages until the next finalized block",
+				w.file.Path(), w.maxSize)
+			w.dropping = true
+		}
+		return false, nil
+	}
+	if err := w.write(&record); err != nil {
+		return false, err
+	}
+	w.recorded[key] = struct{}{}
+	return true, nil
+}
+
+func (w *WAL) write(record *WALRecord) error {
+	if err := w.file.Append(record); err != nil {
+		if err == recordfile.ErrClosed {
+			return fmt.Errorf("consensus WAL %v is closed", w.file.Path())
+		}
+		return err
+	}
+	return w.file.Sync()
+}
+
+// ReadAll returns the records in the WAL.
+func (w *WAL) ReadAll() ([]WALRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.read()
+}
+
+func (w *WAL) read() ([]WALRecord, error) {
+	records := []WALRecord{}
+	err := w.file.Read(func(stream *rlp.Stream) error {
+		record := WALRecord{}
+		if err := stream.Decode(&record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Truncate replaces the content of the WAL with the given records.
+func (w *WAL) Truncate(records []WALRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rewrite(records)
+}
+
+func (w *WAL) rewrite(records []WALRecord) error {
+	raw := make([]interface{}, len(records))
+	recorded := make(map[common.Hash]struct{})
+	for i := range records {
+		raw[i] = &records[i]
+		if records[i].Type == WALMessageBlock || records[i].Type == WALMessageVote {
+			recorded[records[i].key()] = struct{}{}
+		}
+	}
+	if err := w.file.Rewrite(raw); err != nil {
+		return err
+	}
+	w.recorded = recorded
+	w.dropping = false
+	return nil
+}
+
+// Close closes the WAL file.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
+
+// recordWAL writes the message to the WAL before the engine acts on it.
+func (e *ConsensusEngine) recordWAL(msgType WALMessageTypeEnum, msg interface{}) error {
+	if e.wal == nil || e.replaying {
+		return nil
+	}
+	record, err := e.newWALRecord(msgType, msg)
+	if err == nil {
+		err = e.wal.Write(record)
+	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{
+			"type":  msgType,
+			"error": err,
+		}).Error("Failed to write the consensus WAL")
+	}
+	return err
+}
+
+// recordReceivedMessage writes the vote or the block received by the engine to the WAL, once it has
+// passed the basic checks. The messages already recorded are skipped.
+func (e *ConsensusEngine) recordReceivedMessage(msgType WALMessageTypeEnum, msg interface{}) {
+	if e.wal == nil || e.replaying {
+		return
+	}
+	record, err := e.newWALRecord(msgType, msg)
+	if err == nil {
+		_, err = e.wal.WriteReceived(record)
+	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{
+			"type":  msgType,
+			"error": err,
+		}).Error("Failed to write the consensus WAL")
+	}
+}
+
+// shouldRecordBlock returns whether the received block is worth recording, i.e. it is yet to be
+// processed, above the last finalized block and signed by its proposer. The other blocks are ignored
+// by handleNormalBlock.
+func (e *ConsensusEngine) shouldRecordBlock(block *core.Block) bool {
+	eb, err := e.chain.FindBlock(block.Hash())
+	if err != nil || !eb.Status.IsPending() {
+		return false
+	}
+	if block.Height <= e.state.GetLastFinalizedBlock().Height {
+		return false
+	}
+	return block.BlockHeader.Validate(e.chain.ChainID).IsOK()
+}
+
+func (e *ConsensusEngine) newWALRecord(msgType WALMessageTypeEnum, msg interface{}) (WALRecord, error) {
+	record := WALRecord{
+		Type:    msgType,
+		Epoch:   e.GetEpoch(),
+		Payload: common.Bytes{},
+	}
+	if msg != nil {
+		payload, err := rlp.EncodeToBytes(msg)
+		if err != nil {
+			return WALRecord{}, err
+		}
+		record.Payload = payload
+	}
+	return record, nil
+}
+
+// truncateWAL drops the messages which led to the finalized block. The records restoring the
+// state of the engine in the current epoch are kept.
+func (e *ConsensusEngine) truncateWAL() {
+	if e.wal == nil {
+		return
+	}
+	if e.replaying {
+		// Keep the records until they are all replayed.
+		e.walTruncatePending = true
+		return
+	}
+
+	records := []WALRecord{}
+	checkpoint := []struct {
+		msgType WALMessageTypeEnum
+		msg     interface{}
+		ok      bool
+	}{
+		{WALMessageSignedVote, e.state.GetLastVote(), !e.state.GetLastVote().Block.IsEmpty()},
+		{WALMessageSignedProposal, e.state.GetLastProposal(), e.state.GetLastProposal().Block != nil},
+		{WALMessageBlock, e.GetTipToVote().Block, e.blockProcessed},
+		{WALMessageVoteTimeout, nil, e.voteTimerReady},
+	}
+	for _, c := range checkpoint {
+		if !c.ok {
+			continue
+		}
+		record, err := e.newWALRecord(c.msgType, c.msg)
+		if err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to truncate the consensus WAL")
+			return
+		}
+		records = append(records, record)
+	}
+	if err := e.wal.Truncate(records); err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to truncate the consensus WAL")
+	}
+}
+
+// replayWAL restores the state of the engine within the epoch from the messages recorded before
+// the restart. The messages are processed again, without signing or broadcasting anything.
+func (e *ConsensusEngine) replayWAL() {
+	if e.wal == nil {
+		return
+	}
+	records, err := e.wal.ReadAll()
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to read the consensus WAL")
+		return
+	}
+
+	e.replaying = true
+	for _, record := range records {
+		if err := e.replayWALRecord(record); err != nil {
+			e.logger.WithFields(log.Fields{
+				"record": record.String(),
+				"error":  err,
+			}).Warn("Failed to replay consensus WAL record")
+		}
+	}
+	e.replaying = false
+
+	e.logger.WithFields(log.Fields{
+		"records":        len(records),
+		"state":          e.state,
+		"epoch":          e.GetEpoch(),
+		"voteTimerReady": e.voteTimerReady,
+		"blockProcessed": e.blockProcessed,
+	}).Info("Replayed consensus WAL")
+
+	if e.walTruncatePending {
+		e.walTruncatePending = false
+		e.truncateWAL()
+	}
+	if e.voteTimerReady && e.blockProcessed {
+		e.vote()
+	}
+}
+
+func (e *ConsensusEngine) replayWALRecord(record WALRecord) error {
+	switch record.Type {
+	case WALMessageBlock:
+		block := core.NewBlock()
+		if err := rlp.DecodeBytes(record.Payload, block); err != nil {
+			return err
+		}
+		if err := e.replayBlock(block); err != nil {
+			return err
+		}
+		e.processMessage(block)
+		// Blocks processed before the restart are not processed again.
+		eb, err := e.chain.FindBlock(block.Hash())
+		if err == nil && eb.Status.IsValid() {
+			if localEpoch := e.GetEpoch(); block.Epoch == localEpoch-1 || block.Epoch == localEpoch {
+				e.blockProcessed = true
+			}
+		}
+	case WALMessageVote:
+		vote := core.Vote{}
+		if err := rlp.DecodeBytes(record.Payload, &vote); err != nil {
+			return err
+		}
+		if e.processMessage(vote) {
+			e.enterEpoch()
+		}
+	case WALMessageVoteTimeout:
+		if record.Epoch == e.GetEpoch() {
+			e.voteTimerReady = true
+		}
+	case WALMessageEpochTimeout:
+		if record.Epoch == e.GetEpoch() {
+			e.enterEpoch()
+		}
+	case WALMessageSignedVote:
+		vote := core.Vote{}
+		if err := rlp.DecodeBytes(record.Payload, &vote); err != nil {
+			return err
+		}
+		// Repeated votes do not replace the last vote, see vote().
+		if lastVote := e.state.GetLastVote(); lastVote.Block.IsEmpty() || vote.Height > lastVote.Height {
+			e.state.SetLastVote(vote)
+		}
+	case WALMessageSignedProposal:
+		proposal := core.Proposal{}
+		if err := rlp.DecodeBytes(record.Payload, &proposal); err != nil {
+			return err
+		}
+		if proposal.Block == nil {
+			return fmt.Errorf("proposal without block")
+		}
+		if err := e.replayBlock(proposal.Block); err != nil {
+			return err
+		}
+		e.state.SetLastProposal(proposal)
+	default:
+		return fmt.Errorf("unknown record type %v", record.Type)
+	}
+	return nil
+}
+
+// replayBlock adds the block to the chain if it was lost in the restart.
+func (e *ConsensusEngine) replayBlock(block *core.Block) error {
+	if _, err := e.chain.FindBlock(block.Hash()); err == nil {
+		return nil
+	}
+	if block.Height <= e.state.GetLastFinalizedBlock().Height {
+		return fmt.Errorf("block %v is older than the last finalized block", block.Hash().Hex())
+	}
+	_, err := e.chain.AddBlock(block)
+	return err
+}
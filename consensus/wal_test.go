@@ -0,0 +1,311 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/scripttoken/script/store/kvstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consensus_wal")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	walPath := path.Join(dir, "consensus", "wal")
+
+	wal, err := NewWAL(walPath, 1024*1024)
+	require.Nil(err)
+	records, err := wal.ReadAll()
+	require.Nil(err)
+	require.Equal(0, len(records))
+
+	require.Nil(wal.Write(WALRecord{Type: WALMessageVoteTimeout, Epoch: 5, Payload: common.Bytes{}}))
+	require.Nil(wal.Write(WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x01, 0x02}}))
+	require.Nil(wal.Write(WALRecord{Type: WALMessageEpochTimeout, Epoch: 6, Payload: common.Bytes{}}))
+	require.Nil(wal.Close())
+	require.NotNil(wal.Write(WALRecord{Type: WALMessageEpochTimeout, Epoch: 7, Payload: common.Bytes{}}))
+
+	// The records are persisted across restarts
+	wal, err = NewWAL(walPath, 1024*1024)
+	require.Nil(err)
+	records, err = wal.ReadAll()
+	require.Nil(err)
+	require.Equal(3, len(records))
+	require.Equal(WALMessageVoteTimeout, records[0].Type)
+	require.Equal(uint64(5), records[0].Epoch)
+	require.Equal(WALMessageVote, records[1].Type)
+	require.Equal(common.Bytes{0x01, 0x02}, records[1].Payload)
+	require.Equal(WALMessageEpochTimeout, records[2].Type)
+	require.Equal(uint64(6), records[2].Epoch)
+
+	// A partially written record is ignored
+	raw, err := rlp.EncodeToBytes(&WALRecord{Type: WALMessageVote, Epoch: 7, Payload: common.Bytes{0x03, 0x04, 0x05}})
+	require.Nil(err)
+	file, err := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0600)
+	require.Nil(err)
+	_, err = file.Write(raw[:len(raw)-2])
+	require.Nil(err)
+	require.Nil(file.Close())
+	records, err = wal.ReadAll()
+	require.Nil(err)
+	require.Equal(3, len(records))
+
+	// Truncation replaces all the records, including the partial one
+	require.Nil(wal.Truncate([]WALRecord{{Type: WALMessageVoteTimeout, Epoch: 8, Payload: common.Bytes{}}}))
+	require.Nil(wal.Write(WALRecord{Type: WALMessageEpochTimeout, Epoch: 8, Payload: common.Bytes{}}))
+	records, err = wal.ReadAll()
+	require.Nil(err)
+	require.Equal(2, len(records))
+	require.Equal(WALMessageVoteTimeout, records[0].Type)
+	require.Equal(WALMessageEpochTimeout, records[1].Type)
+	require.Equal(uint64(8), records[1].Epoch)
+	require.Nil(wal.Close())
+}
+
+func TestWALPartialRecordOnRestart(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consensus_wal")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	walPath := path.Join(dir, "wal")
+
+	wal, err := NewWAL(walPath, 1024*1024)
+	require.Nil(err)
+	require.Nil(wal.Write(WALRecord{Type: WALMessageVoteTimeout, Epoch: 5, Payload: common.Bytes{}}))
+	require.Nil(wal.Close())
+
+	// Simulate a crash in the middle of writing a record
+	raw, err := rlp.EncodeToBytes(&WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x01, 0x02, 0x03}})
+	require.Nil(err)
+	file, err := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0600)
+	require.Nil(err)
+	_, err = file.Write(raw[:len(raw)-2])
+	require.Nil(err)
+	require.Nil(file.Close())
+
+	// The partial record is dropped on restart, so that the records written after it are readable
+	wal, err = NewWAL(walPath, 1024*1024)
+	require.Nil(err)
+	require.Nil(wal.Write(WALRecord{Type: WALMessageEpochTimeout, Epoch: 6, Payload: common.Bytes{}}))
+	require.Nil(wal.Close())
+
+	wal, err = NewWAL(walPath, 1024*1024)
+	require.Nil(err)
+	defer wal.Close()
+	records, err := wal.ReadAll()
+	require.Nil(err)
+	require.Equal(2, len(records))
+	require.Equal(WALMessageVoteTimeout, records[0].Type)
+	require.Equal(WALMessageEpochTimeout, records[1].Type)
+	require.Equal(uint64(6), records[1].Epoch)
+}
+
+func TestWALReplay(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consensus_wal")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	wal, err := NewWAL(path.Join(dir, "wal"), 1024*1024)
+	require.Nil(err)
+	defer wal.Close()
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	addr := privKey.PublicKey().Address()
+	validatorManager := MockValidatorManager{PrivKey: privKey}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("a0", "")
+	root.ChainID = "testchain"
+	root.Epoch = 0
+	chain := blockchain.NewChain("testchain", store, root)
+
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
+	ce.SetWAL(wal)
+	epoch := ce.GetEpoch()
+
+	// Block proposed and voted on by the node before the restart
+	b1 := core.NewBlock()
+	b1.ChainID = chain.ChainID
+	b1.Height = chain.Root().Height + 1
+	b1.Epoch = epoch
+	b1.Parent = chain.Root().Hash()
+	b1.HCC = core.CommitCertificate{BlockHash: b1.Parent}
+	b1.Proposer = addr
+	b1.Timestamp = big.NewInt(time.Now().Unix())
+	b1.Signature, _ = privKey.Sign(b1.SignBytes())
+	vote := core.Vote{Block: b1.Hash(), Height: b1.Height, Epoch: epoch, ID: addr}
+	vote.Sign(privKey)
+	proposal := core.Proposal{Block: b1, ProposerID: addr}
+
+	for _, r := range []struct {
+		msgType WALMessageTypeEnum
+		msg     interface{}
+		epoch   uint64
+	}{
+		{WALMessageSignedProposal, proposal, epoch},
+		{WALMessageSignedVote, vote, epoch},
+		{WALMessageEpochTimeout, nil, epoch + 10},
+		{WALMessageVoteTimeout, nil, epoch},
+	} {
+		record, err := ce.newWALRecord(r.msgType, r.msg)
+		require.Nil(err)
+		record.Epoch = r.epoch
+		require.Nil(wal.Write(record))
+	}
+
+	ce.replayWAL()
+	require.False(ce.replaying)
+	require.Equal(epoch, ce.GetEpoch())
+	require.True(ce.voteTimerReady)
+	require.False(ce.blockProcessed)
+	require.Equal(b1.Hash(), ce.State().GetLastVote().Block)
+	require.Equal(b1.Hash(), ce.State().GetLastProposal().Block.Hash())
+	_, err = chain.FindBlock(b1.Hash())
+	require.Nil(err)
+
+	// Nothing is recorded while replaying
+	records, err := wal.ReadAll()
+	require.Nil(err)
+	require.Equal(4, len(records))
+
+	// Truncation keeps the state of the engine in the current epoch
+	ce.truncateWAL()
+	records, err = wal.ReadAll()
+	require.Nil(err)
+	require.Equal(3, len(records))
+	require.Equal(WALMessageSignedVote, records[0].Type)
+	require.Equal(WALMessageSignedProposal, records[1].Type)
+	require.Equal(WALMessageVoteTimeout, records[2].Type)
+}
+
+func TestWALWriteReceived(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consensus_wal")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	walPath := path.Join(dir, "wal")
+
+	vote := WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x01, 0x02}}
+	raw, err := rlp.EncodeToBytes(&vote)
+	require.Nil(err)
+	wal, err := NewWAL(walPath, int64(2*len(raw)))
+	require.Nil(err)
+
+	// The received messages already recorded are skipped, even in a later epoch
+	written, err := wal.WriteReceived(vote)
+	require.Nil(err)
+	require.True(written)
+	written, err = wal.WriteReceived(WALRecord{Type: WALMessageVote, Epoch: 6, Payload: common.Bytes{0x01, 0x02}})
+	require.Nil(err)
+	require.False(written)
+	written, err = wal.WriteReceived(WALRecord{Type: WALMessageBlock, Epoch: 5, Payload: common.Bytes{0x01, 0x02}})
+	require.Nil(err)
+	require.True(written)
+
+	// Once the WAL is full, only the messages of the node and the timer events are recorded
+	written, err = wal.WriteReceived(WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x03, 0x04}})
+	require.Nil(err)
+	require.False(written)
+	require.Nil(wal.Write(WALRecord{Type: WALMessageEpochTimeout, Epoch: 6, Payload: common.Bytes{}}))
+	records, err := wal.ReadAll()
+	require.Nil(err)
+	require.Equal(3, len(records))
+
+	// The size and the recorded messages are restored on restart
+	require.Nil(wal.Close())
+	wal, err = NewWAL(walPath, int64(2*len(raw)))
+	require.Nil(err)
+	defer wal.Close()
+	written, err = wal.WriteReceived(WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x03, 0x04}})
+	require.Nil(err)
+	require.False(written)
+	_, err = wal.ReadAll()
+	require.Nil(err)
+	require.Contains(wal.recorded, vote.key())
+
+	// Truncation makes room for the received messages again
+	require.Nil(wal.Truncate([]WALRecord{vote}))
+	written, err = wal.WriteReceived(vote)
+	require.Nil(err)
+	require.False(written)
+	written, err = wal.WriteReceived(WALRecord{Type: WALMessageVote, Epoch: 5, Payload: common.Bytes{0x03, 0x04}})
+	require.Nil(err)
+	require.True(written)
+	records, err = wal.ReadAll()
+	require.Nil(err)
+	require.Equal(2, len(records))
+}
+
+func TestWALRecordReceivedMessages(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "consensus_wal")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	wal, err := NewWAL(path.Join(dir, "wal"), 1024*1024)
+	require.Nil(err)
+	defer wal.Close()
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	addr := privKey.PublicKey().Address()
+	validatorManager := MockValidatorManager{PrivKey: privKey}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("a0", "")
+	root.ChainID = "testchain"
+	root.Epoch = 0
+	chain := blockchain.NewChain("testchain", store, root)
+
+	ce := NewConsensusEngine(newTestSigner(privKey), store, chain, nil, validatorManager)
+	ce.SetWAL(wal)
+	epoch := ce.GetEpoch()
+
+	// The invalid votes are not recorded, and the valid ones only once
+	invalidVote := core.Vote{Block: common.BytesToHash([]byte{0x01}), Height: 1, Epoch: epoch, ID: addr}
+	ce.processMessage(invalidVote)
+	vote := core.Vote{Block: common.BytesToHash([]byte{0x01}), Height: 1, Epoch: epoch, ID: addr}
+	vote.Sign(privKey)
+	ce.processMessage(vote)
+	ce.processMessage(vote)
+	records, err := wal.ReadAll()
+	require.Nil(err)
+	require.Equal(1, len(records))
+	require.Equal(WALMessageVote, records[0].Type)
+
+	// Only the pending blocks above the last finalized block and signed by their proposer are recorded
+	b1 := core.NewBlock()
+	b1.ChainID = chain.ChainID
+	b1.Height = chain.Root().Height + 1
+	b1.Epoch = epoch
+	b1.Parent = chain.Root().Hash()
+	b1.HCC = core.CommitCertificate{BlockHash: b1.Parent}
+	b1.Proposer = addr
+	b1.Timestamp = big.NewInt(time.Now().Unix())
+	require.False(ce.shouldRecordBlock(b1))
+	_, err = chain.AddBlock(b1)
+	require.Nil(err)
+	require.False(ce.shouldRecordBlock(b1))
+
+	b1.Signature, _ = privKey.Sign(b1.SignBytes())
+	require.True(ce.shouldRecordBlock(b1))
+	require.False(ce.shouldRecordBlock(root))
+
+	chain.MarkBlockValid(b1.Hash())
+	require.False(ce.shouldRecordBlock(b1))
+}
@@ -135,6 +135,7 @@ type BlockHeader struct {
 	Timestamp          *big.Int
 	Proposer           common.Address
 	Signature          *crypto.Signature
+	VRFProof           common.Bytes // Added in VRF proposer fork.
 
 	hash common.Hash // Cache of calculated hash.
 }
@@ -183,6 +184,26 @@ func (h *BlockHeader) EncodeRLP(w io.Writer) error {
 	}
 
 	// Script3.0 fork
+	if h.Height >= common.HeightEnableScript3 && h.Height < common.HeightEnableVRFProposer {
+		return rlp.Encode(w, []interface{}{
+			h.ChainID,
+			h.Epoch,
+			h.Height,
+			h.Parent,
+			h.HCC,
+			h.TxHash,
+			h.ReceiptHash,
+			h.Bloom,
+			h.StateHash,
+			h.Timestamp,
+			h.Proposer,
+			h.Signature,
+			h.GuardianVotes,
+			h.EliteEdgeNodeVotes,
+		})
+	}
+
+	// VRF proposer fork
 	return rlp.Encode(w, []interface{}{
 		h.ChainID,
 		h.Epoch,
@@ -198,6 +219,7 @@ func (h *BlockHeader) EncodeRLP(w io.Writer) error {
 		h.Signature,
 		h.GuardianVotes,
 		h.EliteEdgeNodeVotes,
+		h.VRFProof,
 	})
 }
 
@@ -307,6 +329,14 @@ func (h *BlockHeader) DecodeRLP(stream *rlp.Stream) error {
 		}
 	}
 
+	// VRF proposer fork
+	if h.Height >= common.HeightEnableVRFProposer {
+		err = stream.Decode(&h.VRFProof)
+		if err != nil {
+			return err
+		}
+	}
+
 	return stream.ListEnd()
 }
 
@@ -14,21 +14,24 @@ import (
 func TestBlockEncoding(t *testing.T) {
 	require := require.New(t)
 
-	// Serialized block before Gurdian fork.
-	oldBlockHash := common.HexToHash("0xf1a7fa371f6a108bb4f2ed33de26ac006f0d8cf6a0ed9dc2c1d9547b6cf43cae")
-	v1, err := hex.DecodeString("f90217f902138974657374636861696e0301a035a8f8d3cf9b6da72f72363d53291f9744cab20e420e7e6545235e93a3588e74e2c0a035a8f8d3cf9b6da72f72363d53291f9744cab20e420e7e6545235e93a3588e74a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000b1845dc3ade894c0a4e0c9b349b13b5e882770bfcf20e985691298b841e745098aff2ddbae9aefbb72850f3ff7542dd26bc06252d235f9975a52152a6e257ce7195a31e14b4be0f185b59fd56f5dc3ee444759d16e8ae7964e9370436b00c0")
-	require.Nil(err)
-
-	// Should be able to encode/decode blocks before Script2.0 fork.
-	b1 := &Block{}
-	err = rlp.DecodeBytes(v1, b1)
-	require.Nil(err)
-
-	raw, err := rlp.EncodeToBytes(b1)
-	require.Nil(err)
-	require.Equal(v1, raw)
-	// Block hash should remain the same.
-	require.Equal(oldBlockHash, b1.Hash())
+	// Serialized block at height 1 before Gurdian fork. Since the chain enables the Script2.0
+	// feature from genesis, such a block can only be decoded if the fork is scheduled later.
+	if common.HeightEnableScript2 > 1 {
+		oldBlockHash := common.HexToHash("0xf1a7fa371f6a108bb4f2ed33de26ac006f0d8cf6a0ed9dc2c1d9547b6cf43cae")
+		v1, err := hex.DecodeString("f90217f902138974657374636861696e0301a035a8f8d3cf9b6da72f72363d53291f9744cab20e420e7e6545235e93a3588e74e2c0a035a8f8d3cf9b6da72f72363d53291f9744cab20e420e7e6545235e93a3588e74a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000b1845dc3ade894c0a4e0c9b349b13b5e882770bfcf20e985691298b841e745098aff2ddbae9aefbb72850f3ff7542dd26bc06252d235f9975a52152a6e257ce7195a31e14b4be0f185b59fd56f5dc3ee444759d16e8ae7964e9370436b00c0")
+		require.Nil(err)
+
+		// Should be able to encode/decode blocks before Script2.0 fork.
+		b1 := &Block{}
+		err = rlp.DecodeBytes(v1, b1)
+		require.Nil(err)
+
+		raw, err := rlp.EncodeToBytes(b1)
+		require.Nil(err)
+		require.Equal(v1, raw)
+		// Block hash should remain the same.
+		require.Equal(oldBlockHash, b1.Hash())
+	}
 
 	// Should be able to encode/decode blocks before Script2.0 fork.
 	CreateTestBlock("root", "")
@@ -36,7 +39,7 @@ func TestBlockEncoding(t *testing.T) {
 	b2.AddTxs([]common.Bytes{common.Hex2Bytes("aaa")})
 	b2raw1, _ := rlp.EncodeToBytes(b2)
 	tmp := &Block{}
-	err = rlp.DecodeBytes(b2raw1, tmp)
+	err := rlp.DecodeBytes(b2raw1, tmp)
 	require.Nil(err)
 	b2raw2, _ := rlp.EncodeToBytes(tmp)
 	require.Equal(b2raw1, b2raw2)
@@ -76,6 +79,44 @@ func TestBlockEncoding(t *testing.T) {
 	require.Nil(err)
 }
 
+func TestBlockEncodingVRFProposerFork(t *testing.T) {
+	require := require.New(t)
+
+	proof := common.Bytes{0x01, 0x02, 0x03}
+
+	// The VRF proof is not part of the blocks before the fork, whose encoding and hash are unchanged
+	b1 := CreateTestBlock("vrf_b1", "")
+	b1.Height = common.HeightEnableVRFProposer - 1
+	b1raw1, err := rlp.EncodeToBytes(b1)
+	require.Nil(err)
+	b1hash := b1.UpdateHash()
+	b1.VRFProof = proof
+	b1raw2, err := rlp.EncodeToBytes(b1)
+	require.Nil(err)
+	require.Equal(b1raw1, b1raw2)
+	require.Equal(b1hash, b1.UpdateHash())
+	tmp := &Block{}
+	require.Nil(rlp.DecodeBytes(b1raw2, tmp))
+	require.Nil(tmp.VRFProof)
+	require.Equal(b1hash, tmp.Hash())
+
+	// The VRF proof is part of the blocks from the fork onward
+	b2 := CreateTestBlock("vrf_b2", "")
+	b2.Height = common.HeightEnableVRFProposer
+	b2hash := b2.UpdateHash()
+	b2.VRFProof = proof
+	require.NotEqual(b2hash, b2.UpdateHash())
+	b2raw1, err := rlp.EncodeToBytes(b2)
+	require.Nil(err)
+	tmp = &Block{}
+	require.Nil(rlp.DecodeBytes(b2raw1, tmp))
+	require.Equal(proof, tmp.VRFProof)
+	require.Equal(b2.Hash(), tmp.Hash())
+	b2raw2, err := rlp.EncodeToBytes(tmp)
+	require.Nil(err)
+	require.Equal(b2raw1, b2raw2)
+}
+
 func TestBlockHash(t *testing.T) {
 	assert := assert.New(t)
 
@@ -63,6 +63,21 @@ func (b *Bloom) Add(d *big.Int) {
 	b.SetBytes(bin.Bytes())
 }
 
+// AddBytes adds the raw bytes d to the filter. Unlike Add, leading zero bytes are
+// preserved, so it is suitable for addresses and log topics.
+func (b *Bloom) AddBytes(d []byte) {
+	bin := new(big.Int).SetBytes(b[:])
+	bin.Or(bin, bloom9(d))
+	b.SetBytes(bin.Bytes())
+}
+
+// Or merges the bits of other into the filter.
+func (b *Bloom) Or(other Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
 // Big converts b to a big integer.
 func (b Bloom) Big() *big.Int {
 	return new(big.Int).SetBytes(b[:])
@@ -81,6 +96,14 @@ func (b Bloom) TestBytes(test []byte) bool {
 
 }
 
+// ContainsBytes reports whether the raw bytes d may have been added to the filter
+// with AddBytes.
+func (b Bloom) ContainsBytes(d []byte) bool {
+	bloom := b.Big()
+	cmp := bloom9(d)
+	return bloom.And(bloom, cmp).Cmp(cmp) == 0
+}
+
 // MarshalText encodes b as a hex string with 0x prefix.
 func (b Bloom) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(b[:]).MarshalText()
@@ -2,19 +2,19 @@ package core
 
 import (
 	"github.com/scripttoken/script/common"
-	"github.com/scripttoken/script/crypto"
 )
 
 // ConsensusEngine is the interface of a consensus engine.
 type ConsensusEngine interface {
 	ID() string
-	PrivateKey() *crypto.PrivateKey
+	Signer() Signer
 	GetTip(includePendingBlockingLeaf bool) *ExtendedBlock
 	GetEpoch() uint64
 	GetLedger() Ledger
 	AddMessage(msg interface{})
 	FinalizedBlocks() chan *Block
 	GetLastFinalizedBlock() *ExtendedBlock
+	GetDoubleSignProofs() []*DoubleSignProof
 }
 
 // ValidatorManager is the component for managing validator related logic for consensus engine.
@@ -25,3 +25,19 @@ type ValidatorManager interface {
 	GetValidatorSet(blockHash common.Hash) *ValidatorSet
 	GetNextValidatorSet(blockHash common.Hash) *ValidatorSet
 }
+
+// VRFValidatorManager is the ValidatorManager whose proposers prove their eligibility with a VRF
+// instead of being derived from public data, so that the upcoming proposers cannot be known in advance.
+type VRFValidatorManager interface {
+	ValidatorManager
+
+	// IsVRFEnabled returns whether the proposer of the block at the given height proves its eligibility with a VRF.
+	IsVRFEnabled(height uint64) bool
+
+	// ProveEligibility returns the VRF proof if the signer is eligible to propose the child of the parent block
+	// in the epoch, or nil otherwise.
+	ProveEligibility(parent *BlockHeader, epoch uint64, signer Signer) (common.Bytes, error)
+
+	// VerifyEligibility verifies the VRF proof of the block proposer.
+	VerifyEligibility(parent *BlockHeader, block *BlockHeader) error
+}
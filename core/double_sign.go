@@ -0,0 +1,166 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/result"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/rlp"
+)
+
+const (
+	// MaxDoubleSignProofAge is the number of blocks after the conflicting blocks during which the
+	// offender can be slashed. It is shorter than the stake return locking period, so that the
+	// offender cannot withdraw its stake before the proof is included in a block.
+	MaxDoubleSignProofAge uint64 = ReturnLockingPeriod / 2
+
+	// DoubleSignSlashPercentage is the percentage of the offender's validator stake burned
+	DoubleSignSlashPercentage int64 = 10
+)
+
+// DoubleSignProof proves that a validator signed two conflicting blocks at the same height. The
+// conflict is either two votes in the same epoch for different blocks, in which case the headers
+// of the voted blocks bind the votes to their height, or two blocks proposed in the same epoch.
+type DoubleSignProof struct {
+	Offender common.Address
+	HeaderA  *BlockHeader
+	HeaderB  *BlockHeader
+	VoteA    *Vote `rlp:"nil"` // Only set for conflicting votes
+	VoteB    *Vote `rlp:"nil"` // Only set for conflicting votes
+}
+
+// NewVoteDoubleSignProof creates the proof of two conflicting votes. The votes are ordered by block
+// hash, so that the same pair of votes always produces the same proof.
+func NewVoteDoubleSignProof(voteA Vote, headerA *BlockHeader, voteB Vote, headerB *BlockHeader) *DoubleSignProof {
+	if bytes.Compare(headerA.Hash().Bytes(), headerB.Hash().Bytes()) > 0 {
+		voteA, voteB = voteB, voteA
+		headerA, headerB = headerB, headerA
+	}
+	return &DoubleSignProof{
+		Offender: voteA.ID,
+		HeaderA:  headerA,
+		HeaderB:  headerB,
+		VoteA:    &voteA,
+		VoteB:    &voteB,
+	}
+}
+
+// NewProposalDoubleSignProof creates the proof of two conflicting proposals. The headers are ordered
+// by hash, so that the same pair of proposals always produces the same proof.
+func NewProposalDoubleSignProof(headerA *BlockHeader, headerB *BlockHeader) *DoubleSignProof {
+	if bytes.Compare(headerA.Hash().Bytes(), headerB.Hash().Bytes()) > 0 {
+		headerA, headerB = headerB, headerA
+	}
+	return &DoubleSignProof{
+		Offender: headerA.Proposer,
+		HeaderA:  headerA,
+		HeaderB:  headerB,
+	}
+}
+
+// IsVoteConflict returns whether the proof consists of conflicting votes rather than conflicting proposals.
+func (p *DoubleSignProof) IsVoteConflict() bool {
+	return p.VoteA != nil || p.VoteB != nil
+}
+
+// Height returns the height of the conflicting blocks.
+func (p *DoubleSignProof) Height() uint64 {
+	if p.HeaderA == nil {
+		return 0
+	}
+	return p.HeaderA.Height
+}
+
+// Hash returns the hash of the proof.
+func (p *DoubleSignProof) Hash() common.Hash {
+	raw, _ := rlp.EncodeToBytes(p)
+	return crypto.Keccak256Hash(raw)
+}
+
+// OffenceKey identifies the offence, i.e. the offender and the height of the conflicting blocks. An
+// offender is slashed at most once per height, no matter how many conflicting pairs are found.
+func (p *DoubleSignProof) OffenceKey() common.Hash {
+	height := make([]byte, 8)
+	binary.BigEndian.PutUint64(height, p.Height())
+	return crypto.Keccak256Hash(p.Offender[:], height)
+}
+
+// Validate checks that the proof is legitimate, i.e. that the offender did sign both blocks.
+func (p *DoubleSignProof) Validate(chainID string) result.Result {
+	if p.Offender.IsEmpty() {
+		return result.Error("Offender is not specified")
+	}
+	if p.HeaderA == nil || p.HeaderB == nil {
+		return result.Error("Conflicting block headers are missing")
+	}
+	if p.HeaderA.ChainID != chainID || p.HeaderB.ChainID != chainID {
+		return result.Error("ChainID mismatch")
+	}
+	if p.HeaderA.Hash() == p.HeaderB.Hash() {
+		return result.Error("Block headers are not conflicting")
+	}
+	if p.HeaderA.Height != p.HeaderB.Height {
+		return result.Error("Block headers have different heights: %v vs %v", p.HeaderA.Height, p.HeaderB.Height)
+	}
+
+	if p.IsVoteConflict() {
+		return p.validateVotes()
+	}
+	return p.validateProposals()
+}
+
+func (p *DoubleSignProof) validateVotes() result.Result {
+	if p.VoteA == nil || p.VoteB == nil {
+		return result.Error("Conflicting votes are missing")
+	}
+	if p.VoteA.ID != p.Offender || p.VoteB.ID != p.Offender {
+		return result.Error("Votes are not signed by the offender")
+	}
+	if p.VoteA.Epoch != p.VoteB.Epoch {
+		return result.Error("Votes have different epochs: %v vs %v", p.VoteA.Epoch, p.VoteB.Epoch)
+	}
+	if p.VoteA.Block != p.HeaderA.Hash() || p.VoteB.Block != p.HeaderB.Hash() {
+		return result.Error("Votes do not match the block headers")
+	}
+	if res := p.VoteA.Validate(); res.IsError() {
+		return res
+	}
+	return p.VoteB.Validate()
+}
+
+func (p *DoubleSignProof) validateProposals() result.Result {
+	if p.HeaderA.Proposer != p.Offender || p.HeaderB.Proposer != p.Offender {
+		return result.Error("Blocks are not proposed by the offender")
+	}
+	if p.HeaderA.Epoch != p.HeaderB.Epoch {
+		return result.Error("Blocks have different epochs: %v vs %v", p.HeaderA.Epoch, p.HeaderB.Epoch)
+	}
+	if bytes.Equal(p.HeaderA.SignBytes(), p.HeaderB.SignBytes()) {
+		// The same block signed twice is not a conflict
+		return result.Error("Blocks only differ in their signatures")
+	}
+	for _, header := range []*BlockHeader{p.HeaderA, p.HeaderB} {
+		if header.Signature == nil || header.Signature.IsEmpty() {
+			return result.Error("Block is not signed")
+		}
+		if !header.Signature.Verify(header.SignBytes(), header.Proposer) {
+			return result.Error("Block signature verification failed")
+		}
+	}
+	return result.OK
+}
+
+func (p *DoubleSignProof) String() string {
+	if p == nil {
+		return "nil-DoubleSignProof"
+	}
+	if p.IsVoteConflict() {
+		return fmt.Sprintf("DoubleSignProof{offender: %v, height: %v, votes: [%v, %v]}",
+			p.Offender.Hex(), p.Height(), p.VoteA, p.VoteB)
+	}
+	return fmt.Sprintf("DoubleSignProof{offender: %v, height: %v, proposals: [%v, %v]}",
+		p.Offender.Hex(), p.Height(), p.HeaderA.Hash().Hex(), p.HeaderB.Hash().Hex())
+}
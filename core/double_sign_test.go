@@ -0,0 +1,123 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSignedTestHeader(privKey *crypto.PrivateKey, epoch uint64, height uint64, timestamp int64) *BlockHeader {
+	header := &BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     epoch,
+		Height:    height,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(timestamp),
+		Proposer:  privKey.PublicKey().Address(),
+	}
+	header.Signature, _ = privKey.Sign(header.SignBytes())
+	return header
+}
+
+func createSignedTestVote(privKey *crypto.PrivateKey, header *BlockHeader, epoch uint64) Vote {
+	vote := Vote{
+		Block:  header.Hash(),
+		Height: header.Height,
+		Epoch:  epoch,
+		ID:     privKey.PublicKey().Address(),
+	}
+	vote.Sign(privKey)
+	return vote
+}
+
+func TestProposalDoubleSignProof(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	headerA := createSignedTestHeader(privKey, 5, 10, 1000)
+	headerB := createSignedTestHeader(privKey, 5, 10, 1001)
+
+	proof := NewProposalDoubleSignProof(headerA, headerB)
+	assert.False(proof.IsVoteConflict())
+	assert.Equal(privKey.PublicKey().Address(), proof.Offender)
+	assert.Equal(uint64(10), proof.Height())
+	assert.True(proof.Validate("testchain").IsOK())
+	assert.True(proof.Validate("otherchain").IsError())
+
+	// The same pair of proposals always produces the same proof
+	assert.Equal(proof.Hash(), NewProposalDoubleSignProof(headerB, headerA).Hash())
+
+	// Should survive the encoding
+	raw, err := rlp.EncodeToBytes(proof)
+	assert.Nil(err)
+	decoded := &DoubleSignProof{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.False(decoded.IsVoteConflict())
+	assert.True(decoded.Validate("testchain").IsOK())
+	assert.Equal(proof.OffenceKey(), decoded.OffenceKey())
+
+	// Proposals in different epochs do not conflict
+	headerC := createSignedTestHeader(privKey, 6, 10, 1002)
+	assert.True(NewProposalDoubleSignProof(headerA, headerC).Validate("testchain").IsError())
+
+	// Proposals at different heights do not conflict
+	headerD := createSignedTestHeader(privKey, 5, 11, 1003)
+	assert.True(NewProposalDoubleSignProof(headerA, headerD).Validate("testchain").IsError())
+
+	// The same proposal does not conflict with itself
+	assert.True(NewProposalDoubleSignProof(headerA, headerA).Validate("testchain").IsError())
+
+	// Both proposals must be signed by the offender
+	anotherPrivKey, _, _ := crypto.GenerateKeyPair()
+	forgedHeader := createSignedTestHeader(privKey, 5, 10, 1004)
+	forgedHeader.Signature, _ = anotherPrivKey.Sign(forgedHeader.SignBytes())
+	assert.True(NewProposalDoubleSignProof(headerA, forgedHeader).Validate("testchain").IsError())
+}
+
+func TestVoteDoubleSignProof(t *testing.T) {
+	assert := assert.New(t)
+
+	proposerKey, _, _ := crypto.GenerateKeyPair()
+	headerA := createSignedTestHeader(proposerKey, 5, 10, 1000)
+	headerB := createSignedTestHeader(proposerKey, 6, 10, 1001)
+
+	voterKey, _, _ := crypto.GenerateKeyPair()
+	voteA := createSignedTestVote(voterKey, headerA, 7)
+	voteB := createSignedTestVote(voterKey, headerB, 7)
+
+	proof := NewVoteDoubleSignProof(voteA, headerA, voteB, headerB)
+	assert.True(proof.IsVoteConflict())
+	assert.Equal(voterKey.PublicKey().Address(), proof.Offender)
+	assert.True(proof.Validate("testchain").IsOK())
+	assert.Equal(proof.Hash(), NewVoteDoubleSignProof(voteB, headerB, voteA, headerA).Hash())
+
+	// Should survive the encoding
+	raw, err := rlp.EncodeToBytes(proof)
+	assert.Nil(err)
+	decoded := &DoubleSignProof{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.True(decoded.IsVoteConflict())
+	assert.True(decoded.Validate("testchain").IsOK())
+
+	// Votes in different epochs do not conflict, e.g. an honest validator voting on a fork after
+	// the epoch changed
+	voteC := createSignedTestVote(voterKey, headerB, 8)
+	assert.True(NewVoteDoubleSignProof(voteA, headerA, voteC, headerB).Validate("testchain").IsError())
+
+	// Votes for blocks at different heights do not conflict
+	headerD := createSignedTestHeader(proposerKey, 6, 11, 1002)
+	voteD := createSignedTestVote(voterKey, headerD, 7)
+	assert.True(NewVoteDoubleSignProof(voteA, headerA, voteD, headerD).Validate("testchain").IsError())
+
+	// The votes must match the headers
+	assert.True(NewVoteDoubleSignProof(voteA, headerB, voteB, headerA).Validate("testchain").IsError())
+
+	// The votes must be signed by the offender
+	forgedVote := voteB
+	forgedVote.Signature = voteA.Signature
+	assert.True(NewVoteDoubleSignProof(voteA, headerA, forgedVote, headerB).Validate("testchain").IsError())
+}
@@ -39,8 +39,8 @@ func (a *AggregatedVotes) String() string {
 	return fmt.Sprintf("AggregatedVotes{Block: %s, Gcp: %s,  Multiplies: %v}", a.Block.Hex(), a.Gcp.Hex(), a.Multiplies)
 }
 
-// signBytes returns the bytes to be signed.
-func (a *AggregatedVotes) signBytes() common.Bytes {
+// SignBytes returns the bytes to be signed.
+func (a *AggregatedVotes) SignBytes() common.Bytes {
 	tmp := &AggregatedVotes{
 		Block: a.Block,
 		Gcp:   a.Gcp,
@@ -56,8 +56,19 @@ func (a *AggregatedVotes) Sign(key *bls.SecretKey, signerIdx int) bool {
 		return false
 	}
 
+	return a.AddSignature(key.Sign(a.SignBytes()), signerIdx)
+}
+
+// AddSignature adds the signature of the signer, e.g. produced by an external signer. Returns
+// false if signer has already signed.
+func (a *AggregatedVotes) AddSignature(sig *bls.Signature, signerIdx int) bool {
+	if a.Multiplies[signerIdx] > 0 {
+		// Already signed, do nothing.
+		return false
+	}
+
 	a.Multiplies[signerIdx] = 1
-	a.Signature.Aggregate(key.Sign(a.signBytes()))
+	a.Signature.Aggregate(sig)
 	return true
 }
 
@@ -127,7 +138,7 @@ func (a *AggregatedVotes) Validate(gcp *GuardianCandidatePool) result.Result {
 	}
 	pubKeys := gcp.WithStake().PubKeys()
 	aggPubkey := bls.AggregatePublicKeysVec(pubKeys, a.Multiplies)
-	if !a.Signature.Verify(a.signBytes(), aggPubkey) {
+	if !a.Signature.Verify(a.SignBytes(), aggPubkey) {
 		return result.Error("signature verification failed")
 	}
 	return result.OK
@@ -15,11 +15,22 @@ const (
 	ScreenedView  ViewSelector = 3
 )
 
+// TxLane classifies the transactions for the block assembly.
+type TxLane byte
+
+const (
+	TxLaneRegular        TxLane = 0
+	TxLaneStake          TxLane = 1
+	TxLaneServicePayment TxLane = 2
+)
+
 // TxInfo encapsulates information used by mempool to sorting.
 type TxInfo struct {
 	EffectiveGasPrice *big.Int
 	Address           common.Address
 	Sequence          uint64
+	Gas               uint64 // the maximum amount of gas the transaction can consume
+	Lane              TxLane
 }
 
 // Ledger defines the interface of the ledger
@@ -27,6 +38,9 @@ type Ledger interface {
 	GetCurrentBlock() *Block
 	ScreenTxUnsafe(rawTx common.Bytes) result.Result
 	ScreenTx(rawTx common.Bytes) (priority *TxInfo, res result.Result)
+	GetTxInfo(rawTx common.Bytes) (txInfo *TxInfo, res result.Result)
+	PreverifyTx(rawTx common.Bytes) result.Result
+	ResetScreenedState() result.Result
 	ProposeBlockTxs(block *Block, shouldIncludeValidatorUpdateTxs bool) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result)
 	ApplyBlockTxs(block *Block) result.Result
 	ApplyBlockTxsForChainCorrection(block *Block) (common.Hash, result.Result)
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/crypto/bls"
+)
+
+// Signer signs the consensus messages and the block transactions with the node key. The key is
+// either held by the node itself, or by an external signer process so that it never lives on the
+// node host.
+type Signer interface {
+	// PublicKey returns the public key of the node key.
+	PublicKey() *crypto.PublicKey
+
+	// BLSPublicKey returns the public key of the guardian BLS key.
+	BLSPublicKey() *bls.PublicKey
+
+	// SignVote sets the signature of the vote.
+	SignVote(vote *Vote) error
+
+	// SignProposal sets the signature of the block proposed by the node.
+	SignProposal(header *BlockHeader) error
+
+	// SignTx signs a transaction issued by the block proposer, i.e. a coinbase or a double sign
+	// slash transaction, given in its encoded form. The signature covers the sign bytes of the
+	// transaction for the chain.
+	SignTx(chainID string, rawTx common.Bytes) (*crypto.Signature, error)
+
+	// SignGuardianVote adds the BLS signature of the guardian to the aggregated votes.
+	SignGuardianVote(vote *AggregatedVotes, signerIdx int) error
+
+	// ProveVRF returns the VRF output of the message computed with the node key, and its proof.
+	ProveVRF(msg common.Bytes) (output common.Hash, proof common.Bytes, err error)
+
+	// ProveBLSKey returns the proof of possession of the BLS key, and the signature of the proof
+	// by the node key, which binds the BLS key to the node address.
+	ProveBLSKey() (pop *bls.Signature, sig *crypto.Signature, err error)
+}
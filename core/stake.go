@@ -182,6 +182,19 @@ func (sh *StakeHolder) returnStake(source common.Address, currentHeight uint64)
 	return nil, fmt.Errorf("Cannot return, no matched stake source address found: %v", source)
 }
 
+// slashStake burns the given percentage of each stake, including the stakes being withdrawn, and
+// returns the total amount burned.
+func (sh *StakeHolder) slashStake(percentage int64) *big.Int {
+	totalSlashed := new(big.Int).SetUint64(0)
+	for _, stake := range sh.Stakes {
+		slashed := new(big.Int).Mul(stake.Amount, big.NewInt(percentage))
+		slashed.Div(slashed, big.NewInt(100))
+		stake.Amount = new(big.Int).Sub(stake.Amount, slashed)
+		totalSlashed.Add(totalSlashed, slashed)
+	}
+	return totalSlashed
+}
+
 func (sh *StakeHolder) String() string {
 	return fmt.Sprintf("{holder: %v, stakes :%v}", sh.Holder, sh.Stakes)
 }
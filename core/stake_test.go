@@ -80,8 +80,10 @@ func TestStakeWithdraw(t *testing.T) {
 	assert.Nil(stakeHolder.depositStake(sourceAddr2, stake2Amount1))
 	assert.True(stakeHolder.TotalStake().Cmp(new(big.Int).SetUint64(9000)) == 0)
 
-	assert.Nil(stakeHolder.withdrawStake(sourceAddr1, currentHeight))
-	assert.NotNil(stakeHolder.withdrawStake(sourceAddr1, currentHeight)) // cannot withdraw twice
+	_, err := stakeHolder.withdrawStake(sourceAddr1, currentHeight)
+	assert.Nil(err)
+	_, err = stakeHolder.withdrawStake(sourceAddr1, currentHeight)
+	assert.NotNil(err) // cannot withdraw twice
 	assert.True(stakeHolder.TotalStake().Cmp(new(big.Int).SetUint64(8000)) == 0)
 
 	assert.NotNil(stakeHolder.depositStake(sourceAddr1, stake1Amount2)) // sourceAddr1 cannot deposit more stake since it is is in the withdrawal locking period
@@ -90,7 +92,8 @@ func TestStakeWithdraw(t *testing.T) {
 	assert.Nil(stakeHolder.depositStake(sourceAddr3, stake3Amount3))
 	assert.True(stakeHolder.TotalStake().Cmp(new(big.Int).SetUint64(9600)) == 0)
 
-	assert.NotNil(stakeHolder.withdrawStake(sourceAddr4, currentHeight)) // sourceAddr4 never deposited, should not be able to withdraw
+	_, err = stakeHolder.withdrawStake(sourceAddr4, currentHeight)
+	assert.NotNil(err) // sourceAddr4 never deposited, should not be able to withdraw
 }
 
 func TestStakeReturn(t *testing.T) {
@@ -114,7 +117,8 @@ func TestStakeReturn(t *testing.T) {
 	stakeHolder.depositStake(sourceAddr2, stake2Amount1)
 	assert.True(stakeHolder.TotalStake().Cmp(new(big.Int).SetUint64(13000)) == 0)
 
-	assert.Nil(stakeHolder.withdrawStake(sourceAddr1, initHeight))
+	_, err := stakeHolder.withdrawStake(sourceAddr1, initHeight)
+	assert.Nil(err)
 	assert.True(stakeHolder.TotalStake().Cmp(new(big.Int).SetUint64(8000)) == 0)
 	assert.Equal(2, len(stakeHolder.Stakes))
 
@@ -255,6 +255,24 @@ func (vcp *ValidatorCandidatePool) WithdrawStake(source common.Address, holder c
 	return nil
 }
 
+// SlashStake burns the given percentage of the stakes delegated to the holder, and returns the total
+// amount burned.
+func (vcp *ValidatorCandidatePool) SlashStake(holder common.Address, percentage int64) (*big.Int, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, fmt.Errorf("Invalid slash percentage: %v", percentage)
+	}
+
+	candidate := vcp.FindStakeDelegate(holder)
+	if candidate == nil {
+		return nil, fmt.Errorf("No matched stake holder address found: %v", holder)
+	}
+	slashed := candidate.slashStake(percentage)
+
+	vcp.sortCandidates()
+
+	return slashed, nil
+}
+
 func (vcp *ValidatorCandidatePool) ReturnStakes(currentHeight uint64) []*Stake {
 	returnedStakes := []*Stake{}
 
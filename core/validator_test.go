@@ -137,21 +137,21 @@ func TestValidatorCandidatePool(t *testing.T) {
 	log.Infof("--------------------------------------------------------")
 	log.Infof("")
 
-	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr1, stake1Amount1))
-	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr1, stake2Amount1))
-	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr1, stake3Amount2))
+	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr1, stake1Amount1, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr1, stake2Amount1, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr1, stake3Amount2, 0))
 
-	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr2, stake1Amount2))
-	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr2, stake2Amount2))
-	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr2, stake3Amount2))
+	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr2, stake1Amount2, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr2, stake2Amount2, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr2, stake3Amount2, 0))
 
-	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr3, stake3Amount1))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr3, stake3Amount1, 0))
 
-	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr4, stake3Amount3))
-	assert.Nil(vcp.DepositStake(sourceAddr4, holderAddr4, stake4Amount1))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr4, stake3Amount3, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr4, holderAddr4, stake4Amount1, 0))
 
-	assert.NotNil(vcp.DepositStake(sourceAddr4, holderAddr2, invalidStakeAmount))
-	assert.NotNil(vcp.DepositStake(sourceAddr3, holderAddr6, insufficientStakeAmount))
+	assert.NotNil(vcp.DepositStake(sourceAddr4, holderAddr2, invalidStakeAmount, 0))
+	assert.NotNil(vcp.DepositStake(sourceAddr3, holderAddr6, insufficientStakeAmount, 0))
 
 	assert.True(len(vcp.SortedCandidates) == 4)
 	assert.True(vcp.SortedCandidates[0].TotalStake().Cmp(new(big.Int).Mul(new(big.Int).SetUint64(13200), MinValidatorStakeDeposit)) == 0)
@@ -198,14 +198,14 @@ func TestValidatorCandidatePool(t *testing.T) {
 	log.Infof("--------------------------------------------------------")
 	log.Infof("")
 
-	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stake5Amount1))
-	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stake5Amount2))
+	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stake5Amount1, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stake5Amount2, 0))
 
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount1))
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount2))
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount3))
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount4))
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount5))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount1, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount2, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount3, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount4, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount5, 0))
 
 	checkAndPrintAllSortedCandidates(t, assert, vcp)
 	checkAndPrintTopCandidates(t, assert, vcp, 3)
@@ -220,8 +220,8 @@ func TestValidatorCandidatePool(t *testing.T) {
 
 	assert.NotNil(vcp.WithdrawStake(sourceAddr5, holderAddr6, height2)) // sourceAddr5 never deposited to holderAddr6, so cannot withraw from holderAddr6
 	assert.Nil(vcp.WithdrawStake(sourceAddr6, holderAddr6, height2))
-	assert.NotNil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount2)) // cannot deposit during the withdrawal locking period
-	assert.True(len(vcp.SortedCandidates) == 6)                              // holderAddr6's stake not returned yet, should it should still be in the candidate list
+	assert.NotNil(vcp.DepositStake(sourceAddr6, holderAddr6, stake6Amount2, 0)) // cannot deposit during the withdrawal locking period
+	assert.True(len(vcp.SortedCandidates) == 6)                                 // holderAddr6's stake not returned yet, should it should still be in the candidate list
 	assert.True(vcp.SortedCandidates[5].Holder == holderAddr6)
 	assert.True(vcp.SortedCandidates[5].TotalStake().Cmp(Zero) == 0) // All stakes are withdrawn
 	checkAndPrintAllSortedCandidates(t, assert, vcp)
@@ -277,7 +277,7 @@ func TestValidatorCandidatePool(t *testing.T) {
 
 	assert.Nil(vcp.WithdrawStake(sourceAddr1, holderAddr1, height6))
 	assert.Nil(vcp.WithdrawStake(sourceAddr2, holderAddr1, height6))
-	assert.NotNil(vcp.DepositStake(sourceAddr2, holderAddr1, stake2Amount2)) // cannot deposit during the withdrawal locking period
+	assert.NotNil(vcp.DepositStake(sourceAddr2, holderAddr1, stake2Amount2, 0)) // cannot deposit during the withdrawal locking period
 	assert.True(len(vcp.SortedCandidates) == 4)
 	assert.True(len(vcp.SortedCandidates[3].Stakes) == 3)
 	assert.True(vcp.SortedCandidates[3].TotalStake().Cmp(stake3Amount2) == 0) // Both sourceAddr1 and sourceAddr2 have withdrawn, only sourceAddr3's deposited stake is still effective
@@ -302,6 +302,55 @@ func TestValidatorCandidatePool(t *testing.T) {
 	checkAndPrintTopCandidates(t, assert, vcp, 3)
 }
 
+func TestValidatorCandidatePoolSlashStake(t *testing.T) {
+	assert := assert.New(t)
+
+	sourceAddr1 := common.HexToAddress("0x111")
+	stake1Amount := new(big.Int).Mul(new(big.Int).SetUint64(1000), MinValidatorStakeDeposit)
+
+	sourceAddr2 := common.HexToAddress("0x222")
+	stake2Amount := new(big.Int).Mul(new(big.Int).SetUint64(3000), MinValidatorStakeDeposit)
+
+	sourceAddr3 := common.HexToAddress("0x333")
+	stake3Amount := new(big.Int).Mul(new(big.Int).SetUint64(2000), MinValidatorStakeDeposit)
+
+	holderAddr1 := common.HexToAddress("0xf01")
+	holderAddr2 := common.HexToAddress("0xf02")
+	holderAddr3 := common.HexToAddress("0xf03")
+
+	vcp := &ValidatorCandidatePool{}
+	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr1, stake1Amount, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr1, stake2Amount, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr2, stake3Amount, 0))
+	assert.Nil(vcp.WithdrawStake(sourceAddr2, holderAddr1, 100))
+	assert.Equal(holderAddr2, vcp.SortedCandidates[0].Holder)
+
+	// Invalid percentages and unknown holders are rejected
+	_, err := vcp.SlashStake(holderAddr1, -1)
+	assert.NotNil(err)
+	_, err = vcp.SlashStake(holderAddr1, 101)
+	assert.NotNil(err)
+	_, err = vcp.SlashStake(holderAddr3, 10)
+	assert.NotNil(err)
+
+	// The stakes being withdrawn are slashed too
+	slashed, err := vcp.SlashStake(holderAddr1, 10)
+	assert.Nil(err)
+	expectedSlashed := new(big.Int).Mul(new(big.Int).SetUint64(400), MinValidatorStakeDeposit)
+	assert.True(slashed.Cmp(expectedSlashed) == 0)
+	holder1 := vcp.FindStakeDelegate(holderAddr1)
+	assert.True(holder1.Stakes[0].Amount.Cmp(new(big.Int).Mul(new(big.Int).SetUint64(900), MinValidatorStakeDeposit)) == 0)
+	assert.True(holder1.Stakes[1].Amount.Cmp(new(big.Int).Mul(new(big.Int).SetUint64(2700), MinValidatorStakeDeposit)) == 0)
+	assert.True(holder1.Stakes[1].Withdrawn)
+
+	// The candidates are re-sorted by the remaining stakes
+	slashed, err = vcp.SlashStake(holderAddr2, 100)
+	assert.Nil(err)
+	assert.True(slashed.Cmp(stake3Amount) == 0)
+	assert.True(vcp.FindStakeDelegate(holderAddr2).TotalStake().Cmp(big.NewInt(0)) == 0)
+	assert.Equal(holderAddr1, vcp.SortedCandidates[0].Holder)
+}
+
 func TestValidatorSetUniqueSortedOrder(t *testing.T) {
 	assert := assert.New(t)
 
@@ -323,12 +372,12 @@ func TestValidatorSetUniqueSortedOrder(t *testing.T) {
 	holderAddr6 := common.HexToAddress("0x666")
 
 	vcp := &ValidatorCandidatePool{}
-	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr3, stakeAmountA))
-	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr1, stakeAmountA))
-	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stakeAmountB))
-	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr2, stakeAmountA))
-	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stakeAmountB))
-	assert.Nil(vcp.DepositStake(sourceAddr4, holderAddr4, stakeAmountA))
+	assert.Nil(vcp.DepositStake(sourceAddr3, holderAddr3, stakeAmountA, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr1, holderAddr1, stakeAmountA, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr5, holderAddr5, stakeAmountB, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr2, holderAddr2, stakeAmountA, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr6, holderAddr6, stakeAmountB, 0))
+	assert.Nil(vcp.DepositStake(sourceAddr4, holderAddr4, stakeAmountA, 0))
 
 	vcp.sortCandidates()
 	vcpJson1, _ := json.MarshalIndent(vcp, "", "  ")
@@ -219,15 +219,20 @@ func (sig *Signature) IsEmpty() bool {
 	return len(sig.data) == 0
 }
 
-// RecoverSignerAddress recovers the address of the signer for the given message
-func (sig *Signature) RecoverSignerAddress(msg common.Bytes) (common.Address, error) {
+// RecoverPublicKey recovers the public key of the signer for the given message
+func (sig *Signature) RecoverPublicKey(msg common.Bytes) (*PublicKey, error) {
 	msgHash := keccak256(msg)
 	recoveredUncompressedPubKey, err := ecrecover(msgHash, sig.ToBytes())
 	if err != nil {
-		return common.Address{}, err
+		return nil, err
 	}
 
-	pk, err := PublicKeyFromBytes(recoveredUncompressedPubKey)
+	return PublicKeyFromBytes(recoveredUncompressedPubKey)
+}
+
+// RecoverSignerAddress recovers the address of the signer for the given message
+func (sig *Signature) RecoverSignerAddress(msg common.Bytes) (common.Address, error) {
+	pk, err := sig.RecoverPublicKey(msg)
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -237,10 +242,15 @@ func (sig *Signature) RecoverSignerAddress(msg common.Bytes) (common.Address, er
 }
 
 // Verify verifies the signature with given raw message and address.
+// Successfully verified signatures are cached.
 func (sig *Signature) Verify(msg common.Bytes, addr common.Address) bool {
 	if sig == nil || sig.IsEmpty() {
 		return false
 	}
+	cacheKey := verifiedSignatureKey(nativeSignature, keccak256(msg), addr, sig.data)
+	if isSignatureVerified(cacheKey) {
+		return true
+	}
 	recoveredAddress, err := sig.RecoverSignerAddress(msg)
 	if err != nil {
 		return false
@@ -248,6 +258,7 @@ func (sig *Signature) Verify(msg common.Bytes, addr common.Address) bool {
 	if recoveredAddress != addr {
 		return false
 	}
+	markSignatureVerified(cacheKey)
 	return true
 }
 
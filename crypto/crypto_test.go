@@ -202,6 +202,33 @@ func TestSignaureVerifyBytes(t *testing.T) {
 	assert.False(sig1.Verify(msg1, anotherAddr))
 }
 
+func TestVerifiedSignatureCache(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := TEST_GenerateKeyPairWithSeed("test_seed_cache")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	msg := common.Bytes("Hello cache!")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(err)
+
+	key := verifiedSignatureKey(nativeSignature, keccak256(msg), addr, sig.ToBytes())
+	assert.False(isSignatureVerified(key))
+	assert.True(sig.Verify(msg, addr))
+	assert.True(isSignatureVerified(key))
+
+	// A cached signature should not be accepted for another signer or message
+	anotherAddr := common.BytesToAddress(common.Bytes("hello"))
+	assert.False(sig.Verify(msg, anotherAddr))
+	assert.False(sig.Verify(common.Bytes("Foo bar!"), addr))
+	assert.True(sig.Verify(msg, addr))
+
+	// Failed verifications should not be cached
+	anotherKey := verifiedSignatureKey(nativeSignature, keccak256(msg), anotherAddr, sig.ToBytes())
+	assert.False(isSignatureVerified(anotherKey))
+}
+
 func TestSignatureVerification1(t *testing.T) {
 	assert := assert.New(t)
 
@@ -110,6 +110,14 @@ func HomesteadSignerSender(signingHash common.Hash, sig *Signature) (common.Addr
 }
 
 func ValidateEthSignature(sender common.Address, signingHash common.Hash, sig *Signature) error {
+	if sig == nil {
+		return errors.New("Missing signature")
+	}
+	cacheKey := verifiedSignatureKey(ethSignature, signingHash[:], sender, sig.data)
+	if isSignatureVerified(cacheKey) {
+		return nil
+	}
+
 	recoveredSender, err := HomesteadSignerSender(signingHash, sig)
 	if err != nil {
 		return err
@@ -122,6 +130,7 @@ func ValidateEthSignature(sender common.Address, signingHash common.Hash, sig *S
 		return errors.New(fmt.Sprintf("Recovered sender mismatch, recovered sender: %v, sender: %v", recoveredSender.Hex(), sender.Hex()))
 	}
 
+	markSignatureVerified(cacheKey)
 	return nil
 }
 
@@ -0,0 +1,44 @@
+package crypto
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/scripttoken/script/common"
+)
+
+// verifiedSignatureCacheSize is the maximum number of verified signatures cached
+const verifiedSignatureCacheSize = 65536
+
+// Kinds of the cached signatures, so that a native signature and an ETH signature never share a key
+const (
+	nativeSignature byte = 0
+	ethSignature    byte = 1
+)
+
+// verifiedSignatures caches the signatures which passed the verification, so that the signatures
+// of a transaction are not recovered again each time the transaction is screened, checked and
+// delivered. Only successful verifications are cached.
+var verifiedSignatures *lru.Cache
+
+func init() {
+	var err error
+	verifiedSignatures, err = lru.New(verifiedSignatureCacheSize)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// verifiedSignatureKey returns the cache key of a signature of the given message hash by the signer.
+// The variable length signature comes last, after the fixed length fields, so that different inputs
+// never produce the same preimage.
+func verifiedSignatureKey(kind byte, msgHash []byte, signer common.Address, sig []byte) common.Hash {
+	return keccak256Hash([]byte{kind}, msgHash, signer[:], sig)
+}
+
+func isSignatureVerified(key common.Hash) bool {
+	return verifiedSignatures.Contains(key)
+}
+
+func markSignatureVerified(key common.Hash) {
+	verifiedSignatures.Add(key, struct{}{})
+}
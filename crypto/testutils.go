@@ -1,7 +1,8 @@
 package crypto
 
 import (
-	"crypto/ecdsa"
+	"io"
+	"math/big"
 
 	"github.com/scripttoken/script/common"
 )
@@ -12,12 +13,26 @@ import (
 // WARNING: The following APIs are intended only for unit test case for better repeatibility.
 //          They should NOT be used in the production code.
 
-// TEST_GenerateKeyPairWithSeed generates a random private/public key pair with the given seed string
+// TEST_GenerateKeyPairWithSeed generates a random private/public key pair with the given seed string.
+// The key is derived from the seed like ecdsa.GenerateKey did before Go 1.20, since the newer versions
+// ignore the given random source, and the test fixtures depend on the derived addresses.
 func TEST_GenerateKeyPairWithSeed(seed string) (*PrivateKey, *PublicKey, error) {
 	trr := newTestRandReader(seed)
-	ske, err := ecdsa.GenerateKey(s256(), trr)
-	pke := &(ske.PublicKey)
-	return &PrivateKey{privKey: ske}, &PublicKey{pubKey: pke}, err
+	params := s256().Params()
+	b := make([]byte, params.BitSize/8+8)
+	if _, err := io.ReadFull(trr, b); err != nil {
+		return nil, nil, err
+	}
+	k := new(big.Int).SetBytes(b)
+	n := new(big.Int).Sub(params.N, big.NewInt(1))
+	k.Mod(k, n)
+	k.Add(k, big.NewInt(1))
+
+	sk, err := PrivateKeyFromBytes(common.LeftPadBytes(k.Bytes(), 32))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, sk.PublicKey(), nil
 }
 
 type testRandReader struct {
@@ -0,0 +1,178 @@
+package crypto
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/math"
+	"github.com/scripttoken/script/crypto/secp256k1"
+)
+
+//
+// ------------------- Verifiable Random Function APIs ------------------- //
+//
+// The VRF follows the construction of ECVRF (draft-irtf-cfrg-vrf) on the secp256k1 curve, with
+// Keccak256 as the hash function and try-and-increment as the hash to curve method. Unlike an ECDSA
+// signature, the output is unique for a given key and message, so it cannot be ground by the key
+// holder.
+//
+
+// VRFProofLength is the length of the VRF proof: the compressed Gamma point, the challenge c and
+// the response s.
+const VRFProofLength = 33 + 32 + 32
+
+var (
+	errInvalidVRFProof = errors.New("invalid VRF proof")
+
+	vrfHashToCurveDomain = []byte("script-vrf-h2c")
+	vrfChallengeDomain   = []byte("script-vrf-challenge")
+	vrfNonceDomain       = []byte("script-vrf-nonce")
+	vrfOutputDomain      = []byte("script-vrf-output")
+)
+
+// VRFProve computes the VRF output of the message with the private key, and the proof that the
+// output was computed with the key.
+func (sk *PrivateKey) VRFProve(msg common.Bytes) (output common.Hash, proof common.Bytes, err error) {
+	curve := secp256k1.S256()
+	pkBytes := secp256k1.CompressPubkey(sk.privKey.PublicKey.X, sk.privKey.PublicKey.Y)
+	hx, hy, err := vrfHashToCurve(pkBytes, msg)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+
+	skBytes := math.PaddedBigBytes(sk.privKey.D, 32)
+	defer zeroBytes(skBytes)
+	gammaX, gammaY := curve.ScalarMult(hx, hy, skBytes)
+	if gammaX == nil {
+		return common.Hash{}, nil, errInvalidVRFProof
+	}
+
+	// The nonce is derived from the private key and the message, as in RFC 6979.
+	hBytes := secp256k1.CompressPubkey(hx, hy)
+	nonce := new(big.Int).SetBytes(keccak256(vrfNonceDomain, skBytes, hBytes))
+	nonce.Mod(nonce, curve.N)
+	if nonce.Sign() == 0 {
+		return common.Hash{}, nil, errInvalidVRFProof
+	}
+	nonceBytes := math.PaddedBigBytes(nonce, 32)
+	defer zeroBytes(nonceBytes)
+	ux, uy := curve.ScalarBaseMult(nonceBytes)
+	vx, vy := curve.ScalarMult(hx, hy, nonceBytes)
+	if ux == nil || vx == nil {
+		return common.Hash{}, nil, errInvalidVRFProof
+	}
+
+	gammaBytes := secp256k1.CompressPubkey(gammaX, gammaY)
+	c := vrfChallenge(hBytes, pkBytes, gammaBytes,
+		secp256k1.CompressPubkey(ux, uy), secp256k1.CompressPubkey(vx, vy))
+	s := new(big.Int).Mul(c, sk.privKey.D)
+	s.Sub(nonce, s)
+	s.Mod(s, curve.N)
+
+	proof = make(common.Bytes, 0, VRFProofLength)
+	proof = append(proof, gammaBytes...)
+	proof = append(proof, math.PaddedBigBytes(c, 32)...)
+	proof = append(proof, math.PaddedBigBytes(s, 32)...)
+	return vrfOutput(gammaBytes), proof, nil
+}
+
+// VRFVerify verifies that the proof was computed on the message with the private key of the public
+// key, and returns the VRF output.
+func (pk *PublicKey) VRFVerify(msg common.Bytes, proof common.Bytes) (common.Hash, error) {
+	if pk.IsEmpty() || len(proof) != VRFProofLength {
+		return common.Hash{}, errInvalidVRFProof
+	}
+	curve := secp256k1.S256()
+	gammaBytes := proof[:33]
+	gammaX, gammaY := secp256k1.DecompressPubkey(gammaBytes)
+	if gammaX == nil {
+		return common.Hash{}, errInvalidVRFProof
+	}
+	c := new(big.Int).SetBytes(proof[33:65])
+	s := new(big.Int).SetBytes(proof[65:])
+	if c.Cmp(curve.N) >= 0 || s.Cmp(curve.N) >= 0 {
+		return common.Hash{}, errInvalidVRFProof
+	}
+
+	pkBytes := secp256k1.CompressPubkey(pk.pubKey.X, pk.pubKey.Y)
+	hx, hy, err := vrfHashToCurve(pkBytes, msg)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// U = s*G + c*Y, V = s*H + c*Gamma
+	ux, uy, ok := vrfLinearCombination(s, curve.Gx, curve.Gy, c, pk.pubKey.X, pk.pubKey.Y)
+	if !ok {
+		return common.Hash{}, errInvalidVRFProof
+	}
+	vx, vy, ok := vrfLinearCombination(s, hx, hy, c, gammaX, gammaY)
+	if !ok {
+		return common.Hash{}, errInvalidVRFProof
+	}
+
+	expected := vrfChallenge(secp256k1.CompressPubkey(hx, hy), pkBytes, gammaBytes,
+		secp256k1.CompressPubkey(ux, uy), secp256k1.CompressPubkey(vx, vy))
+	if expected.Cmp(c) != 0 {
+		return common.Hash{}, errInvalidVRFProof
+	}
+	return vrfOutput(gammaBytes), nil
+}
+
+// VRFOutput returns the VRF output of the proof without verifying it. It should only be used on
+// proofs which have been verified already.
+func VRFOutput(proof common.Bytes) (common.Hash, error) {
+	if len(proof) != VRFProofLength {
+		return common.Hash{}, errInvalidVRFProof
+	}
+	return vrfOutput(proof[:33]), nil
+}
+
+func vrfOutput(gammaBytes []byte) common.Hash {
+	return keccak256Hash(vrfOutputDomain, gammaBytes)
+}
+
+func vrfChallenge(points ...[]byte) *big.Int {
+	c := new(big.Int).SetBytes(keccak256(append([][]byte{vrfChallengeDomain}, points...)...))
+	return c.Mod(c, secp256k1.S256().N)
+}
+
+// vrfHashToCurve maps the public key and the message to a curve point with the try-and-increment
+// method. The y coordinate of the point is always even.
+func vrfHashToCurve(pkBytes []byte, msg []byte) (x, y *big.Int, err error) {
+	curve := secp256k1.S256()
+	// p = 3 mod 4, so the square root of a is a^((p+1)/4)
+	sqrtExp := new(big.Int).Add(curve.P, big.NewInt(1))
+	sqrtExp.Rsh(sqrtExp, 2)
+	for ctr := 0; ctr < 256; ctr++ {
+		x = new(big.Int).SetBytes(keccak256(vrfHashToCurveDomain, pkBytes, msg, []byte{byte(ctr)}))
+		x.Mod(x, curve.P)
+
+		// y^2 = x^3 + 7
+		rhs := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+		rhs.Add(rhs, curve.B)
+		rhs.Mod(rhs, curve.P)
+		y = new(big.Int).Exp(rhs, sqrtExp, curve.P)
+		if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(rhs) != 0 {
+			continue
+		}
+		if y.Bit(0) == 1 {
+			y.Sub(curve.P, y)
+		}
+		return x, y, nil
+	}
+	return nil, nil, errors.New("failed to hash the VRF message to the curve")
+}
+
+// vrfLinearCombination returns a*(x1, y1) + b*(x2, y2).
+func vrfLinearCombination(a *big.Int, x1, y1 *big.Int, b *big.Int, x2, y2 *big.Int) (x, y *big.Int, ok bool) {
+	curve := secp256k1.S256()
+	ax, ay := curve.ScalarMult(x1, y1, math.PaddedBigBytes(a, 32))
+	bx, by := curve.ScalarMult(x2, y2, math.PaddedBigBytes(b, 32))
+	if ax == nil || bx == nil || (ax.Cmp(bx) == 0) {
+		// The points at infinity and the doubling of a point are not expected from honest proofs.
+		return nil, nil, false
+	}
+	x, y = curve.Add(ax, ay, bx, by)
+	return x, y, true
+}
@@ -0,0 +1,54 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRF(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := GenerateKeyPair()
+	assert.Nil(err)
+	msg := common.Bytes("seed")
+
+	output, proof, err := privKey.VRFProve(msg)
+	assert.Nil(err)
+	assert.Equal(VRFProofLength, len(proof))
+
+	verifiedOutput, err := pubKey.VRFVerify(msg, proof)
+	assert.Nil(err)
+	assert.Equal(output, verifiedOutput)
+
+	unverifiedOutput, err := VRFOutput(proof)
+	assert.Nil(err)
+	assert.Equal(output, unverifiedOutput)
+
+	// The output is unique for the key and the message
+	output2, _, err := privKey.VRFProve(msg)
+	assert.Nil(err)
+	assert.Equal(output, output2)
+	output3, _, err := privKey.VRFProve(common.Bytes("another seed"))
+	assert.Nil(err)
+	assert.NotEqual(output, output3)
+
+	// The proof does not verify with another message or key
+	_, err = pubKey.VRFVerify(common.Bytes("another seed"), proof)
+	assert.NotNil(err)
+	_, otherPubKey, err := GenerateKeyPair()
+	assert.Nil(err)
+	_, err = otherPubKey.VRFVerify(msg, proof)
+	assert.NotNil(err)
+
+	// Tampered proofs are rejected
+	for _, idx := range []int{1, 40, VRFProofLength - 1} {
+		tampered := append(common.Bytes{}, proof...)
+		tampered[idx] ^= 0x01
+		_, err = pubKey.VRFVerify(msg, tampered)
+		assert.NotNil(err)
+	}
+	_, err = pubKey.VRFVerify(msg, proof[1:])
+	assert.NotNil(err)
+}
@@ -184,7 +184,8 @@ func validateInputAdvanced(acc *types.Account, signBytes []byte, in types.TxInpu
 	seq, balance := acc.Sequence, acc.Balance
 	if seq+1 != in.Sequence {
 		return result.Error("ValidateInputAdvanced: Got %v, expected %v. (acc.seq=%v)",
-			in.Sequence, seq+1, acc.Sequence).WithErrorCode(result.CodeInvalidSequence)
+			in.Sequence, seq+1, acc.Sequence).WithErrorCode(result.CodeInvalidSequence).
+			WithInfo(result.InfoExpectedSequence, seq+1)
 	}
 
 	// Check amount
@@ -293,6 +294,51 @@ func chargeFee(account *types.Account, fee types.Coins) bool {
 	return true
 }
 
+// getTxAddresses returns the addresses of the accounts directly involved in the given transaction
+func getTxAddresses(tx types.Tx) []common.Address {
+	addresses := []common.Address{}
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		addresses = append(addresses, tx.Proposer.Address)
+		for _, output := range tx.Outputs {
+			addresses = append(addresses, output.Address)
+		}
+	case *types.SlashTx:
+		addresses = append(addresses, tx.Proposer.Address, tx.SlashedAddress)
+	case *types.SendTx:
+		for _, input := range tx.Inputs {
+			addresses = append(addresses, input.Address)
+		}
+		for _, output := range tx.Outputs {
+			addresses = append(addresses, output.Address)
+		}
+	case *types.ReserveFundTx:
+		addresses = append(addresses, tx.Source.Address)
+	case *types.ReleaseFundTx:
+		addresses = append(addresses, tx.Source.Address)
+	case *types.ServicePaymentTx:
+		addresses = append(addresses, tx.Source.Address, tx.Target.Address)
+	case *types.SplitRuleTx:
+		addresses = append(addresses, tx.Initiator.Address)
+	case *types.SmartContractTx:
+		addresses = append(addresses, tx.From.Address)
+		if (tx.To.Address != common.Address{}) {
+			addresses = append(addresses, tx.To.Address)
+		}
+	case *types.DepositStakeTx:
+		addresses = append(addresses, tx.Source.Address, tx.Holder.Address)
+	case *types.DepositStakeTxV2:
+		addresses = append(addresses, tx.Source.Address, tx.Holder.Address)
+	case *types.WithdrawStakeTx:
+		addresses = append(addresses, tx.Source.Address, tx.Holder.Address)
+	case *types.StakeRewardDistributionTx:
+		addresses = append(addresses, tx.Holder.Address, tx.Beneficiary.Address)
+	case *types.DoubleSignSlashTx:
+		addresses = append(addresses, tx.Proposer.Address, tx.Proof.Offender)
+	}
+	return addresses
+}
+
 func getBlockHeight(ledgerState *state.LedgerState) uint64 {
 	blockHeight := ledgerState.Height() + 1
 	return blockHeight
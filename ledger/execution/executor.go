@@ -41,6 +41,7 @@ type Executor struct {
 	depositStakeTxExec            *DepositStakeExecutor
 	withdrawStakeTxExec           *WithdrawStakeExecutor
 	stakeRewardDistributionTxExec *StakeRewardDistributionTxExecutor
+	doubleSignSlashTxExec         *DoubleSignSlashTxExecutor
 
 	skipSanityCheck bool
 }
@@ -64,6 +65,7 @@ func NewExecutor(db database.Database, chain *blockchain.Chain, state *st.Ledger
 		depositStakeTxExec:            NewDepositStakeExecutor(state),
 		withdrawStakeTxExec:           NewWithdrawStakeExecutor(state),
 		stakeRewardDistributionTxExec: NewStakeRewardDistributionTxExecutor(state),
+		doubleSignSlashTxExec:         NewDoubleSignSlashTxExecutor(state, consensus, valMgr),
 		skipSanityCheck:               false,
 	}
 
@@ -102,6 +104,58 @@ func (exec *Executor) GetTxInfo(tx types.Tx) (*core.TxInfo, result.Result) {
 	return txInfo, result.OK
 }
 
+// TxSimulationResult is the outcome of the simulation of a transaction
+type TxSimulationResult struct {
+	TxHash              common.Hash
+	Result              result.Result
+	SmartContractResult *SmartContractTxResult            // only set for smart contract transactions
+	Accounts            map[common.Address]*types.Account // the accounts touched by the transaction, after its execution
+}
+
+// SimulateTx checks and executes the given transaction on the checked view without recording any tx
+// receipt. The executor is expected to run on top of a private copy of the state, see
+// state.NewLedgerStateFromView(), so that the simulated transactions do not affect the ledger.
+func (exec *Executor) SimulateTx(tx types.Tx) *TxSimulationResult {
+	chainID := exec.state.GetChainID()
+	view := exec.state.Checked()
+
+	simResult := &TxSimulationResult{}
+	simResult.Result = exec.sanityCheck(chainID, view, core.CheckedView, tx)
+	if simResult.Result.IsError() {
+		return simResult
+	}
+	if !exec.isTxTypeSupported(view, tx) {
+		simResult.Result = result.Error("tx type not supported yet")
+		return simResult
+	}
+
+	addresses := getTxAddresses(tx)
+	if sctx, ok := tx.(*types.SmartContractTx); ok {
+		simResult.TxHash, simResult.SmartContractResult, simResult.Result = exec.smartContractTxExec.execute(chainID, view, sctx)
+		if simResult.SmartContractResult != nil {
+			if (simResult.SmartContractResult.ContractAddress != common.Address{}) {
+				addresses = append(addresses, simResult.SmartContractResult.ContractAddress)
+			}
+			for _, bc := range simResult.SmartContractResult.BalanceChanges {
+				addresses = append(addresses, bc.Address)
+			}
+		}
+	} else {
+		simResult.TxHash, simResult.Result = exec.process(chainID, view, core.CheckedView, tx)
+	}
+	if simResult.Result.IsError() {
+		return simResult
+	}
+
+	simResult.Accounts = make(map[common.Address]*types.Account)
+	for _, addr := range addresses {
+		if account := view.GetAccount(addr); account != nil {
+			simResult.Accounts[addr] = account
+		}
+	}
+	return simResult
+}
+
 // processTx contains the main logic to process the transaction. If the tx is invalid, a TMSP error will be returned.
 func (exec *Executor) processTx(tx types.Tx, viewSel core.ViewSelector) (common.Hash, result.Result) {
 	chainID := exec.state.GetChainID()
@@ -177,6 +231,10 @@ func (exec *Executor) isTxTypeSupported(view *st.StoreView, tx types.Tx) bool {
 		if blockHeight < common.HeightEnableScript3 {
 			return false
 		}
+	case *types.DoubleSignSlashTx:
+		if blockHeight < common.HeightEnableDoubleSignSlashing {
+			return false
+		}
 	default:
 		return true
 	}
@@ -211,6 +269,8 @@ func (exec *Executor) getTxExecutor(tx types.Tx) TxExecutor {
 		txExecutor = exec.depositStakeTxExec
 	case *types.StakeRewardDistributionTx:
 		txExecutor = exec.stakeRewardDistributionTxExec
+	case *types.DoubleSignSlashTx:
+		txExecutor = exec.doubleSignSlashTxExec
 	default:
 		txExecutor = nil
 	}
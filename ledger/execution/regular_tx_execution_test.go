@@ -7,6 +7,7 @@ import (
 
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/common/result"
+	"github.com/scripttoken/script/core"
 	"github.com/scripttoken/script/ledger/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -544,7 +545,7 @@ func TestReserveFundTx(t *testing.T) {
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeReservedFundNotSpecified)
 
@@ -561,7 +562,7 @@ func TestReserveFundTx(t *testing.T) {
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeInsufficientFund)
 
@@ -578,7 +579,7 @@ func TestReserveFundTx(t *testing.T) {
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeReserveFundCheckFailed, res.Message)
 
@@ -595,9 +596,9 @@ func TestReserveFundTx(t *testing.T) {
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
 	assert.True(res.IsOK(), res.String())
-	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
 	assert.True(res.IsOK(), res.String())
 
 	retrievedUserAcc := et.state().Delivered().GetAccount(user1.Address)
@@ -636,9 +637,9 @@ func TestReleaseFundTx(t *testing.T) {
 		Duration:    1000,
 	}
 	reserveFundTx.Source.Signature = user1.Sign(reserveFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, reserveFundTx)
 	assert.True(res.IsOK(), res.String())
-	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, reserveFundTx)
 	assert.True(res.IsOK(), res.String())
 
 	et.state().Commit()
@@ -653,7 +654,7 @@ func TestReleaseFundTx(t *testing.T) {
 		ReserveSequence: 1,
 	}
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, releaseFundTx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeInvalidFee, res.String())
 
@@ -666,7 +667,7 @@ func TestReleaseFundTx(t *testing.T) {
 		ReserveSequence: 1,
 	}
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, releaseFundTx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeInvalidFee, res.String())
 
@@ -680,7 +681,7 @@ func TestReleaseFundTx(t *testing.T) {
 		ReserveSequence: 1,
 	}
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, releaseFundTx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeReleaseFundCheckFailed, res.String())
 
@@ -696,7 +697,7 @@ func TestReleaseFundTx(t *testing.T) {
 		ReserveSequence: 99,
 	}
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, releaseFundTx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeReleaseFundCheckFailed, res.String())
 
@@ -715,7 +716,7 @@ func TestReleaseFundTx(t *testing.T) {
 		ReserveSequence: 1,
 	}
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
-	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
+	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, releaseFundTx)
 	assert.False(res.IsOK(), res.String())
 	assert.Equal(res.Code, result.CodeReleaseFundCheckFailed, res.String())
 }
@@ -739,9 +740,9 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 10*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 50*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx1 := createServicePaymentTx(et.chainID, &alice, &bob, payAmount1, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res := et.executor.getTxExecutor(servicePaymentTx1).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx1)
+	res := et.executor.getTxExecutor(servicePaymentTx1).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), servicePaymentTx1)
+	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
@@ -758,9 +759,9 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	srcSeq, tgtSeq, paymentSeq, reserveSeq = 1, 2, 2, 1
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 30*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx2 := createServicePaymentTx(et.chainID, &alice, &bob, payAmount2, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx2).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx2)
+	res = et.executor.getTxExecutor(servicePaymentTx2).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx2)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(servicePaymentTx2).process(et.chainID, et.state().Delivered(), servicePaymentTx2)
+	_, res = et.executor.getTxExecutor(servicePaymentTx2).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx2)
 	assert.True(res.IsOK(), res.Message)
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
@@ -776,9 +777,9 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	srcSeq, tgtSeq, paymentSeq, reserveSeq = 1, 1, 3, 1
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 30*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx3 := createServicePaymentTx(et.chainID, &alice, &carol, payAmount3, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx3).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx3)
+	res = et.executor.getTxExecutor(servicePaymentTx3).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx3)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(servicePaymentTx3).process(et.chainID, et.state().Delivered(), servicePaymentTx3)
+	_, res = et.executor.getTxExecutor(servicePaymentTx3).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx3)
 	assert.True(res.IsOK(), res.Message)
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
@@ -794,11 +795,11 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	srcSeq, tgtSeq, paymentSeq, reserveSeq = 1, 2, 4, 1
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 70000*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx4 := createServicePaymentTx(et.chainID, &alice, &carol, payAmount4, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx4).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx4)
+	res = et.executor.getTxExecutor(servicePaymentTx4).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx4)
 	assert.True(res.IsOK(), res.Message) // the following process() call will create an SlashIntent
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx4).process(et.chainID, et.state().Delivered(), servicePaymentTx4)
+	_, res = et.executor.getTxExecutor(servicePaymentTx4).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx4)
 	assert.True(res.IsOK(), res.Message)
 	//assert.Equal(1, len(et.state().Delivered().GetSlashIntents()))
 }
@@ -822,9 +823,9 @@ func TestServicePaymentTxExpiration(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 10*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 50*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx1 := createServicePaymentTx(et.chainID, &alice, &bob, payAmount1, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res := et.executor.getTxExecutor(servicePaymentTx1).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx1)
+	res := et.executor.getTxExecutor(servicePaymentTx1).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), servicePaymentTx1)
+	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -841,7 +842,7 @@ func TestServicePaymentTxExpiration(t *testing.T) {
 	srcSeq, tgtSeq, paymentSeq, reserveSeq = 1, 2, 2, 1
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 30*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx2 := createServicePaymentTx(et.chainID, &alice, &bob, payAmount2, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx2).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx2)
+	res = et.executor.getTxExecutor(servicePaymentTx2).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx2)
 	assert.False(res.IsOK(), res.Message)
 	assert.Equal(result.CodeCheckTransferReservedFundFailed, res.Code)
 	log.Infof("Service payment check message: %v", res.Message)
@@ -914,6 +915,111 @@ func TestServicePaymentTxExpiration(t *testing.T) {
 // 	log.Infof("Proposer final balance: %v", retrievedProposerAccount.Balance)
 // }
 
+func TestDoubleSignSlashTxSanityCheck(t *testing.T) {
+	assert := assert.New(t)
+	et, offender := setupForDoubleSignSlash(assert)
+	proposer := et.accProposer
+	blockHeight := et.state().Delivered().Height() + 1
+
+	tx := createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-1, 1000)
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Only the validators can submit the proofs
+	nonValidator := types.MakeAcc("User Eve")
+	tx = createDoubleSignSlashTx(et.chainID, &nonValidator, &offender, blockHeight-1, 1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+
+	// The proof must be legitimate
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-1, 1000)
+	tx.Proof.HeaderB.Timestamp = big.NewInt(2000)
+	tx.Proposer.Signature = proposer.Sign(tx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+
+	// The offender must have validator stake
+	nonStaker := types.MakeAcc("User Frank")
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &nonStaker, blockHeight-1, 1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+
+	// The proof cannot be ahead of the current block
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight, 1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+
+	// The proof expires after MaxDoubleSignProofAge blocks
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-core.MaxDoubleSignProofAge, 1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-core.MaxDoubleSignProofAge-1, 1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+}
+
+func TestDoubleSignSlashTxProcess(t *testing.T) {
+	assert := assert.New(t)
+	et, offender := setupForDoubleSignSlash(assert)
+	proposer := et.accProposer
+	blockHeight := et.state().Delivered().Height() + 1
+
+	tx := createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-10, 1000)
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+
+	// DoubleSignSlashPercentage of the offender's stake is burned
+	vcp := et.state().Delivered().GetValidatorCandidatePool()
+	expectedStake := new(big.Int).Mul(core.MinValidatorStakeDeposit, big.NewInt(100-core.DoubleSignSlashPercentage))
+	expectedStake.Div(expectedStake, big.NewInt(100))
+	assert.True(vcp.FindStakeDelegate(offender.Address).TotalStake().Cmp(expectedStake) == 0)
+	assert.True(et.state().Delivered().DoubleSignOffenceSlashed(tx.Proof.OffenceKey()))
+	assert.True(et.state().Delivered().GetStakeTransactionHeightList().Contains(blockHeight))
+
+	// The offender is slashed only once per offence, even with another pair of conflicting blocks
+	anotherTx := createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-10, 2000)
+	assert.NotEqual(tx.Proof.Hash(), anotherTx.Proof.Hash())
+	assert.Equal(tx.Proof.OffenceKey(), anotherTx.Proof.OffenceKey())
+	res = et.executor.getTxExecutor(anotherTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, anotherTx)
+	assert.True(res.IsError())
+
+	// An offence at another height is slashed separately
+	anotherTx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-11, 1000)
+	res = et.executor.getTxExecutor(anotherTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, anotherTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestDoubleSignSlashTxFork(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+	et.acc2State(et.accProposer)
+	proposer := et.accProposer
+
+	offender := types.MakeAcc("User Mallory")
+	vcp := &core.ValidatorCandidatePool{}
+	assert.Nil(vcp.DepositStake(offender.Address, offender.Address, core.MinValidatorStakeDeposit, 1))
+	et.state().Delivered().UpdateValidatorCandidatePool(vcp)
+
+	// The slash transactions are rejected before the fork
+	et.fastforwardTo(common.HeightEnableDoubleSignSlashing - 2)
+	blockHeight := et.state().Delivered().Height() + 1
+	assert.Equal(common.HeightEnableDoubleSignSlashing-1, blockHeight)
+	tx := createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-1, 1000)
+	res := et.executor.sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsError())
+
+	et.fastforwardTo(common.HeightEnableDoubleSignSlashing - 1)
+	blockHeight = et.state().Delivered().Height() + 1
+	assert.Equal(common.HeightEnableDoubleSignSlashing, blockHeight)
+	tx = createDoubleSignSlashTx(et.chainID, &proposer, &offender, blockHeight-1, 1000)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+}
+
 func TestSplitRuleTxNormalExecution(t *testing.T) {
 	assert := assert.New(t)
 	et, resourceID, alice, bob, carol, _, bobInitBalance, carolInitBalance := setupForServicePayment(assert)
@@ -943,9 +1049,9 @@ func TestSplitRuleTxNormalExecution(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -954,11 +1060,11 @@ func TestSplitRuleTxNormalExecution(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1005,9 +1111,9 @@ func TestSplitRuleTxExpiration(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.fastforwardBy(105) // The split rule should expire after the fastforward
@@ -1018,11 +1124,11 @@ func TestSplitRuleTxExpiration(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 100, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &bob, 500, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1071,9 +1177,9 @@ func TestSplitRuleTxUpdate(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	splitRule := et.executor.state.Delivered().GetSplitRule(resourceID)
@@ -1095,10 +1201,10 @@ func TestSplitRuleTxUpdate(t *testing.T) {
 	signBytes = fakeSplitRuleUpdateTx.SignBytes(et.chainID)
 	fakeSplitRuleUpdateTx.Initiator.Signature = fakeInitiator.Sign(signBytes)
 
-	res = et.executor.getTxExecutor(fakeSplitRuleUpdateTx).sanityCheck(et.chainID, et.state().Delivered(), fakeSplitRuleUpdateTx)
+	res = et.executor.getTxExecutor(fakeSplitRuleUpdateTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, fakeSplitRuleUpdateTx)
 	assert.False(res.IsOK(), res.Message)
 	assert.Equal(result.CodeUnauthorizedToUpdateSplitRule, res.Code)
-	_, res = et.executor.getTxExecutor(fakeSplitRuleUpdateTx).process(et.chainID, et.state().Delivered(), fakeSplitRuleUpdateTx)
+	_, res = et.executor.getTxExecutor(fakeSplitRuleUpdateTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, fakeSplitRuleUpdateTx)
 	assert.False(res.IsOK(), res.Message)
 	assert.Equal(result.CodeUnauthorizedToUpdateSplitRule, res.Code)
 
@@ -1123,9 +1229,9 @@ func TestSplitRuleTxUpdate(t *testing.T) {
 	signBytes = splitRuleUpdateTx.SignBytes(et.chainID)
 	splitRuleUpdateTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res = et.executor.getTxExecutor(splitRuleUpdateTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleUpdateTx)
+	res = et.executor.getTxExecutor(splitRuleUpdateTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleUpdateTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleUpdateTx).process(et.chainID, et.state().Delivered(), splitRuleUpdateTx)
+	_, res = et.executor.getTxExecutor(splitRuleUpdateTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleUpdateTx)
 	assert.True(res.IsOK(), res.Message)
 
 	splitRule2 := et.executor.state.Delivered().GetSplitRule(resourceID)
@@ -1218,9 +1324,9 @@ func TestSplitRuleTxTargetAddressAlsoSplits(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1231,11 +1337,11 @@ func TestSplitRuleTxTargetAddressAlsoSplits(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1313,9 +1419,9 @@ func TestSplitRuleTxManyDups(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1324,11 +1430,11 @@ func TestSplitRuleTxManyDups(t *testing.T) {
 
 	// Alice send the service payment to Carol, whose address is included in the split address list
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1403,9 +1509,9 @@ func TestSplitRuleTxSmallAmount(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1414,13 +1520,13 @@ func TestSplitRuleTxSmallAmount(t *testing.T) {
 
 	// Alice send the service payment to Carol, whose address is included in the split address list
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	log.Infof("Payment amount: %v", payAmount)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1509,9 +1615,9 @@ func TestSplitRuleTxSmallPercentage(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1520,13 +1626,13 @@ func TestSplitRuleTxSmallPercentage(t *testing.T) {
 
 	// Alice send the service payment to Carol, whose address is included in the split address list
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	log.Infof("Payment amount: %v", payAmount)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1599,9 +1705,9 @@ func TestSplitRuleHundredPercSplits(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1612,11 +1718,11 @@ func TestSplitRuleHundredPercSplits(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1681,7 +1787,7 @@ func TestSplitRuleOverHundredPercSplits(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.False(res.IsOK(), res.Message) // should be rejected
 }
 
@@ -1716,9 +1822,9 @@ func TestSplitRuleSingleHundredPercSplits(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1729,11 +1835,11 @@ func TestSplitRuleSingleHundredPercSplits(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1796,9 +1902,9 @@ func TestSplitRuleSplitZeroPercSplits(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1809,11 +1915,11 @@ func TestSplitRuleSplitZeroPercSplits(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1868,9 +1974,9 @@ func TestSplitRuleSplitEmptyRule(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1881,11 +1987,11 @@ func TestSplitRuleSplitEmptyRule(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -1950,9 +2056,9 @@ func TestSplitRuleSplitZeroPayment(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -1963,11 +2069,11 @@ func TestSplitRuleSplitZeroPayment(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 0, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 0, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -2033,9 +2139,9 @@ func TestSplitRuleTxSplitPaymentRounding(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 
 	// Simulate micropayment #1 between Alice and Bob, Carol should get a cut
@@ -2046,11 +2152,11 @@ func TestSplitRuleTxSplitPaymentRounding(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -2119,9 +2225,9 @@ func TestSplitRuleExpiration(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 	et.state().Commit()
 
@@ -2145,11 +2251,11 @@ func TestSplitRuleExpiration(t *testing.T) {
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	_ = createServicePaymentTx(et.chainID, &alice, &carol, 500*txFee, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &carol, payAmount, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
-	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
-	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
 
 	et.state().Commit()
@@ -2217,9 +2323,9 @@ func TestSplitRuleZeroDuration(t *testing.T) {
 	signBytes := splitRuleTx.SignBytes(et.chainID)
 	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
 
-	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx)
 	assert.True(res.IsOK(), res.Message)
 	et.state().Commit()
 
@@ -2242,9 +2348,9 @@ func TestSplitRuleZeroDuration(t *testing.T) {
 	signBytes2 := splitRuleTx2.SignBytes(et.chainID)
 	splitRuleTx2.Initiator.Signature = initiator.Sign(signBytes2)
 
-	res = et.executor.getTxExecutor(splitRuleTx2).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx2)
+	res = et.executor.getTxExecutor(splitRuleTx2).sanityCheck(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx2)
 	assert.True(res.IsOK(), res.Message)
-	_, res = et.executor.getTxExecutor(splitRuleTx2).process(et.chainID, et.state().Delivered(), splitRuleTx2)
+	_, res = et.executor.getTxExecutor(splitRuleTx2).process(et.chainID, et.state().Delivered(), core.DeliveredView, splitRuleTx2)
 	assert.True(res.IsOK(), res.Message)
 	et.state().Commit()
 
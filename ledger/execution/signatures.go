@@ -0,0 +1,93 @@
+package execution
+
+import (
+	"encoding/hex"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/result"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+)
+
+// VerifyTxSignatures verifies the signatures of the transaction the same way as the tx executors do,
+// but without accessing the ledger state, so that it can run concurrently ahead of the screening.
+// The verified signatures are cached, and hence are not verified again by the tx executors. The
+// transactions which are not signed by regular accounts, e.g. the coinbase transactions, are not
+// verified here.
+func VerifyTxSignatures(chainID string, blockHeight uint64, tx types.Tx) result.Result {
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		signBytes := tx.SignBytes(chainID)
+		for _, in := range tx.Inputs {
+			if res := verifyInputSignature(signBytes, in, blockHeight); res.IsError() {
+				return res
+			}
+		}
+	case *types.ReserveFundTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Source, blockHeight)
+	case *types.ReleaseFundTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Source, blockHeight)
+	case *types.SplitRuleTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Initiator, blockHeight)
+	case *types.DepositStakeTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Source, blockHeight)
+	case *types.DepositStakeTxV2:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Source, blockHeight)
+	case *types.WithdrawStakeTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Source, blockHeight)
+	case *types.StakeRewardDistributionTx:
+		return verifyInputSignature(tx.SignBytes(chainID), tx.Holder, blockHeight)
+	case *types.ServicePaymentTx:
+		if !tx.Source.Signature.Verify(tx.SourceSignBytes(chainID), tx.Source.Address) {
+			return result.Error("Source signature verification failed, addr: %v", tx.Source.Address.Hex()).
+				WithErrorCode(result.CodeInvalidSignature)
+		}
+		if !tx.Target.Signature.Verify(tx.TargetSignBytes(chainID), tx.Target.Address) {
+			return result.Error("Target signature verification failed, addr: %v", tx.Target.Address.Hex()).
+				WithErrorCode(result.CodeInvalidSignature)
+		}
+	case *types.SmartContractTx:
+		return verifySmartContractTxSignature(chainID, blockHeight, tx)
+	}
+	return result.OK
+}
+
+// verifyInputSignature mirrors the signature check of validateInputAdvanced().
+func verifyInputSignature(signBytes []byte, in types.TxInput, blockHeight uint64) result.Result {
+	signatureValid := in.Signature.Verify(signBytes, in.Address)
+	if !signatureValid && blockHeight >= common.HeightTxWrapperExtension {
+		signBytesV2 := types.ChangeEthereumTxWrapper(signBytes, 2)
+		signatureValid = in.Signature.Verify(signBytesV2, in.Address)
+	}
+	if !signatureValid {
+		return result.Error("Signature verification failed, SignBytes: %v",
+			hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
+	}
+	return result.OK
+}
+
+// verifySmartContractTxSignature mirrors the signature check of SmartContractTxExecutor.sanityCheck().
+func verifySmartContractTxSignature(chainID string, blockHeight uint64, tx *types.SmartContractTx) result.Result {
+	signBytes := tx.SignBytes(chainID)
+	if tx.From.Signature.Verify(signBytes, tx.From.Address) {
+		return result.OK
+	}
+	if blockHeight >= common.HeightTxWrapperExtension {
+		signBytesV2 := types.ChangeEthereumTxWrapper(signBytes, 2)
+		if tx.From.Signature.Verify(signBytesV2, tx.From.Address) {
+			return result.OK
+		}
+	}
+	if blockHeight < common.HeightRPCCompatibility {
+		return result.Error("Signature verification failed, SignBytes: %v",
+			hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
+	}
+
+	// interpret the signature as ETH tx signature
+	ethSigningHash := tx.EthSigningHash(chainID, blockHeight)
+	if err := crypto.ValidateEthSignature(tx.From.Address, ethSigningHash, tx.From.Signature); err != nil {
+		return result.Error("ETH Signature verification failed, SignBytes: %v, error: %v",
+			hex.EncodeToString(signBytes), err.Error()).WithErrorCode(result.CodeInvalidSignature)
+	}
+	return result.OK
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/signer"
 	"github.com/scripttoken/script/store/database/backend"
 )
 
@@ -25,22 +26,41 @@ import (
 
 type TestConsensusEngine struct {
 	privKey *crypto.PrivateKey
+	signer  core.Signer
+	ledger  core.Ledger
 }
 
 func (tce *TestConsensusEngine) ID() string                        { return tce.privKey.PublicKey().Address().Hex() }
-func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey    { return tce.privKey }
+func (tce *TestConsensusEngine) Signer() core.Signer               { return tce.signer }
 func (tce *TestConsensusEngine) GetTip(bool) *core.ExtendedBlock   { return nil }
 func (tce *TestConsensusEngine) GetEpoch() uint64                  { return 100 }
 func (tce *TestConsensusEngine) AddMessage(msg interface{})        {}
 func (tce *TestConsensusEngine) FinalizedBlocks() chan *core.Block { return nil }
-func (tce *TestConsensusEngine) GetLedger() core.Ledger            { return nil }
+func (tce *TestConsensusEngine) GetLedger() core.Ledger            { return tce.ledger }
+func (tce *TestConsensusEngine) HasSynced() bool                   { return true }
 func (tce *TestConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return &core.ExtendedBlock{}
 }
+func (tce *TestConsensusEngine) GetDoubleSignProofs() []*core.DoubleSignProof {
+	return nil
+}
 
 func NewTestConsensusEngine(seed string) *TestConsensusEngine {
 	privKey, _, _ := crypto.TEST_GenerateKeyPairWithSeed(seed)
-	return &TestConsensusEngine{privKey}
+	localSigner, _ := signer.NewLocalSigner(privKey)
+	return &TestConsensusEngine{privKey: privKey, signer: localSigner}
+}
+
+// TestLedger only provides the current block, the executors do not need the rest of the ledger
+type TestLedger struct {
+	core.Ledger
+	currentBlock *core.Block
+}
+
+func (tl *TestLedger) GetCurrentBlock() *core.Block { return tl.currentBlock }
+
+func NewTestLedger(currentBlock *core.Block) *TestLedger {
+	return &TestLedger{currentBlock: currentBlock}
 }
 
 type TestValidatorManager struct {
@@ -123,7 +143,9 @@ func (et *execTest) reset() {
 	valMgr := NewTestValidatorManager(propser, valSet)
 
 	chain := blockchain.CreateTestChain()
-	executor := NewExecutor(db, chain, ledgerState, consensus, valMgr, nil)
+	ledger := NewTestLedger(initBlock)
+	consensus.ledger = ledger
+	executor := NewExecutor(db, chain, ledgerState, consensus, valMgr, ledger)
 
 	et.chainID = chainID
 	et.executor = executor
@@ -245,6 +267,35 @@ func createServicePaymentTx(chainID string, source, target *types.PrivAccount, a
 	return servicePaymentTx
 }
 
+// createDoubleSignSlashTx creates a DoubleSignSlashTx proving that the offender proposed two blocks
+// at the given height. The timestamp tells the conflicting pairs apart.
+func createDoubleSignSlashTx(chainID string, proposer, offender *types.PrivAccount, height uint64, timestamp int64) *types.DoubleSignSlashTx {
+	headers := []*core.BlockHeader{}
+	for i := int64(0); i < 2; i++ {
+		header := &core.BlockHeader{
+			ChainID:   chainID,
+			Epoch:     height,
+			Height:    height,
+			Parent:    common.HexToHash("a0"),
+			Timestamp: big.NewInt(timestamp + i),
+			Proposer:  offender.Address,
+		}
+		header.Signature = offender.Sign(header.SignBytes())
+		headers = append(headers, header)
+	}
+
+	doubleSignSlashTx := &types.DoubleSignSlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: 1,
+		},
+		Proof: *core.NewProposalDoubleSignProof(headers[0], headers[1]),
+	}
+	doubleSignSlashTx.Proposer.Signature = proposer.Sign(doubleSignSlashTx.SignBytes(chainID))
+
+	return doubleSignSlashTx
+}
+
 func setupForServicePayment(ast *assert.Assertions) (et *execTest, resourceID string,
 	alice, bob, carol types.PrivAccount, aliceInitBalance, bobInitBalance, carolInitBalance types.Coins) {
 	et = NewExecTest()
@@ -290,6 +341,21 @@ func setupForServicePayment(ast *assert.Assertions) (et *execTest, resourceID st
 	return et, resourceID, alice, bob, carol, aliceInitBalance, bobInitBalance, carolInitBalance
 }
 
+func setupForDoubleSignSlash(ast *assert.Assertions) (et *execTest, offender types.PrivAccount) {
+	et = NewExecTest()
+	et.acc2State(et.accProposer)
+
+	offender = types.MakeAcc("User Mallory")
+	vcp := &core.ValidatorCandidatePool{}
+	ast.Nil(vcp.DepositStake(offender.Address, offender.Address, core.MinValidatorStakeDeposit, 1))
+	et.state().Delivered().UpdateValidatorCandidatePool(vcp)
+	log.Infof("Offender's Address: %v", offender.Address.Hex())
+
+	et.fastforwardTo(common.HeightEnableDoubleSignSlashing + 2*core.MaxDoubleSignProofAge)
+
+	return et, offender
+}
+
 type contractByteCode struct {
 	DeploymentCode string `json:"deployment_code"`
 	Code           string `json:"code"`
@@ -0,0 +1,132 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/result"
+	"github.com/scripttoken/script/core"
+	st "github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+)
+
+var _ TxExecutor = (*DoubleSignSlashTxExecutor)(nil)
+
+// ------------------------------- DoubleSignSlash Transaction -----------------------------------
+
+// DoubleSignSlashTxExecutor implements the TxExecutor interface
+type DoubleSignSlashTxExecutor struct {
+	state     *st.LedgerState
+	consensus core.ConsensusEngine
+	valMgr    core.ValidatorManager
+}
+
+// NewDoubleSignSlashTxExecutor creates a new instance of DoubleSignSlashTxExecutor
+func NewDoubleSignSlashTxExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *DoubleSignSlashTxExecutor {
+	return &DoubleSignSlashTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *DoubleSignSlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, viewSel core.ViewSelector, transaction types.Tx) result.Result {
+	blockHeight := view.Height() + 1 // the view points to the parent of the current block
+	tx := transaction.(*types.DoubleSignSlashTx)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer is one of the validators
+	validatorSet := getValidatorSet(exec.consensus.GetLedger(), exec.valMgr)
+	res = isAValidator(tx.Proposer.Address, getValidatorAddresses(validatorSet))
+	if res.IsError() {
+		return res
+	}
+
+	proposerAccount, res := getOrMakeInput(view, tx.Proposer)
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer's signature
+	signBytes := tx.SignBytes(chainID)
+	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
+		return result.Error("SignBytes: %X", signBytes)
+	}
+
+	proof := &tx.Proof
+	res = proof.Validate(chainID)
+	if res.IsError() {
+		return result.Error("Invalid double sign proof: %v", res.Message)
+	}
+
+	proofHeight := proof.Height()
+	if proofHeight >= blockHeight {
+		return result.Error("Double sign proof is ahead of the current block, proof height: %v, block height: %v",
+			proofHeight, blockHeight)
+	}
+	if proofHeight+core.MaxDoubleSignProofAge < blockHeight {
+		return result.Error("Double sign proof has expired, proof height: %v, block height: %v",
+			proofHeight, blockHeight)
+	}
+
+	if view.DoubleSignOffenceSlashed(proof.OffenceKey()) {
+		return result.Error("Offender %v has already been slashed for height %v", proof.Offender.Hex(), proofHeight)
+	}
+
+	vcp := view.GetValidatorCandidatePool()
+	if vcp == nil || vcp.FindStakeDelegate(proof.Offender) == nil {
+		return result.Error("Offender %v does not have any validator stake", proof.Offender.Hex())
+	}
+
+	return result.OK
+}
+
+func (exec *DoubleSignSlashTxExecutor) process(chainID string, view *st.StoreView, viewSel core.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.DoubleSignSlashTx)
+	proof := &tx.Proof
+
+	vcp := view.GetValidatorCandidatePool()
+	if vcp == nil {
+		return common.Hash{}, result.Error("Validator candidate pool does not exist")
+	}
+	slashedAmount, err := vcp.SlashStake(proof.Offender, core.DoubleSignSlashPercentage)
+	if err != nil {
+		return common.Hash{}, result.Error("Failed to slash stake, err: %v", err)
+	}
+	view.UpdateValidatorCandidatePool(vcp)
+	view.SetDoubleSignOffenceSlashed(proof.OffenceKey())
+
+	// The validator stakes changed, record the height as for the other validator stake transactions
+	hl := view.GetStakeTransactionHeightList()
+	if hl == nil {
+		hl = &types.HeightList{}
+	}
+	blockHeight := view.Height() + 1 // the view points to the parent of the current block
+	hl.Append(blockHeight)
+	view.UpdateStakeTransactionHeightList(hl)
+
+	logger.Infof("Slashed validator for double signing: offender = %v, height = %v, slashed amount = %v",
+		proof.Offender.Hex(), proof.Height(), slashedAmount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *DoubleSignSlashTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.DoubleSignSlashTx)
+	return &core.TxInfo{
+		Address:           tx.Proposer.Address,
+		Sequence:          tx.Proposer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Lane:              core.TxLaneStake,
+	}
+}
+
+func (exec *DoubleSignSlashTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
@@ -106,6 +106,7 @@ func (exec *ReleaseFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
 	}
 }
 
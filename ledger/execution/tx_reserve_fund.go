@@ -122,6 +122,7 @@ func (exec *ReserveFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
 	}
 }
 
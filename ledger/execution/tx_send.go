@@ -120,20 +120,26 @@ func (exec *SendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 		Address:           tx.Inputs[0].Address,
 		Sequence:          tx.Inputs[0].Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               exec.calculateGas(transaction),
 	}
 }
 
 func (exec *SendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SendTx)
 	fee := tx.Fee
+	gas := new(big.Int).SetUint64(exec.calculateGas(transaction))
+	effectiveGasPrice := new(big.Int).Div(fee.SPAYWei, gas)
+	return effectiveGasPrice
+}
+
+func (exec *SendTxExecutor) calculateGas(transaction types.Tx) uint64 {
+	tx := transaction.(*types.SendTx)
 	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
 
 	gasSendTxPerAccount := getRegularTxGas(exec.state) / 2
-	gasUint64 := gasSendTxPerAccount * numAccountsAffected
-	if gasUint64 < 2*gasSendTxPerAccount {
-		gasUint64 = 2 * gasSendTxPerAccount // to prevent spamming with invalid transactions, e.g. empty inputs/outputs
+	gas := gasSendTxPerAccount * numAccountsAffected
+	if gas < 2*gasSendTxPerAccount {
+		gas = 2 * gasSendTxPerAccount // to prevent spamming with invalid transactions, e.g. empty inputs/outputs
 	}
-	gas := new(big.Int).SetUint64(gasUint64)
-	effectiveGasPrice := new(big.Int).Div(fee.SPAYWei, gas)
-	return effectiveGasPrice
+	return gas
 }
@@ -212,6 +212,8 @@ func (exec *ServicePaymentTxExecutor) getTxInfo(transaction types.Tx) *core.TxIn
 		Address:           tx.Target.Address,
 		Sequence:          tx.Target.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
+		Lane:              core.TxLaneServicePayment,
 	}
 }
 
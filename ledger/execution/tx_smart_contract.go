@@ -84,7 +84,8 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 	seq, balance := fromAccount.Sequence, fromAccount.Balance
 	if seq+1 != tx.From.Sequence {
 		return result.Error("ValidateInputAdvanced: Got %v, expected %v. (acc.seq=%v)",
-			tx.From.Sequence, seq+1, fromAccount.Sequence).WithErrorCode(result.CodeInvalidSequence)
+			tx.From.Sequence, seq+1, fromAccount.Sequence).WithErrorCode(result.CodeInvalidSequence).
+			WithInfo(result.InfoExpectedSequence, seq+1)
 	}
 
 	// Check amount
@@ -154,7 +155,30 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 
 func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView, viewSel core.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.SmartContractTx)
+	txHash, txResult, res := exec.execute(chainID, view, tx)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
 
+	if viewSel == core.DeliveredView { // only record the receipt for the delivered views
+		exec.chain.AddTxReceipt(exec.ledger.GetCurrentBlock(), tx, txResult.Logs, txResult.BalanceChanges,
+			txResult.EvmRet, txResult.ContractAddress, txResult.GasUsed, txResult.EvmErr)
+	}
+
+	return txHash, result.OK
+}
+
+// SmartContractTxResult is the outcome of the execution of a smart contract transaction
+type SmartContractTxResult struct {
+	EvmRet          common.Bytes
+	ContractAddress common.Address
+	GasUsed         uint64
+	EvmErr          error
+	Logs            []*types.Log           // nil if the execution failed
+	BalanceChanges  []*types.BalanceChange // nil if the execution failed
+}
+
+func (exec *SmartContractTxExecutor) execute(chainID string, view *st.StoreView, tx *types.SmartContractTx) (common.Hash, *SmartContractTxResult, result.Result) {
 	view.ResetLogs()
 	view.ResetBalanceChanges()
 
@@ -168,7 +192,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 	fromAddress := tx.From.Address
 	fromAccount, success := getInput(view, tx.From)
 	if success.IsError() {
-		return common.Hash{}, result.Error("Failed to get the from account")
+		return common.Hash{}, nil, result.Error("Failed to get the from account")
 	}
 
 	feeAmount := new(big.Int).Mul(tx.GasPrice, new(big.Int).SetUint64(gasUsed))
@@ -177,7 +201,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 		SPAYWei: feeAmount,
 	}
 	if !chargeFee(fromAccount, fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
+		return common.Hash{}, nil, result.Error("failed to charge transaction fee")
 	}
 
 	createContract := (tx.To.Address == common.Address{})
@@ -197,11 +221,15 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 		balanceChanges = nil
 	}
 
-	if viewSel == core.DeliveredView { // only record the receipt for the delivered views
-		exec.chain.AddTxReceipt(exec.ledger.GetCurrentBlock(), tx, logs, balanceChanges, evmRet, contractAddr, gasUsed, evmErr)
+	txResult := &SmartContractTxResult{
+		EvmRet:          evmRet,
+		ContractAddress: contractAddr,
+		GasUsed:         gasUsed,
+		EvmErr:          evmErr,
+		Logs:            logs,
+		BalanceChanges:  balanceChanges,
 	}
-
-	return txHash, result.OK
+	return txHash, txResult, result.OK
 }
 
 func (exec *SmartContractTxExecutor) checkIntrinsicGas(tx *types.SmartContractTx) error {
@@ -226,6 +254,7 @@ func (exec *SmartContractTxExecutor) getTxInfo(transaction types.Tx) *core.TxInf
 		Address:           tx.From.Address,
 		Sequence:          tx.From.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               tx.GasLimit,
 	}
 }
 
@@ -149,6 +149,7 @@ func (exec *SplitRuleTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 		Address:           tx.Initiator.Address,
 		Sequence:          tx.Initiator.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
 	}
 }
 
@@ -162,6 +162,7 @@ func (exec *StakeRewardDistributionTxExecutor) getTxInfo(transaction types.Tx) *
 		Address:           tx.Holder.Address,
 		Sequence:          tx.Holder.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
 	}
 }
 
@@ -140,6 +140,8 @@ func (exec *WithdrawStakeExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               getRegularTxGas(exec.state),
+		Lane:              core.TxLaneStake,
 	}
 }
 
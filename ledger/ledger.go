@@ -249,6 +249,42 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 	return txInfo, res
 }
 
+// GetTxInfo extracts the information of the given transaction used by the mempool to sort the
+// transactions, without screening it.
+func (ledger *Ledger) GetTxInfo(rawTx common.Bytes) (txInfo *core.TxInfo, res result.Result) {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil, result.Error("Error decoding tx: %v", err)
+	}
+	return ledger.executor.GetTxInfo(tx)
+}
+
+// PreverifyTx decodes the given transaction and verifies its signatures. Only the ledger height is
+// read under the ledger lock, so that the transactions can be verified concurrently before being
+// screened. The verified signatures are cached and not verified again during the screening.
+func (ledger *Ledger) PreverifyTx(rawTx common.Bytes) result.Result {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return result.Error("Error decoding tx: %v", err)
+	}
+
+	ledger.mu.RLock()
+	chainID := ledger.state.GetChainID()
+	blockHeight := ledger.state.Height() + 1
+	ledger.mu.RUnlock()
+
+	return exec.VerifyTxSignatures(chainID, blockHeight, tx)
+}
+
+// ResetScreenedState discards the transactions screened so far. The mempool is expected to screen
+// its pending transactions again afterwards, e.g. after one of them has been replaced.
+func (ledger *Ledger) ResetScreenedState() result.Result {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	return ledger.state.ResetScreened()
+}
+
 // ProposeBlockTxs collects and executes a list of transactions, which will be used to assemble the next blockl
 // It also clears these transactions from the mempool.
 func (ledger *Ledger) ProposeBlockTxs(block *core.Block, shouldIncludeValidatorUpdateTxs bool) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
@@ -277,7 +313,7 @@ func (ledger *Ledger) ProposeBlockTxs(block *core.Block, shouldIncludeValidatorU
 	ledger.addSpecialTransactions(block, view, &rawTxCandidates)
 
 	// Add regular transactions submitted by the clients
-	regularRawTxs := ledger.mempool.ReapUnsafe(core.MaxNumRegularTxsPerBlock)
+	regularRawTxs := ledger.mempool.ReapBlockTxsUnsafe(core.MaxNumRegularTxsPerBlock)
 	for _, regularRawTx := range regularRawTxs {
 		rawTxCandidates = append(rawTxCandidates, regularRawTx)
 	}
@@ -301,6 +337,9 @@ func (ledger *Ledger) ProposeBlockTxs(block *core.Block, shouldIncludeValidatorU
 			if _, ok := tx.(*types.WithdrawStakeTx); ok {
 				continue
 			}
+			if _, ok := tx.(*types.DoubleSignSlashTx); ok {
+				continue
+			}
 		}
 
 		_, res := ledger.executor.CheckTx(tx)
@@ -370,6 +409,8 @@ func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
 			hasValidatorUpdate = true
 		} else if wtx, ok := tx.(*types.WithdrawStakeTx); ok && wtx.Purpose == core.StakeForValidator {
 			hasValidatorUpdate = true
+		} else if _, ok := tx.(*types.DoubleSignSlashTx); ok {
+			hasValidatorUpdate = true
 		}
 		_, res := ledger.executor.ExecuteTx(tx)
 		if res.IsError() {
@@ -401,6 +442,7 @@ func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
 
 	logger.Debugf("ApplyBlockTxs: Committed state change, block.height = %v", block.Height)
 
+	ledger.mempool.MarkIncludedTxs(block.Hash(), block.Height, blockRawTxs)
 	go func() {
 		ledger.mempool.Lock()
 		defer ledger.mempool.Unlock()
@@ -451,6 +493,8 @@ func (ledger *Ledger) ApplyBlockTxsForChainCorrection(block *core.Block) (common
 			hasValidatorUpdate = true
 		} else if wtx, ok := tx.(*types.WithdrawStakeTx); ok && wtx.Purpose == core.StakeForValidator {
 			hasValidatorUpdate = true
+		} else if _, ok := tx.(*types.DoubleSignSlashTx); ok {
+			hasValidatorUpdate = true
 		}
 		_, res := ledger.executor.ExecuteTx(tx)
 		if res.IsError() {
@@ -602,6 +646,83 @@ func (ledger *Ledger) ResetState(block *core.Block) result.Result {
 	return ledger.resetState(block)
 }
 
+// ReplayBlockTxs re-executes the first numTxs transactions of the given block on top of the
+// state of its parent block, and returns the resulting view together with the parent block.
+// The ledger state is left untouched and no tx receipts are recorded, so it can be used to
+// rebuild the state a committed transaction was executed against, e.g. for tracing.
+func (ledger *Ledger) ReplayBlockTxs(block *core.Block, numTxs int) (*st.StoreView, *core.Block, error) {
+	if numTxs > len(block.Txs) {
+		return nil, nil, fmt.Errorf("block %v has only %v transactions", block.Hash().Hex(), len(block.Txs))
+	}
+	extParentBlock, err := ledger.chain.FindBlock(block.Parent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find the parent block %v: %v", block.Parent.Hex(), err)
+	}
+	parentBlock := extParentBlock.Block
+
+	chainID := ledger.state.GetChainID()
+	replayState := st.NewLedgerState(chainID, ledger.db, nil)
+	if res := replayState.ResetState(parentBlock); res.IsError() {
+		return nil, nil, fmt.Errorf("the state of block %v is not available, it might have been pruned", parentBlock.Hash().Hex())
+	}
+
+	// Transactions are replayed on the checked view so that no tx receipts are recorded
+	executor := exec.NewExecutor(ledger.db, ledger.chain, replayState, ledger.consensus, ledger.valMgr, ledger)
+	executor.SetSkipSanityCheck(true)
+	for i := 0; i < numTxs; i++ {
+		tx, err := types.TxFromBytes(block.Txs[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse transaction %v of block %v: %v", i, block.Hash().Hex(), err)
+		}
+		if _, res := executor.CheckTx(tx); res.IsError() {
+			return nil, nil, fmt.Errorf("failed to replay transaction %v of block %v: %v", i, block.Hash().Hex(), res.Message)
+		}
+	}
+
+	return replayState.Checked(), parentBlock, nil
+}
+
+// SimulateTxs checks and executes the given raw transactions one after another on a private copy of
+// the delivered view, or of the screened view if useScreenedView is set. Each transaction sees the
+// state changes of the preceding ones. The ledger state is left untouched and no tx receipts are
+// recorded. A transaction that fails does not stop the simulation of the following ones.
+func (ledger *Ledger) SimulateTxs(rawTxs []common.Bytes, useScreenedView bool) ([]*exec.TxSimulationResult, error) {
+	ledger.mu.RLock()
+	var view *st.StoreView
+	var err error
+	if useScreenedView {
+		view, err = ledger.state.Screened().Copy()
+	} else {
+		view, err = ledger.state.Delivered().Copy()
+	}
+	parentBlock := ledger.state.ParentBlock()
+	chainID := ledger.state.GetChainID()
+	ledger.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	simState := st.NewLedgerStateFromView(chainID, parentBlock, view)
+	executor := exec.NewExecutor(ledger.db, ledger.chain, simState, ledger.consensus, ledger.valMgr, ledger)
+
+	results := []*exec.TxSimulationResult{}
+	for i, rawTx := range rawTxs {
+		tx, err := types.TxFromBytes(rawTx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode transaction %v: %v", i, err)
+		}
+		if ledger.shouldSkipCheckTx(tx) {
+			results = append(results, &exec.TxSimulationResult{
+				Result: result.Error("Unauthorized transaction, should skip").WithErrorCode(result.CodeUnauthorizedTx),
+			})
+			continue
+		}
+		results = append(results, executor.SimulateTx(tx))
+	}
+
+	return results, nil
+}
+
 // FinalizeState sets the ledger state with the finalized root
 func (ledger *Ledger) FinalizeState(height uint64, rootHash common.Hash) result.Result {
 	ledger.mu.Lock()
@@ -638,6 +759,8 @@ func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
 		return true
 	case *types.SlashTx:
 		return true
+	case *types.DoubleSignSlashTx:
+		return true
 	default:
 		return false
 	}
@@ -767,9 +890,19 @@ func (ledger *Ledger) addSpecialTransactions(block *core.Block, view *st.StoreVi
 	parentBlkHash := block.Parent
 	proposer := ledger.valMgr.GetNextProposer(parentBlkHash, block.Epoch)
 	validatorSet := ledger.valMgr.GetNextValidatorSet(parentBlkHash)
+	if vrfValMgr, ok := ledger.valMgr.(core.VRFValidatorManager); ok && vrfValMgr.IsVRFEnabled(block.Height) {
+		// Note 4: The proposer is not known in advance, but has proved its eligibility in the block
+		var err error
+		proposer, err = validatorSet.GetValidator(block.Proposer)
+		if err != nil {
+			logger.Warnf("addSpecialTransactions: block proposer %v is not a validator", block.Proposer.Hex())
+			return
+		}
+	}
 
 	ledger.addCoinbaseTx(view, &proposer, validatorSet, rawTxs)
 	//ledger.addSlashTxs(view, &proposer, &validators, rawTxs)
+	ledger.addDoubleSignSlashTxs(view, block, &proposer, rawTxs)
 }
 
 // addCoinbaseTx adds a Coinbase transaction
@@ -859,11 +992,52 @@ func (ledger *Ledger) addSlashTxs(view *st.StoreView, proposer *core.Validator,
 	view.ClearSlashIntents()
 }
 
+// addDoubleSignSlashTxs adds the transactions slashing the validators that signed conflicting blocks
+func (ledger *Ledger) addDoubleSignSlashTxs(view *st.StoreView, block *core.Block, proposer *core.Validator, rawTxs *[]common.Bytes) {
+	if block.Height < common.HeightEnableDoubleSignSlashing {
+		return
+	}
+
+	proposerAddress := proposer.Address
+	proposerTxIn := types.TxInput{
+		Address: proposerAddress,
+	}
+
+	for _, proof := range ledger.consensus.GetDoubleSignProofs() {
+		if view.DoubleSignOffenceSlashed(proof.OffenceKey()) {
+			continue
+		}
+
+		slashTx := &types.DoubleSignSlashTx{
+			Proposer: proposerTxIn,
+			Proof:    *proof,
+		}
+
+		signature, err := ledger.signTransaction(slashTx)
+		if err != nil {
+			logger.Errorf("Failed to add double sign slash transaction: %v", err)
+			continue
+		}
+		slashTx.SetSignature(proposerAddress, signature)
+		slashTxBytes, err := types.TxToBytes(slashTx)
+		if err != nil {
+			logger.Errorf("Failed to add double sign slash transaction: %v", err)
+			continue
+		}
+
+		*rawTxs = append(*rawTxs, slashTxBytes)
+		logger.Debugf("Adding double sign slash transction: tx: %v, bytes: %v", slashTx, hex.EncodeToString(slashTxBytes))
+	}
+}
+
 // signTransaction signs the given transaction
 func (ledger *Ledger) signTransaction(tx types.Tx) (*crypto.Signature, error) {
 	chainID := ledger.state.GetChainID()
-	signBytes := tx.SignBytes(chainID)
-	signature, err := ledger.consensus.PrivateKey().Sign(signBytes)
+	rawTx, err := types.TxToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := ledger.consensus.Signer().SignTx(chainID, rawTx)
 	if err != nil {
 		return nil, err
 	}
@@ -98,3 +98,8 @@ func EliteEdgeNodeStakeReturnsKey(height uint64) common.Bytes {
 func EliteEdgeNodesTotalActiveStakeKey() common.Bytes {
 	return common.Bytes("ls/eentas")
 }
+
+// DoubleSignOffenceKey returns the state key recording that the given double sign offence has been slashed
+func DoubleSignOffenceKey(offenceKey common.Hash) common.Bytes {
+	return append(common.Bytes("ls/dso/"), offenceKey[:]...)
+}
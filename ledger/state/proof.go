@@ -0,0 +1,103 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/scripttoken/script/store/trie"
+)
+
+//
+// ------------------------- Merkle Proofs -------------------------
+//
+
+// ProofList is a merkle proof, i.e. the RLP encoded trie nodes on the path from the root to a key
+type ProofList []common.Bytes
+
+// Put implements the database.Putter interface.
+func (l *ProofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// ProveAccount returns the merkle proof of the account with the given address against the state
+// root of the view. If the account does not exist, the proof proves its absence.
+func (sv *StoreView) ProveAccount(addr common.Address) (ProofList, error) {
+	proof := ProofList{}
+	if err := sv.store.Prove(AccountKey(addr), 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// ProveStorage returns the merkle proof of the given storage slot of a smart contract against
+// the storage root of the contract account.
+func (sv *StoreView) ProveStorage(addr common.Address, key common.Hash) (ProofList, error) {
+	proof := ProofList{}
+	account := sv.GetAccount(addr)
+	if account == nil {
+		return proof, nil
+	}
+	storage := sv.getAccountStorage(account)
+	if storage == nil {
+		return nil, fmt.Errorf("storage of account %v is not available", addr.Hex())
+	}
+	if err := storage.Prove(key[:], 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// VerifyAccountProof checks the merkle proof of the account with the given address against the
+// state root, e.g. the StateHash of a block header, and returns the proven account. A nil account
+// is returned if the proof shows that the account does not exist.
+func VerifyAccountProof(stateRoot common.Hash, addr common.Address, proof ProofList) (*types.Account, error) {
+	data, err := verifyProof(stateRoot, AccountKey(addr), proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	account := &types.Account{}
+	if err := types.FromBytes(data, account); err != nil {
+		return nil, fmt.Errorf("invalid account in proof: %v", err)
+	}
+	if account.Address != addr {
+		return nil, fmt.Errorf("proof is for account %v instead of %v", account.Address.Hex(), addr.Hex())
+	}
+	return account, nil
+}
+
+// VerifyStorageProof checks the merkle proof of the given storage slot against the storage root of
+// a smart contract, i.e. the Root of its account, and returns the proven value.
+func VerifyStorageProof(storageRoot common.Hash, key common.Hash, proof ProofList) (common.Hash, error) {
+	enc, err := verifyProof(storageRoot, key[:], proof)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(enc) == 0 {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage value in proof: %v", err)
+	}
+	return common.BytesToHash(content), nil
+}
+
+func verifyProof(root common.Hash, key []byte, proof ProofList) ([]byte, error) {
+	proofDb := backend.NewMemDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	value, _, err := trie.VerifyProof(root, key, proofDb)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
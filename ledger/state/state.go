@@ -52,6 +52,21 @@ func NewLedgerState(chainID string, db database.Database, tagger Tagger) *Ledger
 	return s
 }
 
+// NewLedgerStateFromView creates a Ledger State on top of the given view, e.g. to execute transactions
+// against a private copy of the state without touching the actual ledger state. All the views of the
+// returned Ledger State point to the given view.
+func NewLedgerStateFromView(chainID string, parentBlock *core.Block, view *StoreView) *LedgerState {
+	return &LedgerState{
+		chainID:     chainID,
+		db:          view.GetDB(),
+		parentBlock: parentBlock,
+		finalized:   view,
+		delivered:   view,
+		checked:     view,
+		screened:    view,
+	}
+}
+
 // ResetState resets the height and state root of its storeviews, and clear the in-memory states
 // func (s *LedgerState) ResetState(height uint64, stateRootHash common.Hash) result.Result
 func (s *LedgerState) ResetState(block *core.Block) result.Result {
@@ -78,6 +93,17 @@ func (s *LedgerState) ResetState(block *core.Block) result.Result {
 	return result.OK
 }
 
+// ResetScreened discards the changes of the screened transactions by resetting the screened view
+// to a fresh copy of the delivered view.
+func (s *LedgerState) ResetScreened() result.Result {
+	screened, err := s.delivered.Copy()
+	if err != nil {
+		return result.Error(fmt.Sprintf("Failed to copy to the screened view: %v", err))
+	}
+	s.screened = screened
+	return result.OK
+}
+
 // Finalize updates the finalized view.
 func (s *LedgerState) Finalize(height uint64, stateRootHash common.Hash) result.Result {
 	storeview := NewStoreView(height, stateRootHash, s.db)
@@ -138,7 +164,9 @@ func (s *LedgerState) Finalized() *StoreView {
 func (s *LedgerState) Commit() common.Hash {
 	hash := s.delivered.Save()
 	s.delivered.IncrementHeight()
-	s.dbTagger.Tag(s.delivered.height, hash)
+	if s.dbTagger != nil {
+		s.dbTagger.Tag(s.delivered.height, hash)
+	}
 
 	var err error
 	s.checked, err = s.delivered.Copy()
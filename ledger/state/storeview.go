@@ -371,6 +371,17 @@ func (sv *StoreView) UpdateStakeTransactionHeightList(hl *types.HeightList) {
 	sv.Set(StakeTransactionHeightListKey(), hlBytes)
 }
 
+// DoubleSignOffenceSlashed returns whether the given double sign offence has already been slashed
+func (sv *StoreView) DoubleSignOffenceSlashed(offenceKey common.Hash) bool {
+	data := sv.Get(DoubleSignOffenceKey(offenceKey))
+	return len(data) != 0
+}
+
+// SetDoubleSignOffenceSlashed records that the given double sign offence has been slashed
+func (sv *StoreView) SetDoubleSignOffenceSlashed(offenceKey common.Hash) {
+	sv.Set(DoubleSignOffenceKey(offenceKey), common.Bytes{0x1})
+}
+
 type StakeWithHolder struct {
 	Holder common.Address
 	Stake  core.Stake
@@ -21,6 +21,7 @@ import (
 	"github.com/scripttoken/script/p2p"
 	p2psim "github.com/scripttoken/script/p2p/simulation"
 	"github.com/scripttoken/script/p2pl"
+	"github.com/scripttoken/script/signer"
 	"github.com/scripttoken/script/store/database"
 	"github.com/scripttoken/script/store/database/backend"
 	"github.com/scripttoken/script/store/kvstore"
@@ -54,7 +55,11 @@ func newExecSim(chainID string, db database.Database, snapshot mockSnapshot, val
 	dispatcher := dp.NewDispatcher(messenger, nil)
 
 	valMgr := consensus.NewFixedValidatorManager()
-	consensus := consensus.NewConsensusEngine(valPrivAcc.PrivKey, store, chain, dispatcher, valMgr)
+	valSigner, err := signer.NewLocalSigner(valPrivAcc.PrivKey)
+	if err != nil {
+		panic(err)
+	}
+	consensus := consensus.NewConsensusEngine(valSigner, store, chain, dispatcher, valMgr)
 	valMgr.SetConsensusEngine(consensus)
 
 	mempool := mp.CreateMempool(dispatcher, consensus)
@@ -198,7 +203,7 @@ func newTestLedger() (chainID string, ledger *Ledger, mempool *mp.Mempool) {
 	valMgr := newTesetValidatorManager(consensus)
 	p2psimnet := p2psim.NewSimnetWithHandler(nil)
 	messenger := p2psimnet.AddEndpoint(peerID)
-	mempool = newTestMempool(peerID, messenger, nil)
+	mempool = newTestMempool(peerID, messenger, nil, consensus)
 	ledger = NewLedger(chainID, db, nil, chain, consensus, valMgr, mempool)
 	mempool.SetLedger(ledger)
 
@@ -223,7 +228,7 @@ func newTestLedger() (chainID string, ledger *Ledger, mempool *mp.Mempool) {
 }
 
 func newTesetValidatorManager(consensus core.ConsensusEngine) core.ValidatorManager {
-	proposerAddressStr := consensus.PrivateKey().PublicKey().Address().String()
+	proposerAddressStr := consensus.Signer().PublicKey().Address().String()
 	propser := core.NewValidator(proposerAddressStr, new(big.Int).SetUint64(999))
 
 	_, val2PubKey, err := crypto.TEST_GenerateKeyPairWithSeed("val2")
@@ -240,9 +245,9 @@ func newTesetValidatorManager(consensus core.ConsensusEngine) core.ValidatorMana
 	return valMgr
 }
 
-func newTestMempool(peerID string, messenger p2p.Network, messengerL p2pl.Network) *mp.Mempool {
+func newTestMempool(peerID string, messenger p2p.Network, messengerL p2pl.Network, engine mp.SyncChecker) *mp.Mempool {
 	dispatcher := dp.NewDispatcher(messenger, nil)
-	mempool := mp.CreateMempool(dispatcher, nil)
+	mempool := mp.CreateMempool(dispatcher, engine)
 	txMsgHandler := mp.CreateMempoolMessageHandler(mempool)
 	messenger.RegisterMessageHandler(txMsgHandler)
 	return mempool
@@ -286,16 +291,19 @@ func newRawCoinbaseTx(chainID string, ledger *Ledger, sequence int) common.Bytes
 		outputs = append(outputs, output)
 	}
 
-	proposerSk := ledger.consensus.PrivateKey()
-	proposerPk := proposerSk.PublicKey()
+	proposerSigner := ledger.consensus.Signer()
+	proposerPk := proposerSigner.PublicKey()
 	coinbaseTx := &types.CoinbaseTx{
 		Proposer:    types.TxInput{Address: proposerPk.Address(), Sequence: uint64(sequence)},
 		Outputs:     outputs,
 		BlockHeight: 2,
 	}
 
-	signBytes := coinbaseTx.SignBytes(chainID)
-	sig, err := proposerSk.Sign(signBytes)
+	rawTx, err := types.TxToBytes(coinbaseTx)
+	if err != nil {
+		panic("Failed to encode the coinbase transaction")
+	}
+	sig, err := proposerSigner.SignTx(chainID, rawTx)
 	if err != nil {
 		panic("Failed to sign the coinbase transaction")
 	}
@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
 	"github.com/scripttoken/script/rlp"
 )
 
@@ -59,6 +60,18 @@ func (l *Log) DecodeRLP(s *rlp.Stream) error {
 	return err
 }
 
+// LogsBloom returns the bloom filter covering the addresses and topics of the given logs.
+func LogsBloom(logs []*Log) core.Bloom {
+	var bloom core.Bloom
+	for _, log := range logs {
+		bloom.AddBytes(log.Address.Bytes())
+		for _, topic := range log.Topics {
+			bloom.AddBytes(topic.Bytes())
+		}
+	}
+	return bloom
+}
+
 // BalanceChange represents a contract balance transfer event.
 type BalanceChange struct {
 	// address of the account
@@ -34,6 +34,7 @@ func PrivAccountFromSecret(secret string) PrivAccount {
 		PrivKey: privKey,
 		Account: Account{
 			Address:                privKey.PublicKey().Address(),
+			CodeHash:               EmptyCodeHash,
 			LastUpdatedBlockHeight: 1,
 		},
 	}
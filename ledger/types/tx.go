@@ -37,6 +37,7 @@ Transaction Types:
  - WithdrawStakeTx         Withdraw stake from a target address (e.g. a validator)
  - SmartContractTx         Execute smart contract
  - StakeRewardDistribution Defines how stake reward is distributed
+ - DoubleSignSlashTx       Transaction for slashing validators that signed conflicting blocks
 */
 
 // Gas of regular transactions
@@ -346,6 +347,72 @@ func (tx *SlashTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+type DoubleSignSlashTx struct {
+	Proposer TxInput
+	Proof    core.DoubleSignProof
+}
+
+type DoubleSignSlashTxJSON struct {
+	Proposer TxInput              `json:"proposer"`
+	Proof    core.DoubleSignProof `json:"proof"`
+}
+
+func NewDoubleSignSlashTxJSON(a DoubleSignSlashTx) DoubleSignSlashTxJSON {
+	return DoubleSignSlashTxJSON{
+		Proposer: a.Proposer,
+		Proof:    a.Proof,
+	}
+}
+
+func (a DoubleSignSlashTxJSON) DoubleSignSlashTx() DoubleSignSlashTx {
+	return DoubleSignSlashTx{
+		Proposer: a.Proposer,
+		Proof:    a.Proof,
+	}
+}
+
+func (a DoubleSignSlashTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewDoubleSignSlashTxJSON(a))
+}
+
+func (a *DoubleSignSlashTx) UnmarshalJSON(data []byte) error {
+	var b DoubleSignSlashTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.DoubleSignSlashTx()
+	return nil
+}
+
+func (_ *DoubleSignSlashTx) AssertIsTx() {}
+
+func (tx *DoubleSignSlashTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *DoubleSignSlashTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *DoubleSignSlashTx) String() string {
+	return fmt.Sprintf("DoubleSignSlashTx{%v->%v, proof: %v}",
+		tx.Proposer.Address.Hex(), tx.Proof.Offender.Hex(), &tx.Proof)
+}
+
+//-----------------------------------------------------------------------------
+
 type SendTx struct {
 	Fee     Coins      `json:"fee"` // Fee
 	Inputs  []TxInput  `json:"inputs"`
@@ -0,0 +1,113 @@
+package vm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+)
+
+// CallFrame is a node of the call tree recorded by the CallTracer.
+type CallFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*CallFrame   `json:"calls,omitempty"`
+}
+
+func newCallFrame(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) *CallFrame {
+	frame := &CallFrame{
+		Type:  typ.String(),
+		From:  from,
+		To:    to,
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	return frame
+}
+
+func (f *CallFrame) finish(output []byte, gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	f.Output = common.CopyBytes(output)
+	if err != nil {
+		f.Error = err.Error()
+	}
+}
+
+// CallTracer records the tree of the call frames entered by a transaction, i.e. the nested
+// CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE and CREATE2 operations together with
+// their value, gas, input, output and error. It implements CallFrameTracer.
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+// NewCallTracer returns a new call tracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// CaptureStart implements the Tracer interface to record the top level call frame.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	t.root = newCallFrame(typ, from, to, input, gas, value)
+	t.stack = []*CallFrame{t.root}
+	return nil
+}
+
+// CaptureState implements the Tracer interface. Individual steps are not recorded.
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureFault implements the Tracer interface. The faults are reported by the frame exits.
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements the Tracer interface to finalize the top level call frame.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if t.root != nil {
+		t.root.finish(output, gasUsed, err)
+	}
+	t.stack = nil
+	return nil
+}
+
+// CaptureEnter implements the CallFrameTracer interface to record a nested call frame.
+func (t *CallTracer) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := newCallFrame(typ, from, to, input, gas, value)
+	parent := t.stack[len(t.stack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	t.stack = append(t.stack, frame)
+}
+
+// CaptureExit implements the CallFrameTracer interface to finalize the innermost call frame.
+func (t *CallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(t.stack) <= 1 {
+		return
+	}
+	t.stack[len(t.stack)-1].finish(output, gasUsed, err)
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// Result returns the recorded call tree, or nil if no call has been traced.
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
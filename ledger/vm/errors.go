@@ -16,7 +16,12 @@
 
 package vm
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+)
 
 // List execution errors
 var (
@@ -31,3 +36,158 @@ var (
 	ErrInsufficientScriptBlance = errors.New("insufficient Script balance for transfer")
 	ErrInvalidStakeOperation    = errors.New("invalid stake operation")
 )
+
+// IsExecutionReverted returns true if the error indicates that the execution was
+// aborted by the REVERT opcode, in which case the return data carries the reason.
+func IsExecutionReverted(err error) bool {
+	return err == errExecutionReverted
+}
+
+// ErrorCode is a stable code classifying the failure of an EVM execution. Unlike the error
+// messages, the codes are meant to be matched on by the clients.
+type ErrorCode string
+
+const (
+	ErrorCodeNone                     ErrorCode = ""
+	ErrorCodeOutOfGas                 ErrorCode = "out_of_gas"
+	ErrorCodeExecutionReverted        ErrorCode = "execution_reverted"
+	ErrorCodeInvalidOpcode            ErrorCode = "invalid_opcode"
+	ErrorCodeInvalidJump              ErrorCode = "invalid_jump"
+	ErrorCodeStackUnderflow           ErrorCode = "stack_underflow"
+	ErrorCodeStackOverflow            ErrorCode = "stack_overflow"
+	ErrorCodeWriteProtection          ErrorCode = "write_protection"
+	ErrorCodeInsufficientBalance      ErrorCode = "insufficient_balance"
+	ErrorCodeCallDepthExceeded        ErrorCode = "call_depth_exceeded"
+	ErrorCodeReturnDataOutOfBounds    ErrorCode = "return_data_out_of_bounds"
+	ErrorCodeMaxCodeSizeExceeded      ErrorCode = "max_code_size_exceeded"
+	ErrorCodeContractAddressCollision ErrorCode = "contract_address_collision"
+	ErrorCodeInvalidGasLimit          ErrorCode = "invalid_gas_limit"
+	ErrorCodeUnknown                  ErrorCode = "unknown"
+)
+
+// errorCodesByPrefix maps the messages of the VM errors onto their codes. The receipts only keep
+// the error messages, so the classification works on the messages rather than the error values.
+var errorCodesByPrefix = []struct {
+	prefix string
+	code   ErrorCode
+}{
+	{ErrOutOfGas.Error(), ErrorCodeOutOfGas},
+	{ErrCodeStoreOutOfGas.Error(), ErrorCodeOutOfGas},
+	{errGasUintOverflow.Error(), ErrorCodeOutOfGas},
+	{errExecutionReverted.Error(), ErrorCodeExecutionReverted},
+	{"invalid opcode", ErrorCodeInvalidOpcode},
+	{"invalid jump destination", ErrorCodeInvalidJump},
+	{"stack underflow", ErrorCodeStackUnderflow},
+	{"stack limit reached", ErrorCodeStackOverflow},
+	{errWriteProtection.Error(), ErrorCodeWriteProtection},
+	{ErrInsufficientBalance.Error(), ErrorCodeInsufficientBalance},
+	{ErrInsufficientScriptBlance.Error(), ErrorCodeInsufficientBalance},
+	{ErrDepth.Error(), ErrorCodeCallDepthExceeded},
+	{errReturnDataOutOfBounds.Error(), ErrorCodeReturnDataOutOfBounds},
+	{errMaxCodeSizeExceeded.Error(), ErrorCodeMaxCodeSizeExceeded},
+	{ErrContractAddressCollision.Error(), ErrorCodeContractAddressCollision},
+	{ErrInvalidGasLimit.Error(), ErrorCodeInvalidGasLimit},
+}
+
+// ClassifyError returns the code of the VM error with the given message.
+func ClassifyError(errMsg string) ErrorCode {
+	if errMsg == "" {
+		return ErrorCodeNone
+	}
+	for _, entry := range errorCodesByPrefix {
+		if strings.HasPrefix(errMsg, entry.prefix) {
+			return entry.code
+		}
+	}
+	return ErrorCodeUnknown
+}
+
+var (
+	// revertSelector is the selector of Error(string), which the Solidity compiler uses to
+	// encode the reason passed to revert() and require()
+	revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+	// panicSelector is the selector of Panic(uint256), which the Solidity compiler uses to
+	// encode the failed assertions and the runtime errors like an arithmetic overflow
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// panicReasons describes the panic codes defined by Solidity.
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// UnpackRevertReason decodes the reason string from the data returned by a reverted
+// execution. It returns false if the data is not an ABI encoded Error(string).
+func UnpackRevertReason(data []byte) (string, bool) {
+	if len(data) < 4+64 || !bytes.Equal(data[:4], revertSelector) {
+		return "", false
+	}
+	data = data[4:]
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data))-32 {
+		return "", false
+	}
+	start := offset.Uint64()
+	length := new(big.Int).SetBytes(data[start : start+32])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data))-start-32 {
+		return "", false
+	}
+	return string(data[start+32 : start+32+length.Uint64()]), true
+}
+
+// UnpackPanicCode decodes the panic code from the data returned by a reverted execution.
+// It returns false if the data is not an ABI encoded Panic(uint256).
+func UnpackPanicCode(data []byte) (*big.Int, bool) {
+	if len(data) != 4+32 || !bytes.Equal(data[:4], panicSelector) {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(data[4:]), true
+}
+
+// PanicReason returns the description of the Solidity panic code.
+func PanicReason(code *big.Int) string {
+	if code.IsUint64() {
+		if reason, ok := panicReasons[code.Uint64()]; ok {
+			return reason
+		}
+	}
+	return "unknown panic code"
+}
+
+// ExecutionFailure is the classification of a failed EVM execution, together with the revert
+// reason or the panic code decoded from the return data.
+type ExecutionFailure struct {
+	Code         ErrorCode
+	RevertReason string   // the reason passed to revert() or require(), if any
+	PanicCode    *big.Int // the Solidity panic code, if any
+}
+
+// DecodeExecutionFailure classifies the VM error with the given message and decodes the
+// return data of a reverted execution. It returns nil if the execution succeeded.
+func DecodeExecutionFailure(errMsg string, ret []byte) *ExecutionFailure {
+	code := ClassifyError(errMsg)
+	if code == ErrorCodeNone {
+		return nil
+	}
+	failure := &ExecutionFailure{
+		Code: code,
+	}
+	if code == ErrorCodeExecutionReverted {
+		if reason, ok := UnpackRevertReason(ret); ok {
+			failure.RevertReason = reason
+		} else if panicCode, ok := UnpackPanicCode(ret); ok {
+			failure.PanicCode = panicCode
+		}
+	}
+	return failure
+}
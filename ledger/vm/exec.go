@@ -25,6 +25,13 @@ func NewBlockInfo(height uint64, timestamp *big.Int, chainID string) *BlockInfo
 
 // Execute executes the given smart contract
 func Execute(parentBlockInfo *BlockInfo, tx *types.SmartContractTx, statedb StateDB) (evmRet common.Bytes,
+	contractAddr common.Address, gasUsed uint64, evmErr error) {
+	return ExecuteWithConfig(parentBlockInfo, tx, statedb, Config{})
+}
+
+// ExecuteWithConfig executes the given smart contract with the given EVM configuration, e.g.
+// with a tracer attached
+func ExecuteWithConfig(parentBlockInfo *BlockInfo, tx *types.SmartContractTx, statedb StateDB, config Config) (evmRet common.Bytes,
 	contractAddr common.Address, gasUsed uint64, evmErr error) {
 	context := Context{
 		CanTransfer: CanTransfer,
@@ -40,7 +47,6 @@ func Execute(parentBlockInfo *BlockInfo, tx *types.SmartContractTx, statedb Stat
 	chainConfig := &params.ChainConfig{
 		ChainID: chainIDBigInt,
 	}
-	config := Config{}
 	evm := NewEVM(context, statedb, chainConfig, config)
 
 	value := tx.From.Coins.SPAYWei
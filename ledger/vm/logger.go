@@ -102,6 +102,15 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// CallFrameTracer is implemented by the tracers which also follow the nested call frames.
+// CaptureEnter is called when a CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE or CREATE2
+// enters a new frame below the top level one, and CaptureExit when that frame returns.
+type CallFrameTracer interface {
+	Tracer
+	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int)
+	CaptureExit(output []byte, gasUsed uint64, err error)
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
@@ -395,6 +395,13 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		return nil, gas, ErrInsufficientScriptBlance
 	}
 
+	if evm.vmConfig.Debug {
+		evm.captureEnter(CALL, caller.Address(), addr, input, gas, value)
+		defer func(startTime time.Time) {
+			evm.captureExit(ret, gas-leftOverGas, time.Since(startTime), err)
+		}(time.Now())
+	}
+
 	var (
 		to       = AccountRef(addr)
 		snapshot = evm.StateDB.Snapshot()
@@ -403,11 +410,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 
 		precompiles := getPrecompiledContracts(blockHeight)
 		if precompiles[addr] == nil && value.Sign() == 0 {
-			// Calling a non existing account, don't do anything, but ping the tracer
-			if evm.vmConfig.Debug && evm.depth == 0 {
-				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
-				evm.vmConfig.Tracer.CaptureEnd(ret, 0, 0, nil)
-			}
+			// Calling a non existing account, don't do anything
 			return nil, gas, nil
 		}
 
@@ -468,6 +471,13 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 		return nil, gas, ErrInsufficientScriptBlance
 	}
 
+	if evm.vmConfig.Debug {
+		evm.captureEnter(CALLCODE, caller.Address(), addr, input, gas, value)
+		defer func(startTime time.Time) {
+			evm.captureExit(ret, gas-leftOverGas, time.Since(startTime), err)
+		}(time.Now())
+	}
+
 	var (
 		snapshot = evm.StateDB.Snapshot()
 		to       = AccountRef(caller.Address())
@@ -502,6 +512,13 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 		return nil, gas, ErrDepth
 	}
 
+	if evm.vmConfig.Debug {
+		evm.captureEnter(DELEGATECALL, caller.Address(), addr, input, gas, nil)
+		defer func(startTime time.Time) {
+			evm.captureExit(ret, gas-leftOverGas, time.Since(startTime), err)
+		}(time.Now())
+	}
+
 	var (
 		snapshot = evm.StateDB.Snapshot()
 		to       = AccountRef(caller.Address())
@@ -534,6 +551,13 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 		return nil, gas, ErrDepth
 	}
 
+	if evm.vmConfig.Debug {
+		evm.captureEnter(STATICCALL, caller.Address(), addr, input, gas, new(big.Int))
+		defer func(startTime time.Time) {
+			evm.captureExit(ret, gas-leftOverGas, time.Since(startTime), err)
+		}(time.Now())
+	}
+
 	var (
 		to       = AccountRef(addr)
 		snapshot = evm.StateDB.Snapshot()
@@ -570,7 +594,7 @@ func (c *codeAndHash) Hash() common.Hash {
 }
 
 // create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, scriptValue *big.Int, address common.Address) ([]byte, common.Address, uint64, error) {
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, scriptValue *big.Int, address common.Address, typ OpCode) ([]byte, common.Address, uint64, error) {
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
 	if evm.depth > int(params.CallCreateDepth) {
@@ -616,8 +640,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, address, gas, nil
 	}
 
-	if evm.vmConfig.Debug && evm.depth == 0 {
-		evm.vmConfig.Tracer.CaptureStart(caller.Address(), address, true, codeAndHash.code, gas, value)
+	if evm.vmConfig.Debug {
+		evm.captureEnter(typ, caller.Address(), address, codeAndHash.code, gas, value)
 	}
 	start := time.Now()
 
@@ -656,8 +680,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if maxCodeSizeExceeded && err == nil {
 		err = errMaxCodeSizeExceeded
 	}
-	if evm.vmConfig.Debug && evm.depth == 0 {
-		evm.vmConfig.Tracer.CaptureEnd(ret, gas-contract.Gas, time.Since(start), err)
+	if evm.vmConfig.Debug {
+		evm.captureExit(ret, gas-contract.Gas, time.Since(start), err)
 	}
 	return ret, address, contract.Gas, err
 
@@ -666,7 +690,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int, scriptValue *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
-	return evm.create(caller, &codeAndHash{code: code}, gas, value, scriptValue, contractAddr)
+	return evm.create(caller, &codeAndHash{code: code}, gas, value, scriptValue, contractAddr, CREATE)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -676,7 +700,30 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *big.Int, scriptEndowment *big.Int, salt *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gas, endowment, scriptEndowment, contractAddr)
+	return evm.create(caller, codeAndHash, gas, endowment, scriptEndowment, contractAddr, CREATE2)
+}
+
+// captureEnter notifies the tracer that a call frame is entered. The top level frame starts the
+// trace, while the nested frames are only reported to the tracers following the call frames.
+func (evm *EVM) captureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureStart(from, to, typ == CREATE || typ == CREATE2, input, gas, value)
+		return
+	}
+	if tracer, ok := evm.vmConfig.Tracer.(CallFrameTracer); ok {
+		tracer.CaptureEnter(typ, from, to, input, gas, value)
+	}
+}
+
+// captureExit notifies the tracer that the call frame entered last is exited.
+func (evm *EVM) captureExit(output []byte, gasUsed uint64, t time.Duration, err error) {
+	if evm.depth == 0 {
+		evm.vmConfig.Tracer.CaptureEnd(output, gasUsed, t, err)
+		return
+	}
+	if tracer, ok := evm.vmConfig.Tracer.(CallFrameTracer); ok {
+		tracer.CaptureExit(output, gasUsed, err)
+	}
 }
 
 // ChainConfig returns the environment's chain configuration
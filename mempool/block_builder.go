@@ -0,0 +1,226 @@
+package mempool
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"github.com/spf13/viper"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+)
+
+// Names of the block builders, selected by common.CfgMempoolBlockBuilder
+const (
+	BlockBuilderFeeGreedy     = "fee_greedy"
+	BlockBuilderFIFO          = "fifo"
+	BlockBuilderReservedLanes = "reserved_lanes"
+)
+
+// BlockTxCandidate is a pending transaction considered for the next block.
+type BlockTxCandidate struct {
+	RawTx   common.Bytes
+	TxInfo  *core.TxInfo
+	Arrival uint64 // order in which the transaction entered the mempool
+}
+
+// BlockBuilder selects the regular transactions of the next block among the pending transactions.
+type BlockBuilder interface {
+	// SelectTxs returns the selected transactions in the order they are to be included in the block.
+	// accountTxs holds the pending transactions of each account ordered by sequence, and a transaction
+	// can only be selected after all the preceding transactions of its account. A maxGas of zero means
+	// there is no gas limit.
+	SelectTxs(accountTxs [][]*BlockTxCandidate, maxNumTxs int, maxGas uint64) []*BlockTxCandidate
+}
+
+var (
+	_ BlockBuilder = (*FeeGreedyBlockBuilder)(nil)
+	_ BlockBuilder = (*FIFOBlockBuilder)(nil)
+	_ BlockBuilder = (*ReservedLanesBlockBuilder)(nil)
+)
+
+// FeeGreedyBlockBuilder fills the block with the transactions paying the highest effective gas price
+// first. A transaction which does not fit in the remaining gas is skipped, together with the later
+// transactions of its account, so that the remaining gas can still be filled by smaller transactions.
+type FeeGreedyBlockBuilder struct {
+}
+
+func (b *FeeGreedyBlockBuilder) SelectTxs(accountTxs [][]*BlockTxCandidate, maxNumTxs int, maxGas uint64) []*BlockTxCandidate {
+	selection := newBlockTxSelection(accountTxs)
+	selection.fill(maxNumTxs, gasBudget(maxGas), hasHigherFee, nil)
+	return selection.selected
+}
+
+// FIFOBlockBuilder fills the block with the transactions in the order they entered the mempool,
+// regardless of their fees.
+type FIFOBlockBuilder struct {
+}
+
+func (b *FIFOBlockBuilder) SelectTxs(accountTxs [][]*BlockTxCandidate, maxNumTxs int, maxGas uint64) []*BlockTxCandidate {
+	selection := newBlockTxSelection(accountTxs)
+	selection.fill(maxNumTxs, gasBudget(maxGas), hasArrivedEarlier, nil)
+	return selection.selected
+}
+
+// ReservedLanesBlockBuilder guarantees a share of each block, both in number of transactions and in
+// gas, to the transactions of the reserved lanes. Each lane is filled first with its own transactions
+// by the highest effective gas price, and the rest of the block is then filled with any transactions
+// the same way. The share a lane does not use is available to the other transactions.
+type ReservedLanesBlockBuilder struct {
+	lanes  []core.TxLane
+	shares map[core.TxLane]int // map: lane -> share of the block in percent
+}
+
+// NewReservedLanesBlockBuilder creates a ReservedLanesBlockBuilder reserving the given share, in percent,
+// of each block to each lane. The lanes are filled in the given order.
+func NewReservedLanesBlockBuilder(lanes []core.TxLane, shares []int) *ReservedLanesBlockBuilder {
+	b := &ReservedLanesBlockBuilder{
+		lanes:  lanes,
+		shares: make(map[core.TxLane]int),
+	}
+	for i, lane := range lanes {
+		b.shares[lane] = shares[i]
+	}
+	return b
+}
+
+func (b *ReservedLanesBlockBuilder) SelectTxs(accountTxs [][]*BlockTxCandidate, maxNumTxs int, maxGas uint64) []*BlockTxCandidate {
+	selection := newBlockTxSelection(accountTxs)
+	gas := gasBudget(maxGas)
+	for _, lane := range b.lanes {
+		share := b.shares[lane]
+		if share <= 0 {
+			continue
+		}
+		laneNumTxs := maxNumTxs * share / 100
+		laneGas := gas / 100 * uint64(share)
+		if laneNumTxs > maxNumTxs-len(selection.selected) {
+			laneNumTxs = maxNumTxs - len(selection.selected)
+		}
+		if laneGas > gas-selection.gas {
+			laneGas = gas - selection.gas
+		}
+
+		lane := lane
+		selection.fill(laneNumTxs, laneGas, hasHigherFee, func(tx *BlockTxCandidate) bool {
+			return tx.TxInfo.Lane == lane
+		})
+	}
+	selection.fill(maxNumTxs-len(selection.selected), gas-selection.gas, hasHigherFee, nil)
+	return selection.selected
+}
+
+// createBlockBuilder creates the block builder with the given name, configured from the node config.
+func createBlockBuilder(name string) (BlockBuilder, error) {
+	switch name {
+	case BlockBuilderFeeGreedy:
+		return &FeeGreedyBlockBuilder{}, nil
+	case BlockBuilderFIFO:
+		return &FIFOBlockBuilder{}, nil
+	case BlockBuilderReservedLanes:
+		return NewReservedLanesBlockBuilder(
+			[]core.TxLane{core.TxLaneStake, core.TxLaneServicePayment},
+			[]int{viper.GetInt(common.CfgMempoolStakeLaneShare), viper.GetInt(common.CfgMempoolServicePaymentLaneShare)},
+		), nil
+	}
+	return nil, fmt.Errorf("unknown block builder: %v", name)
+}
+
+func gasBudget(maxGas uint64) uint64 {
+	if maxGas == 0 {
+		return math.MaxUint64
+	}
+	return maxGas
+}
+
+func hasHigherFee(a, b *BlockTxCandidate) bool {
+	cmp := a.TxInfo.EffectiveGasPrice.Cmp(b.TxInfo.EffectiveGasPrice)
+	if cmp != 0 {
+		return cmp > 0
+	}
+	return a.Arrival < b.Arrival
+}
+
+func hasArrivedEarlier(a, b *BlockTxCandidate) bool {
+	return a.Arrival < b.Arrival
+}
+
+// blockTxSelection keeps track of the transactions selected so far. The next transaction of an
+// account can only be selected after all its preceding ones.
+type blockTxSelection struct {
+	accountTxs [][]*BlockTxCandidate
+	next       []int // index of the next transaction of each account
+	selected   []*BlockTxCandidate
+	gas        uint64
+}
+
+func newBlockTxSelection(accountTxs [][]*BlockTxCandidate) *blockTxSelection {
+	return &blockTxSelection{
+		accountTxs: accountTxs,
+		next:       make([]int, len(accountTxs)),
+		selected:   []*BlockTxCandidate{},
+	}
+}
+
+// fill selects up to maxNumTxs more transactions using up to maxGas more gas, picking the best next
+// transaction accepted by the filter each time. An account whose next transaction does not fit in the
+// remaining gas is not considered further.
+func (s *blockTxSelection) fill(maxNumTxs int, maxGas uint64, better func(a, b *BlockTxCandidate) bool, accept func(tx *BlockTxCandidate) bool) {
+	heads := &blockTxHeads{selection: s, better: better}
+	for account := range s.accountTxs {
+		if tx := s.nextTx(account); tx != nil && (accept == nil || accept(tx)) {
+			heads.accounts = append(heads.accounts, account)
+		}
+	}
+	heap.Init(heads)
+
+	numTxs, gas := 0, uint64(0)
+	for numTxs < maxNumTxs && heads.Len() > 0 {
+		account := heap.Pop(heads).(int)
+		tx := s.nextTx(account)
+		if tx.TxInfo.Gas > maxGas-gas {
+			continue
+		}
+		s.selected = append(s.selected, tx)
+		s.gas += tx.TxInfo.Gas
+		s.next[account]++
+		numTxs++
+		gas += tx.TxInfo.Gas
+
+		if tx := s.nextTx(account); tx != nil && (accept == nil || accept(tx)) {
+			heap.Push(heads, account)
+		}
+	}
+}
+
+func (s *blockTxSelection) nextTx(account int) *BlockTxCandidate {
+	if s.next[account] >= len(s.accountTxs[account]) {
+		return nil
+	}
+	return s.accountTxs[account][s.next[account]]
+}
+
+// blockTxHeads implements heap.Interface over the accounts, ordered by their next transactions.
+type blockTxHeads struct {
+	selection *blockTxSelection
+	better    func(a, b *BlockTxCandidate) bool
+	accounts  []int
+}
+
+func (h *blockTxHeads) Len() int { return len(h.accounts) }
+
+func (h *blockTxHeads) Less(i, j int) bool {
+	return h.better(h.selection.nextTx(h.accounts[i]), h.selection.nextTx(h.accounts[j]))
+}
+
+func (h *blockTxHeads) Swap(i, j int) { h.accounts[i], h.accounts[j] = h.accounts[j], h.accounts[i] }
+
+func (h *blockTxHeads) Push(x interface{}) { h.accounts = append(h.accounts, x.(int)) }
+
+func (h *blockTxHeads) Pop() interface{} {
+	n := len(h.accounts)
+	account := h.accounts[n-1]
+	h.accounts = h.accounts[:n-1]
+	return account
+}
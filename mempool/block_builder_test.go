@@ -0,0 +1,192 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockBuilders(t *testing.T) {
+	reservedLanes := NewReservedLanesBlockBuilder([]core.TxLane{core.TxLaneStake, core.TxLaneServicePayment}, []int{20, 10})
+
+	tests := []struct {
+		name       string
+		builder    BlockBuilder
+		accountTxs [][]*BlockTxCandidate
+		maxNumTxs  int
+		maxGas     uint64
+		expected   []string
+	}{
+		{
+			name:    "fee greedy follows the sequences of each account",
+			builder: &FeeGreedyBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 10, 1, 1), newBlockTxCandidate("a2", 100, 1, 2)},
+				{newBlockTxCandidate("b1", 50, 1, 3)},
+			},
+			maxNumTxs: 10,
+			expected:  []string{"b1", "a1", "a2"},
+		},
+		{
+			name:    "fee greedy stops at the max number of txs",
+			builder: &FeeGreedyBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 10, 1, 1), newBlockTxCandidate("a2", 100, 1, 2)},
+				{newBlockTxCandidate("b1", 50, 1, 3)},
+			},
+			maxNumTxs: 2,
+			expected:  []string{"b1", "a1"},
+		},
+		{
+			name:    "fee greedy breaks the ties by arrival",
+			builder: &FeeGreedyBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 10, 1, 2)},
+				{newBlockTxCandidate("b1", 10, 1, 1)},
+			},
+			maxNumTxs: 10,
+			expected:  []string{"b1", "a1"},
+		},
+		{
+			name:    "fee greedy skips the txs exceeding the remaining gas",
+			builder: &FeeGreedyBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 100, 8, 1), newBlockTxCandidate("a2", 100, 1, 2)},
+				{newBlockTxCandidate("b1", 50, 5, 3)},
+				{newBlockTxCandidate("c1", 10, 1, 4)},
+			},
+			maxNumTxs: 10,
+			maxGas:    10,
+			expected:  []string{"a1", "a2", "c1"},
+		},
+		{
+			name:    "fee greedy skips the later txs of an account whose tx exceeds the gas",
+			builder: &FeeGreedyBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 100, 20, 1), newBlockTxCandidate("a2", 100, 1, 2)},
+				{newBlockTxCandidate("b1", 10, 1, 3)},
+			},
+			maxNumTxs: 10,
+			maxGas:    10,
+			expected:  []string{"b1"},
+		},
+		{
+			name:    "fifo follows the arrival order and the sequences of each account",
+			builder: &FIFOBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 100, 1, 3), newBlockTxCandidate("a2", 100, 1, 1)},
+				{newBlockTxCandidate("b1", 1, 1, 2)},
+				{newBlockTxCandidate("c1", 1, 1, 4)},
+			},
+			maxNumTxs: 10,
+			expected:  []string{"b1", "a1", "a2", "c1"},
+		},
+		{
+			name:    "fifo skips the txs exceeding the remaining gas",
+			builder: &FIFOBlockBuilder{},
+			accountTxs: [][]*BlockTxCandidate{
+				{newBlockTxCandidate("a1", 1, 5, 1)},
+				{newBlockTxCandidate("b1", 1, 6, 2)},
+				{newBlockTxCandidate("c1", 1, 5, 3)},
+			},
+			maxNumTxs: 10,
+			maxGas:    10,
+			expected:  []string{"a1", "c1"},
+		},
+		{
+			name:       "reserved lanes get their shares of the block",
+			builder:    reservedLanes,
+			accountTxs: newLaneTestAccountTxs(5, 5, 10, 1),
+			maxNumTxs:  10,
+			expected:   []string{"s1", "s2", "p1", "r1", "r2", "r3", "r4", "r5", "r6", "r7"},
+		},
+		{
+			name:       "the unused shares carry over to the other txs",
+			builder:    reservedLanes,
+			accountTxs: newLaneTestAccountTxs(1, 0, 10, 1),
+			maxNumTxs:  10,
+			expected:   []string{"s1", "r1", "r2", "r3", "r4", "r5", "r6", "r7", "r8", "r9"},
+		},
+		{
+			name:       "the reserved lanes compete for the rest of the block",
+			builder:    reservedLanes,
+			accountTxs: newLaneTestAccountTxs(5, 5, 2, 1),
+			maxNumTxs:  10,
+			expected:   []string{"s1", "s2", "p1", "r1", "r2", "s3", "s4", "s5", "p2", "p3"},
+		},
+		{
+			name:       "the reserved lanes get their shares of the gas",
+			builder:    reservedLanes,
+			accountTxs: newLaneTestAccountTxs(5, 5, 10, 10),
+			maxNumTxs:  100,
+			maxGas:     100,
+			expected:   []string{"s1", "s2", "p1", "r1", "r2", "r3", "r4", "r5", "r6", "r7"},
+		},
+	}
+
+	for _, test := range tests {
+		selected := test.builder.SelectTxs(test.accountTxs, test.maxNumTxs, test.maxGas)
+		names := []string{}
+		for _, tx := range selected {
+			names = append(names, string(tx.RawTx))
+		}
+		assert.Equal(t, test.expected, names, test.name)
+	}
+}
+
+func TestCreateBlockBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{BlockBuilderFeeGreedy, BlockBuilderFIFO, BlockBuilderReservedLanes} {
+		builder, err := createBlockBuilder(name)
+		assert.Nil(err)
+		assert.NotNil(builder)
+	}
+	_, err := createBlockBuilder("unknown")
+	assert.NotNil(err)
+}
+
+// --------------- Test Utilities --------------- //
+
+// newBlockTxCandidate creates a candidate named after its account and sequence, e.g. "a1" for the
+// first transaction of the account "a".
+func newBlockTxCandidate(name string, gasPrice int64, gas uint64, arrival uint64) *BlockTxCandidate {
+	return newLaneBlockTxCandidate(name, core.TxLaneRegular, gasPrice, gas, arrival)
+}
+
+func newLaneBlockTxCandidate(name string, lane core.TxLane, gasPrice int64, gas uint64, arrival uint64) *BlockTxCandidate {
+	return &BlockTxCandidate{
+		RawTx: common.Bytes(name),
+		TxInfo: &core.TxInfo{
+			EffectiveGasPrice: big.NewInt(gasPrice),
+			Address:           common.HexToAddress(name[:1]),
+			Gas:               gas,
+			Lane:              lane,
+		},
+		Arrival: arrival,
+	}
+}
+
+// newLaneTestAccountTxs creates the given numbers of stake ("s1", "s2", ...), service payment ("p1",
+// "p2", ...) and regular ("r1", "r2", ...) transactions, each from a different account and using the
+// given gas. The regular transactions pay more than the others.
+func newLaneTestAccountTxs(numStakeTxs, numServicePaymentTxs, numRegularTxs int, gas uint64) [][]*BlockTxCandidate {
+	accountTxs := [][]*BlockTxCandidate{}
+	arrival := uint64(0)
+	add := func(prefix string, lane core.TxLane, gasPrice int64, numTxs int) {
+		for i := 1; i <= numTxs; i++ {
+			arrival++
+			accountTxs = append(accountTxs, []*BlockTxCandidate{
+				newLaneBlockTxCandidate(fmt.Sprintf("%v%v", prefix, i), lane, gasPrice, gas, arrival),
+			})
+		}
+	}
+	add("s", core.TxLaneStake, 1, numStakeTxs)
+	add("p", core.TxLaneServicePayment, 1, numServicePaymentTxs)
+	add("r", core.TxLaneRegular, 100, numRegularTxs)
+	return accountTxs
+}
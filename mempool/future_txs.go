@@ -0,0 +1,141 @@
+package mempool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+)
+
+// futureTransaction is a transaction whose sequence is ahead of the next expected sequence of its account
+type futureTransaction struct {
+	rawTransaction common.Bytes
+	txInfo         *core.TxInfo
+	expiresAt      time.Time
+}
+
+// futureTxQueue holds the future transactions until the sequence gaps before them close, or they expire.
+// It is protected by the mempool lock.
+type futureTxQueue struct {
+	txs  map[common.Address]map[uint64]*futureTransaction // map: address -> sequence -> transaction
+	size int
+
+	maxNumTxs           int
+	maxNumTxsPerAccount int
+	ttl                 time.Duration
+}
+
+func createFutureTxQueue(maxNumTxs, maxNumTxsPerAccount int, ttl time.Duration) *futureTxQueue {
+	return &futureTxQueue{
+		txs:                 make(map[common.Address]map[uint64]*futureTransaction),
+		maxNumTxs:           maxNumTxs,
+		maxNumTxsPerAccount: maxNumTxsPerAccount,
+		ttl:                 ttl,
+	}
+}
+
+// add queues the transaction. If a transaction with the same sequence from the same account is already
+// queued, it is returned as replaced.
+func (q *futureTxQueue) add(rawTx common.Bytes, txInfo *core.TxInfo, minReplacementFeeBump int64) (replaced *futureTransaction, err error) {
+	accountTxs := q.txs[txInfo.Address]
+	if existing, ok := accountTxs[txInfo.Sequence]; ok {
+		if !isReplacementPriced(existing.txInfo, txInfo, minReplacementFeeBump) {
+			return nil, ReplacementUnderpricedError
+		}
+		replaced = existing
+	} else {
+		if q.maxNumTxsPerAccount > 0 && len(accountTxs) >= q.maxNumTxsPerAccount {
+			return nil, AccountQuotaExceededError
+		}
+		if q.size >= q.maxNumTxs {
+			return nil, FutureTxQueueFullError
+		}
+	}
+
+	if accountTxs == nil {
+		accountTxs = make(map[uint64]*futureTransaction)
+		q.txs[txInfo.Address] = accountTxs
+	}
+	accountTxs[txInfo.Sequence] = &futureTransaction{
+		rawTransaction: rawTx,
+		txInfo:         txInfo,
+		expiresAt:      time.Now().Add(q.ttl),
+	}
+	if replaced == nil {
+		q.size++
+	}
+	return replaced, nil
+}
+
+// peek returns the queued transaction with the lowest sequence of the given account, or nil if there is none.
+func (q *futureTxQueue) peek(address common.Address) *futureTransaction {
+	var next *futureTransaction
+	for _, ftx := range q.txs[address] {
+		if next == nil || ftx.txInfo.Sequence < next.txInfo.Sequence {
+			next = ftx
+		}
+	}
+	return next
+}
+
+// accountTxs returns the queued transactions of the given account, ordered by sequence.
+func (q *futureTxQueue) accountTxs(address common.Address) []*futureTransaction {
+	ftxs := []*futureTransaction{}
+	for _, ftx := range q.txs[address] {
+		ftxs = append(ftxs, ftx)
+	}
+	sort.Slice(ftxs, func(i, j int) bool {
+		return ftxs[i].txInfo.Sequence < ftxs[j].txInfo.Sequence
+	})
+	return ftxs
+}
+
+func (q *futureTxQueue) remove(ftx *futureTransaction) {
+	accountTxs, ok := q.txs[ftx.txInfo.Address]
+	if !ok {
+		return
+	}
+	if _, ok := accountTxs[ftx.txInfo.Sequence]; !ok {
+		return
+	}
+	delete(accountTxs, ftx.txInfo.Sequence)
+	q.size--
+	if len(accountTxs) == 0 {
+		delete(q.txs, ftx.txInfo.Address)
+	}
+}
+
+// removeExpired removes and returns the transactions which have waited longer than the TTL.
+func (q *futureTxQueue) removeExpired() []*futureTransaction {
+	now := time.Now()
+	expired := []*futureTransaction{}
+	for _, accountTxs := range q.txs {
+		for _, ftx := range accountTxs {
+			if now.After(ftx.expiresAt) {
+				expired = append(expired, ftx)
+			}
+		}
+	}
+	for _, ftx := range expired {
+		q.remove(ftx)
+	}
+	return expired
+}
+
+// addresses returns the accounts with queued transactions, in a deterministic order.
+func (q *futureTxQueue) addresses() []common.Address {
+	addresses := make([]common.Address, 0, len(q.txs))
+	for address := range q.txs {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Hex() < addresses[j].Hex()
+	})
+	return addresses
+}
+
+func (q *futureTxQueue) reset() {
+	q.txs = make(map[common.Address]map[uint64]*futureTransaction)
+	q.size = 0
+}
@@ -0,0 +1,181 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	p2psim "github.com/scripttoken/script/p2p/simulation"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFutureTxsPromotedWhenGapCloses(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	tx3 := createSequenceTestTx("A1", 3, 100)
+	assert.Nil(mempool.InsertTransaction(tx3))
+	assert.Nil(mempool.InsertTransaction(tx2))
+	assert.Equal(0, mempool.Size())
+	assert.Equal(2, mempool.futureTxs.size)
+	status, _ := mempool.txBookeepper.getStatus(getTransactionHash(tx3))
+	assert.Equal(TxStatusQueued, status)
+
+	// The missing sequence closes the gap, and the queued transactions follow it
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Equal(0, mempool.futureTxs.size)
+	assert.Equal([]common.Bytes{tx1, tx2, tx3}, mempool.Reap(-1))
+	status, _ = mempool.txBookeepper.getStatus(getTransactionHash(tx3))
+	assert.Equal(TxStatusPending, status)
+}
+
+func TestFutureTxsPromotedByBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	assert.Nil(mempool.InsertTransaction(tx2))
+	assert.Equal(1, mempool.futureTxs.size)
+
+	// The missing sequence is included in a block proposed by another node
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	ledger.commit(tx1)
+	mempool.Update([]common.Bytes{tx1})
+	assert.Equal(0, mempool.futureTxs.size)
+	assert.Equal([]common.Bytes{tx2}, getPendingRawTxs(mempool))
+}
+
+func TestFutureTxsExpire(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	tx3 := createSequenceTestTx("A1", 3, 100)
+	assert.Nil(mempool.InsertTransaction(tx2))
+	assert.Nil(mempool.InsertTransaction(tx3))
+
+	mempool.futureTxs.txs[common.HexToAddress("A1")][2].expiresAt = time.Now().Add(-time.Second)
+	mempool.Update([]common.Bytes{})
+	assert.Equal(1, mempool.futureTxs.size)
+	outcome, ok := mempool.txBookeepper.getOutcome(getTransactionHash(tx2))
+	assert.True(ok)
+	assert.Equal(TxReasonExpired, outcome.Reason)
+	_, ok = mempool.txBookeepper.getOutcome(getTransactionHash(tx3))
+	assert.False(ok)
+}
+
+func TestFutureTxQueueExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	q := createFutureTxQueue(10, 10, time.Minute)
+	_, err := q.add(common.Bytes("tx2"), newFutureTestTxInfo("A1", 2, 100), 10)
+	assert.Nil(err)
+	_, err = q.add(common.Bytes("tx3"), newFutureTestTxInfo("A1", 3, 100), 10)
+	assert.Nil(err)
+	assert.Empty(q.removeExpired())
+
+	q.txs[common.HexToAddress("A1")][3].expiresAt = time.Now().Add(-time.Second)
+	expired := q.removeExpired()
+	assert.Equal(1, len(expired))
+	assert.Equal(common.Bytes("tx3"), expired[0].rawTransaction)
+	assert.Equal(1, q.size)
+	assert.Equal(common.Bytes("tx2"), q.peek(common.HexToAddress("A1")).rawTransaction)
+}
+
+func TestFutureTxQueueCaps(t *testing.T) {
+	assert := assert.New(t)
+
+	q := createFutureTxQueue(3, 2, time.Minute)
+	_, err := q.add(common.Bytes("a2"), newFutureTestTxInfo("A1", 2, 100), 10)
+	assert.Nil(err)
+	_, err = q.add(common.Bytes("a3"), newFutureTestTxInfo("A1", 3, 100), 10)
+	assert.Nil(err)
+	_, err = q.add(common.Bytes("a4"), newFutureTestTxInfo("A1", 4, 100), 10)
+	assert.Equal(AccountQuotaExceededError, err)
+
+	_, err = q.add(common.Bytes("b2"), newFutureTestTxInfo("B1", 2, 100), 10)
+	assert.Nil(err)
+	_, err = q.add(common.Bytes("c2"), newFutureTestTxInfo("C1", 2, 100), 10)
+	assert.Equal(FutureTxQueueFullError, err)
+	assert.Equal(3, q.size)
+
+	// The removed transactions free their slots
+	q.remove(q.peek(common.HexToAddress("A1")))
+	_, err = q.add(common.Bytes("c2"), newFutureTestTxInfo("C1", 2, 100), 10)
+	assert.Nil(err)
+	assert.Equal([]common.Address{common.HexToAddress("A1"), common.HexToAddress("B1"), common.HexToAddress("C1")}, q.addresses())
+}
+
+func TestFutureTxsGlobalCap(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxNumFutureTxs, viper.GetInt(common.CfgMempoolMaxNumFutureTxs))
+	viper.Set(common.CfgMempoolMaxNumFutureTxs, 1)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 2, 100)))
+	assert.Equal(FutureTxQueueFullError, mempool.InsertTransaction(createSequenceTestTx("B1", 2, 100)))
+
+	// The pending transactions are not limited by the queue
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 100)))
+}
+
+func TestFutureTxQueueReplacement(t *testing.T) {
+	assert := assert.New(t)
+
+	q := createFutureTxQueue(10, 1, time.Minute)
+	_, err := q.add(common.Bytes("tx2"), newFutureTestTxInfo("A1", 2, 100), 10)
+	assert.Nil(err)
+
+	_, err = q.add(common.Bytes("tx2b"), newFutureTestTxInfo("A1", 2, 109), 10)
+	assert.Equal(ReplacementUnderpricedError, err)
+
+	// The replacement does not count against the per-account cap
+	replaced, err := q.add(common.Bytes("tx2c"), newFutureTestTxInfo("A1", 2, 110), 10)
+	assert.Nil(err)
+	assert.Equal(common.Bytes("tx2"), replaced.rawTransaction)
+	assert.Equal(1, q.size)
+	assert.Equal(common.Bytes("tx2c"), q.peek(common.HexToAddress("A1")).rawTransaction)
+}
+
+func TestFutureTxsReplacedInMempool(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	tx2b := createSequenceTestTx("A1", 2, 200)
+	assert.Nil(mempool.InsertTransaction(tx2))
+	assert.Nil(mempool.InsertTransaction(tx2b))
+	assert.Equal(1, mempool.futureTxs.size)
+	status, _ := mempool.txBookeepper.getStatus(getTransactionHash(tx2))
+	assert.Equal(TxStatusReplaced, status)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Equal([]common.Bytes{tx1, tx2b}, getPendingRawTxs(mempool))
+}
+
+// --------------- Test Utilities --------------- //
+
+func newFutureTestTxInfo(address string, sequence uint64, gasPrice int64) *core.TxInfo {
+	return &core.TxInfo{
+		EffectiveGasPrice: big.NewInt(gasPrice),
+		Address:           common.HexToAddress(address),
+		Sequence:          sequence,
+	}
+}
@@ -0,0 +1,64 @@
+package mempool
+
+import (
+	"errors"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/store/recordfile"
+)
+
+var errNoActiveJournal = errors.New("no active journal")
+
+// txJournal is an append-only file recording the raw transactions accepted by the mempool,
+// so that the pending transactions survive node restarts.
+type txJournal struct {
+	file *recordfile.File // not open until the journal is loaded and rotated
+}
+
+func newTxJournal(path string) *txJournal {
+	return &txJournal{
+		file: recordfile.New(path),
+	}
+}
+
+// load reads all the transactions recorded in the journal.
+func (j *txJournal) load() ([]common.Bytes, error) {
+	rawTxs := []common.Bytes{}
+	err := j.file.Read(func(stream *rlp.Stream) error {
+		rawTx, err := stream.Bytes()
+		if err != nil {
+			return err
+		}
+		rawTxs = append(rawTxs, common.Bytes(rawTx))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rawTxs, nil
+}
+
+// insert appends the transaction to the journal.
+func (j *txJournal) insert(rawTx common.Bytes) error {
+	err := j.file.Append(rawTx)
+	if err == recordfile.ErrClosed {
+		return errNoActiveJournal
+	}
+	return err
+}
+
+// rotate replaces the content of the journal with the given transactions, and reopens the
+// journal for appending.
+func (j *txJournal) rotate(rawTxs []common.Bytes) error {
+	records := make([]interface{}, len(rawTxs))
+	for i, rawTx := range rawTxs {
+		records[i] = rawTx
+	}
+	return j.file.Rewrite(records)
+}
+
+// close flushes and closes the journal.
+func (j *txJournal) close() error {
+	return j.file.Close()
+}
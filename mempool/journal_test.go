@@ -0,0 +1,59 @@
+package mempool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "mempool", "txs.journal")
+	journal := newTxJournal(path)
+
+	rawTxs, err := journal.load()
+	assert.Nil(err)
+	assert.Empty(rawTxs)
+	assert.Equal(errNoActiveJournal, journal.insert(common.Bytes("tx1")))
+
+	assert.Nil(journal.rotate([]common.Bytes{common.Bytes("tx1"), common.Bytes("tx2")}))
+	assert.Nil(journal.insert(common.Bytes("tx3")))
+	assert.Nil(journal.close())
+
+	rawTxs, err = journal.load()
+	assert.Nil(err)
+	assert.Equal([]common.Bytes{common.Bytes("tx1"), common.Bytes("tx2"), common.Bytes("tx3")}, rawTxs)
+
+	// The rotation only keeps the given transactions
+	assert.Nil(journal.rotate([]common.Bytes{common.Bytes("tx3")}))
+	assert.Nil(journal.insert(common.Bytes("tx4")))
+	assert.Nil(journal.close())
+
+	rawTxs, err = journal.load()
+	assert.Nil(err)
+	assert.Equal([]common.Bytes{common.Bytes("tx3"), common.Bytes("tx4")}, rawTxs)
+	_, err = os.Stat(path + ".new")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestJournalTruncatedRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "txs.journal")
+	journal := newTxJournal(path)
+	assert.Nil(journal.rotate([]common.Bytes{common.Bytes("tx1"), common.Bytes("a much longer transaction")}))
+	assert.Nil(journal.close())
+
+	// Simulate a crash in the middle of writing the last record
+	info, err := os.Stat(path)
+	assert.Nil(err)
+	assert.Nil(os.Truncate(path, info.Size()-5))
+
+	rawTxs, err := journal.load()
+	assert.Nil(err)
+	assert.Equal([]common.Bytes{common.Bytes("tx1")}, rawTxs)
+}
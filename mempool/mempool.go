@@ -3,19 +3,21 @@ package mempool
 import (
 	"context"
 	"encoding/hex"
-	"errors"
 	"math/big"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/scripttoken/script/common"
 	"github.com/scripttoken/script/common/clist"
 	"github.com/scripttoken/script/common/math"
+	"github.com/scripttoken/script/common/metrics"
 	"github.com/scripttoken/script/common/pqueue"
 	"github.com/scripttoken/script/common/result"
-	"github.com/scripttoken/script/consensus"
 	"github.com/scripttoken/script/core"
 	dp "github.com/scripttoken/script/dispatcher"
 )
@@ -30,14 +32,53 @@ func (m MempoolError) Error() string {
 
 const DuplicateTxError = MempoolError("Transaction already seen")
 const FastsyncSkipTxError = MempoolError("Skip tx during fastsync")
+const ReplacementUnderpricedError = MempoolError("Replacement transaction underpriced")
+const ReplacementLimitExceededError = MempoolError("Too many replacements from the account, please wait for the next block")
+const ReplacementBudgetExceededError = MempoolError("Too many replacements in the mempool, please wait for the next block")
+const AccountQuotaExceededError = MempoolError("Too many pending transactions from the account")
+const MempoolFullError = MempoolError("mempool is full, please submit your transaction again later")
+const FutureTxQueueFullError = MempoolError("Too many queued transactions with future sequences")
+const GasPriceTooLowError = MempoolError("Transaction gas price is below the minimum gas price of the node")
+
+// TxScreeningError is returned when a transaction fails the screening against the ledger state
+type TxScreeningError struct {
+	Code    result.ErrorCode
+	Message string
+}
+
+func (e TxScreeningError) Error() string {
+	return e.Message
+}
 
+// MaxMempoolTxCount is the maximum number of pending transactions if not configured otherwise
 const MaxMempoolTxCount int = 25600
 
+var (
+	mempoolNumTxsGauge           = metrics.NewRegisteredGauge("mempool/txs", nil)
+	mempoolBytesGauge            = metrics.NewRegisteredGauge("mempool/bytes", nil)
+	mempoolFutureTxsGauge        = metrics.NewRegisteredGauge("mempool/queued", nil)
+	mempoolMaxNumTxsGauge        = metrics.NewRegisteredGauge("mempool/limit/txs", nil)
+	mempoolMaxBytesGauge         = metrics.NewRegisteredGauge("mempool/limit/bytes", nil)
+	mempoolMaxAccountTxsGauge    = metrics.NewRegisteredGauge("mempool/limit/accounttxs", nil)
+	mempoolEvictedMeter          = metrics.NewRegisteredMeter("mempool/evicted", nil)
+	mempoolFullMeter             = metrics.NewRegisteredMeter("mempool/rejected/full", nil)
+	mempoolAccountQuotaMeter     = metrics.NewRegisteredMeter("mempool/rejected/accountquota", nil)
+	mempoolGasPriceTooLowMeter   = metrics.NewRegisteredMeter("mempool/rejected/gaspricetoolow", nil)
+	mempoolReplacementLimitMeter = metrics.NewRegisteredMeter("mempool/rejected/replacementlimit", nil)
+)
+
+// journalSyncCheckInterval is how often to check whether the node has synced and the journal can be replayed
+const journalSyncCheckInterval = 1 * time.Second
+
+// insertedTxsQueueSize is the capacity of the queue notifying the newly inserted transactions
+const insertedTxsQueueSize int = 1024
+
 // mempoolTransaction implements the pqueue.Element interface
 type mempoolTransaction struct {
 	index          int
 	rawTransaction common.Bytes
 	txInfo         *core.TxInfo
+	arrival        uint64 // order in which the transaction entered the mempool
 }
 
 var _ pqueue.Element = (*mempoolTransaction)(nil)
@@ -87,9 +128,26 @@ func (mtg *mempoolTransactionGroup) GetIndex() int {
 	return mtg.index
 }
 
-func (mtg *mempoolTransactionGroup) AddTx(rawTx common.Bytes, txInfo *core.TxInfo) {
+func (mtg *mempoolTransactionGroup) AddTx(rawTx common.Bytes, txInfo *core.TxInfo) *mempoolTransaction {
 	mpx := createMempoolTransaction(rawTx, txInfo)
 	mtg.txs.Push(mpx)
+	return mpx
+}
+
+// Size returns the number of transactions in the group.
+func (mtg *mempoolTransactionGroup) Size() int {
+	return mtg.txs.NumElements()
+}
+
+// FindTx returns the transaction with the given sequence, or nil if there is none.
+func (mtg *mempoolTransactionGroup) FindTx(sequence uint64) *mempoolTransaction {
+	for _, elem := range *mtg.txs.ElementList() {
+		mptx := elem.(*mempoolTransaction)
+		if mptx.txInfo.Sequence == sequence {
+			return mptx
+		}
+	}
+	return nil
 }
 
 func (mtg *mempoolTransactionGroup) PopTx() (common.Bytes, *core.TxInfo) {
@@ -101,8 +159,8 @@ func (mtg *mempoolTransactionGroup) IsEmpty() bool {
 	return mtg.txs.IsEmpty()
 }
 
-// RemoveTxs removes matching Txs from transaction group. Returns number of Txs removed.
-func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool) (numRemoved int) {
+// RemoveTxs removes matching Txs from transaction group. Returns number of Txs removed and their total size in bytes.
+func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool) (numRemoved int, numBytesRemoved int) {
 	elementList := mtg.txs.ElementList()
 	elemsTobeRemoved := []pqueue.Element{}
 	for _, elem := range *elementList {
@@ -116,25 +174,31 @@ func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool)
 	for _, elem := range elemsTobeRemoved {
 		mtg.txs.Remove(elem.GetIndex())
 		numRemoved++
+		numBytesRemoved += len(elem.(*mempoolTransaction).rawTransaction)
 	}
 	return
 }
 
-func createMempoolTransactionGroup(rawTx common.Bytes, txInfo *core.TxInfo) *mempoolTransactionGroup {
+func createMempoolTransactionGroup(address common.Address) *mempoolTransactionGroup {
 	txGroup := &mempoolTransactionGroup{
-		address: txInfo.Address,
+		address: address,
 		txs:     pqueue.CreatePriorityQueue(),
 	}
-	txGroup.AddTx(rawTx, txInfo)
 	return txGroup
 }
 
+// SyncChecker reports whether the node has caught up with the network. The mempool only screens
+// the transactions once the node has synced.
+type SyncChecker interface {
+	HasSynced() bool
+}
+
 // Mempool manages the transactions submitted by the clients
 // or relayed from peers
 type Mempool struct {
 	mutex *sync.Mutex
 
-	consensus  *consensus.ConsensusEngine
+	consensus  SyncChecker
 	ledger     core.Ledger
 	dispatcher *dp.Dispatcher
 
@@ -143,6 +207,28 @@ type Mempool struct {
 	txBookeepper     transactionBookkeeper
 	addressToTxGroup map[common.Address]*mempoolTransactionGroup
 	size             int
+	sizeInBytes      int
+	numArrivals      uint64
+	insertedTxs      chan common.Bytes // transactions which passed the screening and entered the mempool
+	futureTxs        *futureTxQueue    // transactions waiting for the preceding sequences of their accounts
+	journal          *txJournal        // nil if journaling is disabled
+	gossip           *txGossiper
+	preverifier      *txPreverifier
+	blockBuilder     BlockBuilder
+	numEvicted       uint64
+	numRejected      map[string]uint64 // map: rejection reason -> number of rejected transactions
+
+	minReplacementFeeBump     int64 // in percent
+	maxReplacementsPerAccount int
+	numReplacements           map[common.Address]int // map: account -> number of replacements since the last block
+	maxReplacementsPerBlock   int
+	numBlockReplacements      int // number of replacements by all the accounts since the last block
+	maxNumTxs                 int
+	maxNumTxsPerAccount       int
+	maxBytes                  int
+	blockGasLimit             uint64
+	minGasPrice               *big.Int
+	localAddresses            map[common.Address]bool
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -153,7 +239,44 @@ type Mempool struct {
 }
 
 // CreateMempool creates an instance of Mempool
-func CreateMempool(dispatcher *dp.Dispatcher, engine *consensus.ConsensusEngine) *Mempool {
+func CreateMempool(dispatcher *dp.Dispatcher, engine SyncChecker) *Mempool {
+	maxNumTxs := viper.GetInt(common.CfgMempoolMaxNumTxs)
+	if maxNumTxs <= 0 {
+		maxNumTxs = MaxMempoolTxCount
+	}
+	maxNumTxsPerAccount := viper.GetInt(common.CfgMempoolMaxNumTxsPerAccount)
+	maxBytes := viper.GetInt(common.CfgMempoolMaxBytes)
+
+	mempoolMaxNumTxsGauge.Update(int64(maxNumTxs))
+	mempoolMaxAccountTxsGauge.Update(int64(maxNumTxsPerAccount))
+	mempoolMaxBytesGauge.Update(int64(maxBytes))
+
+	blockBuilder, err := createBlockBuilder(viper.GetString(common.CfgMempoolBlockBuilder))
+	if err != nil {
+		logger.Fatalf("Failed to create the block builder: %v", err)
+	}
+	minGasPrice, ok := math.ParseBig256(viper.GetString(common.CfgMempoolMinGasPrice))
+	if !ok {
+		logger.Fatalf("Invalid minimum gas price: %v", viper.GetString(common.CfgMempoolMinGasPrice))
+	}
+	localAddresses := make(map[common.Address]bool)
+	for _, address := range viper.GetStringSlice(common.CfgMempoolLocalAddresses) {
+		if !common.IsHexAddress(address) {
+			logger.Fatalf("Invalid local address: %v", address)
+		}
+		localAddresses[common.HexToAddress(address)] = true
+	}
+
+	numPreverifyWorkers := viper.GetInt(common.CfgMempoolNumPreverifyWorkers)
+	if numPreverifyWorkers <= 0 {
+		numPreverifyWorkers = runtime.NumCPU()
+	}
+
+	txBookeepper := createTransactionBookkeeper(defaultMaxNumTxs)
+	if retentionSecs := viper.GetInt64(common.CfgMempoolTxOutcomeRetentionSecs); retentionSecs > 0 {
+		txBookeepper.outcomeRetention = time.Duration(retentionSecs) * time.Second
+	}
+
 	return &Mempool{
 		mutex:            &sync.Mutex{},
 		consensus:        engine,
@@ -161,62 +284,154 @@ func CreateMempool(dispatcher *dp.Dispatcher, engine *consensus.ConsensusEngine)
 		newTxs:           clist.New(),
 		candidateTxs:     pqueue.CreatePriorityQueue(),
 		addressToTxGroup: make(map[common.Address]*mempoolTransactionGroup),
-		txBookeepper:     createTransactionBookkeeper(defaultMaxNumTxs),
-		wg:               &sync.WaitGroup{},
+		txBookeepper:     txBookeepper,
+		insertedTxs:      make(chan common.Bytes, insertedTxsQueueSize),
+		numRejected:      make(map[string]uint64),
+		gossip: newTxGossiper(dispatcher, viper.GetInt(common.CfgMempoolGossipAnnounceThreshold),
+			viper.GetFloat64(common.CfgMempoolPeerTxRateLimit), viper.GetInt(common.CfgMempoolPeerPenaltyThreshold)),
+		futureTxs: createFutureTxQueue(viper.GetInt(common.CfgMempoolMaxNumFutureTxs), maxNumTxsPerAccount,
+			time.Duration(viper.GetInt64(common.CfgMempoolFutureTxTTLSecs))*time.Second),
+		preverifier:  newTxPreverifier(numPreverifyWorkers, viper.GetInt(common.CfgMempoolPreverifyQueueSize)),
+		blockBuilder: blockBuilder,
+		wg:           &sync.WaitGroup{},
+
+		minReplacementFeeBump:     viper.GetInt64(common.CfgMempoolMinReplacementFeeBump),
+		maxReplacementsPerAccount: viper.GetInt(common.CfgMempoolMaxReplacementsPerAccount),
+		numReplacements:           make(map[common.Address]int),
+		maxReplacementsPerBlock:   viper.GetInt(common.CfgMempoolMaxReplacementsPerBlock),
+		maxNumTxs:                 maxNumTxs,
+		maxNumTxsPerAccount:       maxNumTxsPerAccount,
+		maxBytes:                  maxBytes,
+		blockGasLimit:             viper.GetUint64(common.CfgMempoolBlockGasLimit),
+		minGasPrice:               minGasPrice,
+		localAddresses:            localAddresses,
 	}
 }
 
+// InsertedTxs returns a channel notifying the transactions newly inserted into the mempool.
+// Notifications are dropped if the channel is not drained in time.
+func (mp *Mempool) InsertedTxs() chan common.Bytes {
+	return mp.insertedTxs
+}
+
 // SetLedger sets the ledger for the mempool
 func (mp *Mempool) SetLedger(ledger core.Ledger) {
 	mp.ledger = ledger
 }
 
+// SetBlockBuilder replaces the strategy used to select the transactions of the proposed blocks.
+func (mp *Mempool) SetBlockBuilder(blockBuilder BlockBuilder) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	mp.blockBuilder = blockBuilder
+}
+
+// SetJournal enables journaling the accepted transactions to the file at the given path. The journal
+// is replayed once the node has synced. Must be called before Start().
+func (mp *Mempool) SetJournal(journalPath string) {
+	mp.journal = newTxJournal(journalPath)
+}
+
 // InsertTransaction inserts the incoming transaction to mempool (submitted by the clients or relayed from peers)
-func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
+func (mp *Mempool) InsertTransaction(rawTx common.Bytes) (err error) {
+	// Verify the signatures before taking the lock, so that concurrent insertions are verified in parallel
+	preverifyErr := mp.preverifyTx(rawTx)
+
 	mp.mutex.Lock()
 	defer mp.mutex.Unlock()
 
+	defer func() {
+		if err != nil {
+			mp.numRejected[rejectionReason(err)]++
+			mp.recordRejection(rawTx, err)
+		}
+	}()
+
+	if preverifyErr != nil {
+		logger.Debugf("Transaction preverification failed, tx: %v, error: %v", hex.EncodeToString(rawTx), preverifyErr)
+		return preverifyErr
+	}
+
 	if mp.txBookeepper.hasSeen(rawTx) {
 		logger.Debugf("Transaction already seen: %v, hash: 0x%v",
 			hex.EncodeToString(rawTx), getTransactionHash(rawTx))
 		return DuplicateTxError
 	}
 
-	if mp.size >= MaxMempoolTxCount {
-		logger.Debugf("Mempool is full")
-		return errors.New("mempool is full, please submit your transaction again later")
-	}
-
 	var txInfo *core.TxInfo
 	var checkTxRes result.Result
 
 	// Delay tx verification when in fast sync
 	if mp.consensus.HasSynced() {
-		txInfo, checkTxRes = mp.ledger.ScreenTx(rawTx)
+		// A transaction with the same sequence as a pending transaction from the same account
+		// replaces the pending one if it pays enough more
+		txInfo, checkTxRes = mp.ledger.GetTxInfo(rawTx)
 		if !checkTxRes.IsOK() {
-			logger.Debugf("Transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
-			return errors.New(checkTxRes.Message)
+			return TxScreeningError{Code: checkTxRes.Code, Message: checkTxRes.Message}
+		}
+		if !mp.isPriceAcceptable(txInfo) {
+			logger.Debugf("Gas price too low: %v, tx.hash: 0x%v", txInfo.EffectiveGasPrice, getTransactionHash(rawTx))
+			mempoolGasPriceTooLowMeter.Mark(1)
+			return GasPriceTooLowError
+		}
+		if txGroup, ok := mp.addressToTxGroup[txInfo.Address]; ok {
+			if pendingTx := txGroup.FindTx(txInfo.Sequence); pendingTx != nil {
+				if err := mp.replaceTransaction(txGroup, pendingTx, rawTx, txInfo); err != nil {
+					return err
+				}
+				mp.journalTx(rawTx)
+				return nil
+			}
+			if mp.maxNumTxsPerAccount > 0 && txGroup.Size() >= mp.maxNumTxsPerAccount {
+				logger.Debugf("Too many pending transactions from %v, tx.hash: 0x%v", txInfo.Address.Hex(), getTransactionHash(rawTx))
+				mempoolAccountQuotaMeter.Mark(1)
+				return AccountQuotaExceededError
+			}
 		}
 
-		// only record the transactions that passed the screening. This is because that
-		// an invalid transaction could becoume valid later on. For example, assume expected
-		// sequence for an account is 6. The account accidentally submits txA (seq = 7), got rejected.
-		// He then submit txB(seq = 6), and then txA(seq = 7) again. For the second submission, txA
-		// should not be rejected even though it has been submitted earlier.
-		mp.txBookeepper.record(rawTx)
+		// When the mempool is full, the incoming transaction needs to evict transactions which pay less
+		evictedTxs, ok := mp.selectEvictedTxs(rawTx, txInfo)
+		if !ok {
+			logger.Debugf("Mempool is full")
+			mempoolFullMeter.Mark(1)
+			return MempoolFullError
+		}
 
-		txGroup, ok := mp.addressToTxGroup[txInfo.Address]
-		if ok {
-			txGroup.AddTx(rawTx, txInfo)
-			mp.candidateTxs.Remove(txGroup.index) // Need to re-insert txGroup into queue since its priority could change.
-		} else {
-			txGroup = createMempoolTransactionGroup(rawTx, txInfo)
-			mp.addressToTxGroup[txInfo.Address] = txGroup
+		var screenedTxInfo *core.TxInfo
+		screenedTxInfo, checkTxRes = mp.ledger.ScreenTx(rawTx)
+		if !checkTxRes.IsOK() {
+			// Queue the transaction if it only needs to wait for the preceding sequences
+			if isFutureSequence(checkTxRes, txInfo) {
+				if err := mp.queueFutureTx(rawTx, txInfo); err != nil {
+					return err
+				}
+				mp.journalTx(rawTx)
+				return nil
+			}
+			logger.Debugf("Transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
+			return TxScreeningError{Code: checkTxRes.Code, Message: checkTxRes.Message}
 		}
-		mp.candidateTxs.Push(txGroup)
-		logger.Debugf("rawTx: %v, txInfo: %v", hex.EncodeToString(rawTx), txInfo)
-		logger.Infof("Insert tx, tx.hash: 0x%v", getTransactionHash(rawTx))
-		mp.size++
+		if len(evictedTxs) > 0 {
+			// The screened state is rebuilt without the evicted transactions, so the incoming
+			// transaction needs to be screened again on top of it
+			mp.evictTxs(evictedTxs)
+			screenedTxInfo, checkTxRes = mp.ledger.ScreenTx(rawTx)
+			if !checkTxRes.IsOK() {
+				logger.Debugf("Transaction screening failed after eviction, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
+				return TxScreeningError{Code: checkTxRes.Code, Message: checkTxRes.Message}
+			}
+		}
+
+		// only record the transactions that passed the screening (or got queued). This is because
+		// that an invalid transaction could becoume valid later on. For example, assume an account
+		// submits txA without enough balance, got rejected. After the account gets funded, it
+		// submits txA again. For the second submission, txA should not be rejected even though it
+		// has been submitted earlier.
+		mp.txBookeepper.record(rawTx)
+		mp.addPendingTx(rawTx, screenedTxInfo)
+		mp.promoteFutureTxs([]common.Address{screenedTxInfo.Address}, mp.screenTx)
+		mp.updateSizeMetrics()
+		mp.journalTx(rawTx)
 
 		return nil
 	}
@@ -224,15 +439,454 @@ func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
 	return FastsyncSkipTxError
 }
 
+// preverifyTx decodes the transaction and verifies its signatures without holding the mempool lock.
+// The verified signatures are cached, so the screening does not verify them again. The transactions
+// already seen, or which would be skipped anyway during fast sync, are not verified.
+func (mp *Mempool) preverifyTx(rawTx common.Bytes) error {
+	if mp.ledger == nil || !mp.consensus.HasSynced() || mp.txBookeepper.hasSeen(rawTx) {
+		return nil
+	}
+	if res := mp.ledger.PreverifyTx(rawTx); res.IsError() {
+		return TxScreeningError{Code: res.Code, Message: res.Message}
+	}
+	return nil
+}
+
+// replaceTransaction replaces the pending transaction with the given transaction of the same account
+// and sequence, if the new transaction bumps the effective gas price by at least the configured
+// percentage. Since the screened state already includes the effects of the pending transaction, the
+// screened state is reset and all the pending transactions are screened again in their arrival order,
+// with the new transaction taking the place of the replaced one. If the new transaction fails the
+// screening, the replaced transaction is restored. Since screening all the pending transactions is
+// expensive, the number of replacements by an account, and by all the accounts, is limited between
+// two blocks.
+func (mp *Mempool) replaceTransaction(txGroup *mempoolTransactionGroup, pendingTx *mempoolTransaction,
+	rawTx common.Bytes, txInfo *core.TxInfo) error {
+	if !isReplacementPriced(pendingTx.txInfo, txInfo, mp.minReplacementFeeBump) {
+		logger.Debugf("Replacement transaction underpriced, tx.hash: 0x%v, gas price: %v, replaced gas price: %v",
+			getTransactionHash(rawTx), txInfo.EffectiveGasPrice, pendingTx.txInfo.EffectiveGasPrice)
+		return ReplacementUnderpricedError
+	}
+	if mp.maxReplacementsPerAccount > 0 && mp.numReplacements[txInfo.Address] >= mp.maxReplacementsPerAccount {
+		logger.Debugf("Too many replacements from %v, tx.hash: 0x%v", txInfo.Address.Hex(), getTransactionHash(rawTx))
+		mempoolReplacementLimitMeter.Mark(1)
+		return ReplacementLimitExceededError
+	}
+	if mp.maxReplacementsPerBlock > 0 && mp.numBlockReplacements >= mp.maxReplacementsPerBlock {
+		logger.Debugf("Too many replacements since the last block, tx.hash: 0x%v", getTransactionHash(rawTx))
+		mempoolReplacementLimitMeter.Mark(1)
+		return ReplacementBudgetExceededError
+	}
+	mp.numReplacements[txInfo.Address]++ // the failed replacements are as expensive as the successful ones
+	mp.numBlockReplacements++
+
+	replacementTx := createMempoolTransaction(rawTx, txInfo)
+	replacementTx.arrival = pendingTx.arrival
+	txGroup.txs.Remove(pendingTx.index)
+	txGroup.txs.Push(replacementTx)
+
+	invalidTxs, replacementRes := mp.rescreenTxs(replacementTx)
+	if replacementRes.IsError() {
+		logger.Debugf("Replacement transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), replacementRes.Message)
+		txGroup.txs.Remove(replacementTx.index)
+		txGroup.txs.Push(pendingTx)
+		invalidTxs, _ = mp.rescreenTxs(nil)
+		mp.removeInvalidTxs(invalidTxs)
+		return TxScreeningError{Code: replacementRes.Code, Message: replacementRes.Message}
+	}
+
+	mp.txBookeepper.markReplaced(pendingTx.rawTransaction, rawTx)
+	mp.txBookeepper.record(rawTx)
+	mp.sizeInBytes += len(rawTx) - len(pendingTx.rawTransaction)
+	mp.candidateTxs.Remove(txGroup.index) // Need to re-insert txGroup into queue since its priority could change.
+	mp.candidateTxs.Push(txGroup)
+	mp.removeInvalidTxs(invalidTxs)
+	mp.updateSizeMetrics()
+
+	logger.Infof("Replace tx, tx.hash: 0x%v, replaced tx.hash: 0x%v", getTransactionHash(rawTx),
+		getTransactionHash(pendingTx.rawTransaction))
+
+	mp.notifyInsertedTx(rawTx)
+
+	return nil
+}
+
+// isPriceAcceptable returns whether the transaction pays at least the minimum gas price of the node.
+// The transactions of the local accounts are always acceptable.
+func (mp *Mempool) isPriceAcceptable(txInfo *core.TxInfo) bool {
+	if mp.minGasPrice == nil || mp.localAddresses[txInfo.Address] {
+		return true
+	}
+	return txInfo.EffectiveGasPrice.Cmp(mp.minGasPrice) >= 0
+}
+
+// isReplacementPriced returns whether the new transaction bumps the effective gas price of the replaced
+// transaction by at least the given percentage.
+func isReplacementPriced(replacedTxInfo, txInfo *core.TxInfo, minFeeBump int64) bool {
+	oldPrice := replacedTxInfo.EffectiveGasPrice
+	minPrice := new(big.Int).Mul(oldPrice, big.NewInt(100+minFeeBump))
+	minPrice.Div(minPrice, big.NewInt(100))
+	return txInfo.EffectiveGasPrice.Cmp(minPrice) >= 0 && txInfo.EffectiveGasPrice.Cmp(oldPrice) > 0
+}
+
+// rescreenTxs resets the screened state and screens all the pending transactions again in their
+// arrival order. It returns the transactions which are no longer valid, and separately the screening
+// result of the given transaction, which is not included in the invalid transactions.
+func (mp *Mempool) rescreenTxs(target *mempoolTransaction) (invalidTxs []common.Bytes, targetRes result.Result) {
+	targetRes = result.OK
+	if res := mp.ledger.ResetScreenedState(); res.IsError() {
+		logger.Errorf("Failed to reset the screened state: %v", res.Message)
+		return nil, res
+	}
+
+	pendingTxs := []*mempoolTransaction{}
+	for _, txGroupEl := range *mp.candidateTxs.ElementList() {
+		txGroup := txGroupEl.(*mempoolTransactionGroup)
+		for _, txEl := range *txGroup.txs.ElementList() {
+			pendingTxs = append(pendingTxs, txEl.(*mempoolTransaction))
+		}
+	}
+	sort.Slice(pendingTxs, func(i, j int) bool {
+		return pendingTxs[i].arrival < pendingTxs[j].arrival
+	})
+
+	for _, mptx := range pendingTxs {
+		res := mp.screenTx(mptx.rawTransaction)
+		if mptx == target {
+			targetRes = res
+		} else if res.IsError() {
+			invalidTxs = append(invalidTxs, mptx.rawTransaction)
+		}
+	}
+	return invalidTxs, targetRes
+}
+
+// addPendingTx adds the screened transaction to the candidate transactions.
+func (mp *Mempool) addPendingTx(rawTx common.Bytes, txInfo *core.TxInfo) {
+	txGroup, ok := mp.addressToTxGroup[txInfo.Address]
+	if ok {
+		mp.candidateTxs.Remove(txGroup.index) // Need to re-insert txGroup into queue since its priority could change.
+	} else {
+		txGroup = createMempoolTransactionGroup(txInfo.Address)
+		mp.addressToTxGroup[txInfo.Address] = txGroup
+	}
+	mptx := txGroup.AddTx(rawTx, txInfo)
+	mptx.arrival = mp.nextArrival()
+	mp.candidateTxs.Push(txGroup)
+	logger.Debugf("rawTx: %v, txInfo: %v", hex.EncodeToString(rawTx), txInfo)
+	logger.Infof("Insert tx, tx.hash: 0x%v", getTransactionHash(rawTx))
+	mp.size++
+	mp.sizeInBytes += len(rawTx)
+
+	mp.notifyInsertedTx(rawTx)
+}
+
+// isFutureSequence returns whether the screening failed only because the sequence of the transaction
+// is ahead of the next expected sequence of the account.
+func isFutureSequence(res result.Result, txInfo *core.TxInfo) bool {
+	if res.Code != result.CodeInvalidSequence {
+		return false
+	}
+	expectedSequence, ok := res.Info[result.InfoExpectedSequence].(uint64)
+	return ok && txInfo.Sequence > expectedSequence
+}
+
+// queueFutureTx queues the transaction until the preceding sequences of the account arrive.
+func (mp *Mempool) queueFutureTx(rawTx common.Bytes, txInfo *core.TxInfo) error {
+	replaced, err := mp.futureTxs.add(rawTx, txInfo, mp.minReplacementFeeBump)
+	if err != nil {
+		logger.Debugf("Failed to queue future tx, tx.hash: 0x%v, error: %v", getTransactionHash(rawTx), err)
+		return err
+	}
+	mp.txBookeepper.record(rawTx)
+	mp.txBookeepper.markQueued(rawTx)
+	if replaced != nil {
+		mp.txBookeepper.markReplaced(replaced.rawTransaction, rawTx)
+	}
+	mempoolFutureTxsGauge.Update(int64(mp.futureTxs.size))
+
+	logger.Infof("Queue future tx, tx.hash: 0x%v, sequence: %v", getTransactionHash(rawTx), txInfo.Sequence)
+	return nil
+}
+
+// promoteFutureTxs moves the queued transactions of the given accounts into the candidate transactions
+// as long as their sequences follow the pending transactions. The queued transactions which became
+// invalid are dropped.
+func (mp *Mempool) promoteFutureTxs(addresses []common.Address, screenTx func(rawTx common.Bytes) result.Result) {
+	for _, address := range addresses {
+		for {
+			ftx := mp.futureTxs.peek(address)
+			if ftx == nil || !mp.hasRoomFor(ftx.rawTransaction) {
+				break
+			}
+			if txGroup, ok := mp.addressToTxGroup[address]; ok &&
+				mp.maxNumTxsPerAccount > 0 && txGroup.Size() >= mp.maxNumTxsPerAccount {
+				break
+			}
+
+			res := screenTx(ftx.rawTransaction)
+			if isFutureSequence(res, ftx.txInfo) {
+				break // the gap has not closed yet
+			}
+			mp.futureTxs.remove(ftx)
+			if res.IsError() {
+				logger.Debugf("Drop future tx, tx.hash: 0x%v, error: %v", getTransactionHash(ftx.rawTransaction), res.Message)
+				mp.txBookeepper.markDropped(ftx.rawTransaction, TxReasonScreeningFailed, res.Message)
+				continue
+			}
+
+			logger.Infof("Promote future tx, tx.hash: 0x%v", getTransactionHash(ftx.rawTransaction))
+			mp.txBookeepper.markPending(ftx.rawTransaction)
+			mp.addPendingTx(ftx.rawTransaction, ftx.txInfo)
+		}
+	}
+	mempoolFutureTxsGauge.Update(int64(mp.futureTxs.size))
+}
+
+// hasRoomFor returns whether the transaction fits in the mempool without evicting other transactions.
+func (mp *Mempool) hasRoomFor(rawTx common.Bytes) bool {
+	if mp.size >= mp.maxNumTxs {
+		return false
+	}
+	return mp.maxBytes <= 0 || mp.sizeInBytes+len(rawTx) <= mp.maxBytes
+}
+
+// screenTx is the locking counterpart of core.Ledger.ScreenTxUnsafe()
+func (mp *Mempool) screenTx(rawTx common.Bytes) result.Result {
+	_, res := mp.ledger.ScreenTx(rawTx)
+	return res
+}
+
+// removeInvalidTxs removes the transactions which failed the screening and marks them abandoned.
+func (mp *Mempool) removeInvalidTxs(invalidTxs []common.Bytes) {
+	if len(invalidTxs) == 0 {
+		return
+	}
+	for _, rawTx := range invalidTxs {
+		mp.txBookeepper.markDropped(rawTx, TxReasonScreeningFailed, "")
+	}
+	mp.removeTxs(invalidTxs)
+}
+
+// selectEvictedTxs returns the transactions to be evicted to make room for the incoming transaction,
+// and false if the incoming transaction does not fit in the mempool. The transactions are evicted
+// from the tail of the lowest priority transaction groups, and only if the incoming transaction pays
+// a higher effective gas price than the group, or is from a local account. The transactions of the
+// local accounts are never evicted.
+func (mp *Mempool) selectEvictedTxs(rawTx common.Bytes, txInfo *core.TxInfo) ([]*mempoolTransaction, bool) {
+	numTxsToFree := mp.size + 1 - mp.maxNumTxs
+	numBytesToFree := 0
+	if mp.maxBytes > 0 {
+		numBytesToFree = mp.sizeInBytes + len(rawTx) - mp.maxBytes
+	}
+	if numTxsToFree <= 0 && numBytesToFree <= 0 {
+		return nil, true
+	}
+	if mp.maxBytes > 0 && len(rawTx) > mp.maxBytes {
+		return nil, false
+	}
+
+	txGroups := []*mempoolTransactionGroup{}
+	for _, txGroupEl := range *mp.candidateTxs.ElementList() {
+		txGroup := txGroupEl.(*mempoolTransactionGroup)
+		if txGroup.address == txInfo.Address {
+			continue // evicting the transactions of the same account would leave a sequence gap
+		}
+		if mp.localAddresses[txGroup.address] {
+			continue // the transactions of the local accounts are never evicted
+		}
+		txGroups = append(txGroups, txGroup)
+	}
+	sort.Slice(txGroups, func(i, j int) bool {
+		return txGroups[i].Priority().Cmp(txGroups[j].Priority()) < 0
+	})
+
+	// The transactions of the local accounts can evict any other transactions
+	isLocal := mp.localAddresses[txInfo.Address]
+	evictedTxs := []*mempoolTransaction{}
+	for _, txGroup := range txGroups {
+		if !isLocal && txInfo.EffectiveGasPrice.Cmp(txGroup.Priority()) <= 0 {
+			break
+		}
+		groupTxs := []*mempoolTransaction{}
+		for _, txEl := range *txGroup.txs.ElementList() {
+			groupTxs = append(groupTxs, txEl.(*mempoolTransaction))
+		}
+		sort.Slice(groupTxs, func(i, j int) bool {
+			return groupTxs[i].txInfo.Sequence > groupTxs[j].txInfo.Sequence
+		})
+		for _, mptx := range groupTxs {
+			evictedTxs = append(evictedTxs, mptx)
+			numTxsToFree--
+			numBytesToFree -= len(mptx.rawTransaction)
+			if numTxsToFree <= 0 && numBytesToFree <= 0 {
+				return evictedTxs, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// evictTxs removes the given transactions from the mempool and marks them abandoned. Since the
+// screened state includes the effects of the evicted transactions, it is rebuilt from the remaining
+// pending transactions, and the ones which are no longer valid without the evicted transactions, e.g.
+// spent the funds they transferred, are removed as well.
+func (mp *Mempool) evictTxs(evictedTxs []*mempoolTransaction) {
+	rawTxs := []common.Bytes{}
+	for _, mptx := range evictedTxs {
+		logger.Infof("Evict tx, tx.hash: 0x%v", getTransactionHash(mptx.rawTransaction))
+		mp.txBookeepper.markDropped(mptx.rawTransaction, TxReasonEvicted, "")
+		rawTxs = append(rawTxs, mptx.rawTransaction)
+	}
+	mp.removeTxs(rawTxs)
+	mempoolEvictedMeter.Mark(int64(len(rawTxs)))
+	mp.numEvicted += uint64(len(rawTxs))
+
+	invalidTxs, _ := mp.rescreenTxs(nil)
+	mp.removeInvalidTxs(invalidTxs)
+}
+
+func (mp *Mempool) updateSizeMetrics() {
+	mempoolNumTxsGauge.Update(int64(mp.size))
+	mempoolBytesGauge.Update(int64(mp.sizeInBytes))
+}
+
+func (mp *Mempool) nextArrival() uint64 {
+	mp.numArrivals++
+	return mp.numArrivals
+}
+
+func (mp *Mempool) notifyInsertedTx(rawTx common.Bytes) {
+	select {
+	case mp.insertedTxs <- rawTx:
+	default:
+		logger.Debugf("Failed to notify inserted tx, tx.hash: 0x%v", getTransactionHash(rawTx))
+	}
+}
+
 // Start needs to be called when the Mempool starts
 func (mp *Mempool) Start(ctx context.Context) error {
 	c, cancel := context.WithCancel(ctx)
 	mp.ctx = c
 	mp.cancel = cancel
 
+	mp.preverifier.start(mp.ctx, mp.wg)
+
+	if mp.journal != nil {
+		mp.wg.Add(1)
+		go mp.journalLoop()
+	}
+
 	return nil
 }
 
+// journalLoop replays the journal once the node has synced, since the transactions can only be
+// screened against the latest state, and then compacts the journal periodically.
+func (mp *Mempool) journalLoop() {
+	defer mp.wg.Done()
+	defer mp.closeJournal()
+
+	syncCheckTicker := time.NewTicker(journalSyncCheckInterval)
+	for !mp.consensus.HasSynced() {
+		select {
+		case <-mp.ctx.Done():
+			syncCheckTicker.Stop()
+			return
+		case <-syncCheckTicker.C:
+		}
+	}
+	syncCheckTicker.Stop()
+
+	mp.replayJournal()
+
+	compactionTicker := time.NewTicker(time.Duration(viper.GetInt64(common.CfgMempoolJournalCompactionIntervalSecs)) * time.Second)
+	defer compactionTicker.Stop()
+	for {
+		select {
+		case <-mp.ctx.Done():
+			mp.compactJournal()
+			return
+		case <-compactionTicker.C:
+			mp.compactJournal()
+		}
+	}
+}
+
+// replayJournal inserts the transactions recorded in the journal back into the mempool, and
+// broadcasts the ones which are still valid.
+func (mp *Mempool) replayJournal() {
+	rawTxs, err := mp.journal.load()
+	if err != nil {
+		logger.Errorf("Failed to load the mempool journal: %v", err)
+	}
+
+	numReplayed := 0
+	for _, rawTx := range rawTxs {
+		if err := mp.InsertTransaction(rawTx); err != nil {
+			logger.Debugf("Failed to replay tx, tx.hash: 0x%v, error: %v", getTransactionHash(rawTx), err)
+			continue
+		}
+		mp.BroadcastTx(rawTx)
+		numReplayed++
+	}
+	logger.Infof("Replayed %v out of %v transactions from the mempool journal", numReplayed, len(rawTxs))
+
+	mp.compactJournal()
+}
+
+// compactJournal rewrites the journal with the transactions currently in the mempool.
+func (mp *Mempool) compactJournal() {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	pendingTxs := []*mempoolTransaction{}
+	for _, txGroupEl := range *mp.candidateTxs.ElementList() {
+		txGroup := txGroupEl.(*mempoolTransactionGroup)
+		for _, txEl := range *txGroup.txs.ElementList() {
+			pendingTxs = append(pendingTxs, txEl.(*mempoolTransaction))
+		}
+	}
+	sort.Slice(pendingTxs, func(i, j int) bool {
+		return pendingTxs[i].arrival < pendingTxs[j].arrival
+	})
+
+	rawTxs := []common.Bytes{}
+	for _, mptx := range pendingTxs {
+		rawTxs = append(rawTxs, mptx.rawTransaction)
+	}
+	for _, address := range mp.futureTxs.addresses() {
+		for _, ftx := range mp.futureTxs.accountTxs(address) {
+			rawTxs = append(rawTxs, ftx.rawTransaction)
+		}
+	}
+
+	if err := mp.journal.rotate(rawTxs); err != nil {
+		logger.Errorf("Failed to compact the mempool journal: %v", err)
+		return
+	}
+	logger.Debugf("Compacted the mempool journal, %v transactions", len(rawTxs))
+}
+
+// closeJournal closes the journal under the mempool lock, since the insertions write to the
+// journal while holding the lock.
+func (mp *Mempool) closeJournal() {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	if err := mp.journal.close(); err != nil {
+		logger.Warnf("Failed to close the mempool journal: %v", err)
+	}
+}
+
+func (mp *Mempool) journalTx(rawTx common.Bytes) {
+	if mp.journal == nil {
+		return
+	}
+	if err := mp.journal.insert(rawTx); err != nil && err != errNoActiveJournal {
+		logger.Warnf("Failed to journal tx, tx.hash: 0x%v, error: %v", getTransactionHash(rawTx), err)
+	}
+}
+
 // Stop needs to be called when the Mempool stops
 func (mp *Mempool) Stop() {
 	mp.cancel()
@@ -289,6 +943,7 @@ func (mp *Mempool) ReapUnsafe(maxNumTxs int) []common.Bytes {
 		}
 		txGroup := mp.candidateTxs.Pop().(*mempoolTransactionGroup)
 		rawTx, txInfo := txGroup.PopTx()
+		mp.sizeInBytes -= len(rawTx)
 
 		// Check for outdated txs
 		txHash := getTransactionHash(rawTx)
@@ -309,7 +964,92 @@ func (mp *Mempool) ReapUnsafe(maxNumTxs int) []common.Bytes {
 	}
 
 	mp.size -= len(txs)
+	mp.updateSizeMetrics()
+
+	return txs
+}
+
+// ReapBlockTxsUnsafe returns the regular transactions for the next block as selected by the block
+// builder, within the configured block gas limit, and removes them from the candidate pool like
+// ReapUnsafe. The transactions of the local accounts are selected first, and the transactions paying
+// less than the minimum gas price are left out. Caller must call Mempool.Lock() before calling this method.
+func (mp *Mempool) ReapBlockTxsUnsafe(maxNumTxs int) []common.Bytes {
+	txGroups := make([]*mempoolTransactionGroup, 0, mp.candidateTxs.NumElements())
+	accountTxs := make([][]*BlockTxCandidate, 0, mp.candidateTxs.NumElements())
+	localAccountTxs := [][]*BlockTxCandidate{}
+	for _, elem := range *mp.candidateTxs.ElementList() {
+		txGroup := elem.(*mempoolTransactionGroup)
+		candidates := make([]*BlockTxCandidate, 0, txGroup.Size())
+		for _, txEl := range *txGroup.txs.ElementList() {
+			mptx := txEl.(*mempoolTransaction)
+			candidates = append(candidates, &BlockTxCandidate{
+				RawTx:   mptx.rawTransaction,
+				TxInfo:  mptx.txInfo,
+				Arrival: mptx.arrival,
+			})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].TxInfo.Sequence < candidates[j].TxInfo.Sequence
+		})
+		txGroups = append(txGroups, txGroup)
+		if mp.localAddresses[txGroup.address] {
+			localAccountTxs = append(localAccountTxs, candidates)
+			continue
+		}
+		for i, candidate := range candidates {
+			if !mp.isPriceAcceptable(candidate.TxInfo) {
+				candidates = candidates[:i] // the later transactions of the account have to wait
+				break
+			}
+		}
+		accountTxs = append(accountTxs, candidates)
+	}
+
+	selected := mp.blockBuilder.SelectTxs(localAccountTxs, maxNumTxs, mp.blockGasLimit)
+	localGas := uint64(0)
+	for _, tx := range selected {
+		localGas += tx.TxInfo.Gas
+	}
+	if len(selected) < maxNumTxs && (mp.blockGasLimit == 0 || localGas < mp.blockGasLimit) {
+		remainingGas := uint64(0)
+		if mp.blockGasLimit > 0 {
+			remainingGas = mp.blockGasLimit - localGas
+		}
+		selected = append(selected, mp.blockBuilder.SelectTxs(accountTxs, maxNumTxs-len(selected), remainingGas)...)
+	}
 
+	// The selected transactions of each account are its lowest sequence ones
+	numSelected := make(map[common.Address]int)
+	for _, tx := range selected {
+		numSelected[tx.TxInfo.Address]++
+	}
+	for _, txGroup := range txGroups {
+		count := numSelected[txGroup.address]
+		if count == 0 {
+			continue
+		}
+		mp.candidateTxs.Remove(txGroup.GetIndex())
+		for i := 0; i < count; i++ {
+			rawTx, _ := txGroup.PopTx()
+			mp.sizeInBytes -= len(rawTx)
+			mp.size--
+		}
+		if txGroup.IsEmpty() {
+			delete(mp.addressToTxGroup, txGroup.address)
+		} else {
+			mp.candidateTxs.Push(txGroup)
+		}
+	}
+	mp.updateSizeMetrics()
+
+	txs := make([]common.Bytes, 0, len(selected))
+	for _, tx := range selected {
+		// Only include the transactions which have not been removed from the bookkeeper due to timeout
+		if _, exists := mp.txBookeepper.getStatus(getTransactionHash(tx.RawTx)); exists {
+			txs = append(txs, tx.RawTx)
+		}
+		logger.Debugf("Reap tx: %v, txInfo: %v", hex.EncodeToString(tx.RawTx), tx.TxInfo)
+	}
 	return txs
 }
 
@@ -327,12 +1067,15 @@ func (mp *Mempool) Update(committedRawTxs []common.Bytes) {
 func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) {
 	start := time.Now()
 	mp.removeTxs(committedRawTxs)
+	mp.numReplacements = make(map[common.Address]int)
+	mp.numBlockReplacements = 0
 	removeCommittedTxTime := time.Since(start)
 
 	// Remove Txs that have become obsolete.
 	start = time.Now()
 	count := 0
 	invalidTxs := []common.Bytes{}
+	gappedTxs := []*mempoolTransaction{}
 	txGroups := mp.candidateTxs.ElementList()
 	for _, txGroupEl := range *txGroups {
 		txGroup := txGroupEl.(*mempoolTransactionGroup)
@@ -354,7 +1097,11 @@ func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) {
 			checkTxRes := mp.ledger.ScreenTxUnsafe(mempoolTx.rawTransaction)
 			if !checkTxRes.IsOK() {
 				invalidTxs = append(invalidTxs, mempoolTx.rawTransaction)
-				mp.txBookeepper.markAbandoned(mempoolTx.rawTransaction)
+				if isFutureSequence(checkTxRes, mempoolTx.txInfo) {
+					gappedTxs = append(gappedTxs, mempoolTx) // e.g. a preceding transaction has expired
+				} else {
+					mp.txBookeepper.markDropped(mempoolTx.rawTransaction, TxReasonScreeningFailed, checkTxRes.Message)
+				}
 			}
 		}
 	}
@@ -364,6 +1111,19 @@ func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) {
 	mp.removeTxs(invalidTxs)
 	removeInvalidTxTime := time.Since(start)
 
+	// Queue the transactions with sequence gaps, and promote the queued transactions whose gaps have closed
+	for _, mempoolTx := range gappedTxs {
+		if err := mp.queueFutureTx(mempoolTx.rawTransaction, mempoolTx.txInfo); err != nil {
+			mp.txBookeepper.markDropped(mempoolTx.rawTransaction, TxReasonEvicted, err.Error())
+		}
+	}
+	for _, ftx := range mp.futureTxs.removeExpired() {
+		logger.Debugf("Future tx expired, tx.hash: 0x%v", getTransactionHash(ftx.rawTransaction))
+		mp.txBookeepper.markDropped(ftx.rawTransaction, TxReasonExpired, "")
+	}
+	mp.promoteFutureTxs(mp.futureTxs.addresses(), mp.ledger.ScreenTxUnsafe)
+	mp.updateSizeMetrics()
+
 	logger.Debugf("UpdateUnsafe: %d tx screened in %v, removeCommittedTxTime = %v, removed %d obsolete Txs in %v: %v,", count, screenTxTime, removeCommittedTxTime, len(invalidTxs), removeInvalidTxTime, invalidTxs)
 }
 
@@ -377,8 +1137,9 @@ func (mp *Mempool) removeTxs(committedRawTxs []common.Bytes) {
 	elemsTobeRemoved := []pqueue.Element{}
 	for _, elem := range *elementList {
 		txGroup := elem.(*mempoolTransactionGroup)
-		numRemoved := txGroup.RemoveTxs(committedRawTxMap)
+		numRemoved, numBytesRemoved := txGroup.RemoveTxs(committedRawTxMap)
 		mp.size -= numRemoved
+		mp.sizeInBytes -= numBytesRemoved
 		if txGroup.IsEmpty() {
 			delete(mp.addressToTxGroup, txGroup.address)
 			elemsTobeRemoved = append(elemsTobeRemoved, txGroup)
@@ -396,6 +1157,178 @@ func (mp *Mempool) GetTransactionStatus(hash string) (TxStatus, bool) {
 	return mp.txBookeepper.getStatus(hash)
 }
 
+// GetTransactionOutcome returns the reason the transaction left, or was rejected by, the mempool, if
+// it did within the configured retention period.
+func (mp *Mempool) GetTransactionOutcome(hash string) (TxOutcome, bool) {
+	return mp.txBookeepper.getOutcome(hash)
+}
+
+// MarkIncludedTxs records the transactions included in the given block.
+func (mp *Mempool) MarkIncludedTxs(blockHash common.Hash, blockHeight uint64, rawTxs []common.Bytes) {
+	for _, rawTx := range rawTxs {
+		mp.txBookeepper.markIncluded(rawTx, blockHash, blockHeight)
+	}
+}
+
+// MempoolTxDetails describes a transaction in the mempool
+type MempoolTxDetails struct {
+	RawTx             common.Bytes
+	Hash              string
+	Sequence          uint64
+	EffectiveGasPrice *big.Int // which determines the priority of the transaction
+	Status            TxStatus
+	Age               time.Duration
+}
+
+// MempoolStats summarizes the content of the mempool
+type MempoolStats struct {
+	NumPendingTxs       int
+	NumQueuedTxs        int
+	NumAccounts         int
+	SizeInBytes         int
+	MaxNumTxs           int
+	MaxNumTxsPerAccount int
+	MaxBytes            int
+	MinGasPrice         *big.Int // nil if there is no pending transaction
+	MaxGasPrice         *big.Int // nil if there is no pending transaction
+	NumEvicted          uint64
+	NumRejected         map[string]uint64 // map: rejection reason -> number of rejected transactions
+}
+
+// Rejection reasons of the incoming transactions
+const (
+	RejectionReasonDuplicate        = "duplicate"
+	RejectionReasonMempoolFull      = "mempool_full"
+	RejectionReasonAccountQuota     = "account_quota"
+	RejectionReasonUnderpriced      = "replacement_underpriced"
+	RejectionReasonReplacementLimit = "replacement_limit"
+	RejectionReasonFutureTxsFull    = "future_txs_full"
+	RejectionReasonFastsync         = "fastsync"
+	RejectionReasonGasPriceTooLow   = "gas_price_too_low"
+	RejectionReasonInvalid          = "invalid"
+)
+
+// recordRejection records the reason the transaction was rejected, so that it can be queried later.
+func (mp *Mempool) recordRejection(rawTx common.Bytes, err error) {
+	switch e := err.(type) {
+	case TxScreeningError:
+		mp.txBookeepper.markRejected(rawTx, TxReasonScreeningFailed, e.Message)
+	case MempoolError:
+		if e == DuplicateTxError || e == FastsyncSkipTxError {
+			return // the transaction could still be valid
+		}
+		mp.txBookeepper.markRejected(rawTx, TxReasonRejected, e.Error())
+	}
+}
+
+func rejectionReason(err error) string {
+	switch err {
+	case DuplicateTxError:
+		return RejectionReasonDuplicate
+	case MempoolFullError:
+		return RejectionReasonMempoolFull
+	case AccountQuotaExceededError:
+		return RejectionReasonAccountQuota
+	case ReplacementUnderpricedError:
+		return RejectionReasonUnderpriced
+	case ReplacementLimitExceededError, ReplacementBudgetExceededError:
+		return RejectionReasonReplacementLimit
+	case FutureTxQueueFullError:
+		return RejectionReasonFutureTxsFull
+	case FastsyncSkipTxError:
+		return RejectionReasonFastsync
+	case GasPriceTooLowError:
+		return RejectionReasonGasPriceTooLow
+	default:
+		return RejectionReasonInvalid
+	}
+}
+
+// GetContent returns the pending and the queued transactions grouped by account, in the order
+// of their sequences. If address is not nil, only the transactions of the account are returned.
+func (mp *Mempool) GetContent(address *common.Address) (pending, queued map[common.Address][]*MempoolTxDetails) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	pending = make(map[common.Address][]*MempoolTxDetails)
+	for addr, txGroup := range mp.addressToTxGroup {
+		if address != nil && *address != addr {
+			continue
+		}
+		for _, txEl := range *txGroup.txs.ElementList() {
+			mptx := txEl.(*mempoolTransaction)
+			pending[addr] = append(pending[addr], mp.getTxDetails(mptx.rawTransaction, mptx.txInfo))
+		}
+		sortTxDetails(pending[addr])
+	}
+
+	queued = make(map[common.Address][]*MempoolTxDetails)
+	for _, addr := range mp.futureTxs.addresses() {
+		if address != nil && *address != addr {
+			continue
+		}
+		for _, ftx := range mp.futureTxs.accountTxs(addr) {
+			queued[addr] = append(queued[addr], mp.getTxDetails(ftx.rawTransaction, ftx.txInfo))
+		}
+	}
+
+	return pending, queued
+}
+
+func (mp *Mempool) getTxDetails(rawTx common.Bytes, txInfo *core.TxInfo) *MempoolTxDetails {
+	txHash := getTransactionHash(rawTx)
+	details := &MempoolTxDetails{
+		RawTx:             rawTx,
+		Hash:              txHash,
+		Sequence:          txInfo.Sequence,
+		EffectiveGasPrice: txInfo.EffectiveGasPrice,
+	}
+	if record, exists := mp.txBookeepper.getRecord(txHash); exists {
+		details.Status = record.Status
+		details.Age = time.Since(record.CreatedAt)
+	}
+	return details
+}
+
+func sortTxDetails(txs []*MempoolTxDetails) {
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Sequence < txs[j].Sequence
+	})
+}
+
+// GetStats returns the statistics of the mempool.
+func (mp *Mempool) GetStats() *MempoolStats {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	stats := &MempoolStats{
+		NumPendingTxs:       mp.size,
+		NumQueuedTxs:        mp.futureTxs.size,
+		NumAccounts:         len(mp.addressToTxGroup),
+		SizeInBytes:         mp.sizeInBytes,
+		MaxNumTxs:           mp.maxNumTxs,
+		MaxNumTxsPerAccount: mp.maxNumTxsPerAccount,
+		MaxBytes:            mp.maxBytes,
+		NumEvicted:          mp.numEvicted,
+		NumRejected:         make(map[string]uint64),
+	}
+	for reason, count := range mp.numRejected {
+		stats.NumRejected[reason] = count
+	}
+	for _, txGroup := range mp.addressToTxGroup {
+		for _, txEl := range *txGroup.txs.ElementList() {
+			gasPrice := txEl.(*mempoolTransaction).txInfo.EffectiveGasPrice
+			if stats.MinGasPrice == nil || gasPrice.Cmp(stats.MinGasPrice) < 0 {
+				stats.MinGasPrice = gasPrice
+			}
+			if stats.MaxGasPrice == nil || gasPrice.Cmp(stats.MaxGasPrice) > 0 {
+				stats.MaxGasPrice = gasPrice
+			}
+		}
+	}
+	return stats
+}
+
 // GetCandidateTransactions returns all the currently candidate transactions
 func (mp *Mempool) GetCandidateTransactionHashes() []string {
 	mp.mutex.Lock()
@@ -423,11 +1356,16 @@ func (mp *Mempool) Flush() {
 	defer mp.mutex.Unlock()
 
 	mp.txBookeepper.reset()
+	mp.futureTxs.reset()
+	mp.numReplacements = make(map[common.Address]int)
+	mp.numBlockReplacements = 0
 
 	for !mp.candidateTxs.IsEmpty() {
 		mp.candidateTxs.Pop()
 	}
 	mp.size = 0
+	mp.sizeInBytes = 0
+	mp.updateSizeMetrics()
 }
 
 // BroadcastTx broadcast given raw transaction to the network
@@ -440,11 +1378,5 @@ func (mp *Mempool) BroadcastTx(tx common.Bytes) {
 
 // BroadcastTxUnsafe is the non-locking version of BroadcastTx
 func (mp *Mempool) BroadcastTxUnsafe(tx common.Bytes) {
-	data := dp.DataResponse{
-		ChannelID: common.ChannelIDTransaction,
-		Payload:   tx,
-	}
-
-	peerIDs := []string{}
-	mp.dispatcher.SendData(peerIDs, data)
+	mp.gossip.relay(tx, "")
 }
@@ -1,15 +1,15 @@
 package mempool
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 
-	"github.com/spf13/viper"
-
 	"github.com/scripttoken/script/common"
 	dp "github.com/scripttoken/script/dispatcher"
 	"github.com/scripttoken/script/p2p/types"
 	"github.com/scripttoken/script/rlp"
+	"github.com/spf13/viper"
 )
 
 // MempoolMessageHandler handles the messages received over the
@@ -34,7 +34,26 @@ func (mmh *MempoolMessageHandler) GetChannelIDs() []common.ChannelIDEnum {
 
 // EncodeMessage implements the p2p.MessageHandler interface
 func (mmh *MempoolMessageHandler) EncodeMessage(message interface{}) (common.Bytes, error) {
-	return rlp.EncodeToBytes(message)
+	switch message.(type) {
+	case dp.InventoryResponse:
+		return encodeTxGossipMessage(common.MessageIDInvResponse, message)
+	case dp.DataRequest:
+		return encodeTxGossipMessage(common.MessageIDDataRequest, message)
+	default:
+		// The transactions are sent without the message ID for backward compatibility
+		return rlp.EncodeToBytes(message)
+	}
+}
+
+func encodeTxGossipMessage(msgID common.MessageIDEnum, message interface{}) (common.Bytes, error) {
+	var buf bytes.Buffer
+	if err := rlp.Encode(&buf, msgID); err != nil {
+		return nil, err
+	}
+	if err := rlp.Encode(&buf, message); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func Fuzz(data []byte) int {
@@ -47,15 +66,36 @@ func Fuzz(data []byte) int {
 
 // ParseMessage implements the p2p.MessageHandler interface
 func (mmh *MempoolMessageHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
-	var dataResponse dp.DataResponse
-	rlp.DecodeBytes(rawMessageBytes, &dataResponse)
-
-	rawTx := dataResponse.Payload
 	message := types.Message{
 		PeerID:    peerID,
 		ChannelID: channelID,
-		Content:   rawTx,
 	}
+
+	// A transaction is an RLP list, while the announcements and the fetch requests start with the message ID
+	if len(rawMessageBytes) > 0 && rawMessageBytes[0] < 0xc0 {
+		var msgID common.MessageIDEnum
+		if err := rlp.DecodeBytes(rawMessageBytes[:1], &msgID); err != nil {
+			return message, err
+		}
+		switch msgID {
+		case common.MessageIDInvResponse:
+			announcement := dp.InventoryResponse{}
+			err := rlp.DecodeBytes(rawMessageBytes[1:], &announcement)
+			message.Content = announcement
+			return message, err
+		case common.MessageIDDataRequest:
+			request := dp.DataRequest{}
+			err := rlp.DecodeBytes(rawMessageBytes[1:], &request)
+			message.Content = request
+			return message, err
+		default:
+			return message, fmt.Errorf("Unknown transaction message ID: %v", msgID)
+		}
+	}
+
+	var dataResponse dp.DataResponse
+	rlp.DecodeBytes(rawMessageBytes, &dataResponse)
+	message.Content = dataResponse.Payload
 	return message, nil
 }
 
@@ -64,23 +104,94 @@ func (mmh *MempoolMessageHandler) HandleMessage(message types.Message) error {
 	if message.ChannelID != common.ChannelIDTransaction {
 		return fmt.Errorf("Invalid channel for MempoolMessageHandler: %v", message.ChannelID)
 	}
-	rawTx := message.Content.(common.Bytes)
+
+	switch content := message.Content.(type) {
+	case common.Bytes:
+		return mmh.handleTransaction(message.PeerID, content)
+	case dp.InventoryResponse:
+		mmh.handleAnnouncement(message.PeerID, content.Entries)
+	case dp.DataRequest:
+		mmh.handleFetchRequest(message.PeerID, content.Entries)
+	default:
+		return fmt.Errorf("Invalid message content for MempoolMessageHandler: %v", message.Content)
+	}
+	return nil
+}
+
+func (mmh *MempoolMessageHandler) handleTransaction(peerID string, rawTx common.Bytes) error {
+	gossip := mmh.mempool.gossip
+	if !gossip.allow(peerID, 1) {
+		return nil
+	}
 	logger.Debugf("Received gossiped transaction: %v", hex.EncodeToString(rawTx))
+	gossip.markKnown(peerID, getTransactionHash(rawTx))
+
+	// The transaction is inserted by the preverifier workers, which verify its signatures concurrently
+	mmh.mempool.preverifier.submit(func() {
+		mmh.insertGossipedTx(peerID, rawTx)
+	})
+	return nil
+}
 
+func (mmh *MempoolMessageHandler) insertGossipedTx(peerID string, rawTx common.Bytes) {
+	gossip := mmh.mempool.gossip
 	err := mmh.mempool.InsertTransaction(rawTx)
 	if err == DuplicateTxError {
-		return nil
+		return
 	}
 	if err != nil {
-		return err
+		logger.Debugf("Failed to insert gossiped transaction, tx.hash: 0x%v, error: %v", getTransactionHash(rawTx), err)
+		if isInvalidTxError(err) {
+			gossip.penalize(peerID, 1)
+		}
+		return
 	}
 
 	// When using libp2p gossip, we don't need to re-broadcast txs received from other
 	// nodes.
 	p2pOpt := common.P2POptEnum(viper.GetInt(common.CfgP2POpt))
 	if p2pOpt != common.P2POptLibp2p {
-		mmh.mempool.BroadcastTx(rawTx)
+		gossip.relay(rawTx, peerID)
 	}
+}
 
-	return nil
+// handleAnnouncement fetches the announced transactions which have not been seen yet from the peer.
+func (mmh *MempoolMessageHandler) handleAnnouncement(peerID string, txHashes []string) {
+	if len(txHashes) > dp.MaxInventorySize {
+		txHashes = txHashes[:dp.MaxInventorySize]
+	}
+	gossip := mmh.mempool.gossip
+	if !gossip.allow(peerID, len(txHashes)) {
+		return
+	}
+	if !mmh.mempool.consensus.HasSynced() {
+		return // the transactions would be skipped anyway
+	}
+
+	toFetch := gossip.selectTxsToFetch(peerID, txHashes, mmh.mempool.txBookeepper.hasSeenHash)
+	if len(toFetch) == 0 {
+		return
+	}
+	mmh.mempool.dispatcher.GetData([]string{peerID}, dp.DataRequest{
+		ChannelID: common.ChannelIDTransaction,
+		Entries:   toFetch,
+	})
+}
+
+// handleFetchRequest sends the requested transactions to the peer.
+func (mmh *MempoolMessageHandler) handleFetchRequest(peerID string, txHashes []string) {
+	if len(txHashes) > dp.MaxInventorySize {
+		txHashes = txHashes[:dp.MaxInventorySize]
+	}
+	gossip := mmh.mempool.gossip
+	if !gossip.allow(peerID, len(txHashes)) {
+		return
+	}
+
+	for _, rawTx := range gossip.getRecentTxs(txHashes) {
+		mmh.mempool.dispatcher.SendData([]string{peerID}, dp.DataResponse{
+			ChannelID: common.ChannelIDTransaction,
+			Payload:   rawTx,
+		})
+	}
 }
@@ -3,7 +3,9 @@ package mempool
 import (
 	"context"
 	"math/big"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -14,8 +16,10 @@ import (
 	dp "github.com/scripttoken/script/dispatcher"
 	p2psim "github.com/scripttoken/script/p2p/simulation"
 	p2ptypes "github.com/scripttoken/script/p2p/types"
+	p2plmsg "github.com/scripttoken/script/p2pl/messenger"
 	"github.com/scripttoken/script/rlp"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -289,8 +293,10 @@ func TestMempoolUpdateAndInsert(t *testing.T) {
 func TestMempoolBigBatchUpdateAndReaping(t *testing.T) {
 	assert := assert.New(t)
 
-	// Initialize the mempool
+	// Initialize the mempool, the test accounts send many more txs than the per-account cap
 
+	defer viper.Set(common.CfgMempoolMaxNumTxsPerAccount, viper.GetInt(common.CfgMempoolMaxNumTxsPerAccount))
+	viper.Set(common.CfgMempoolMaxNumTxsPerAccount, 0)
 	p2psimnet := p2psim.NewSimnetWithHandler(nil)
 	mempool, _ := newTestMempool("peer0", p2psimnet)
 
@@ -374,9 +380,10 @@ func TestMempoolTransactionGossip(t *testing.T) {
 	tx2 := createTestRawTx("tx2")
 	tx3 := createTestRawTx("tx3")
 
-	assert.Nil(mempool.InsertTransaction(tx1))
-	assert.Nil(mempool.InsertTransaction(tx2))
-	assert.Nil(mempool.InsertTransaction(tx3))
+	for _, tx := range []common.Bytes{tx1, tx2, tx3} {
+		assert.Nil(mempool.InsertTransaction(tx))
+		mempool.BroadcastTx(tx)
+	}
 	assert.Equal(3, mempool.Size())
 	log.Infof(">>> Client submitted tx1, tx2, tx3")
 
@@ -391,31 +398,402 @@ func TestMempoolTransactionGossip(t *testing.T) {
 	}
 }
 
+func TestMempoolReplacementUnderpriced(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	assert.Nil(mempool.InsertTransaction(tx1))
+
+	// The default minimum fee bump is 10%
+	assert.Equal(ReplacementUnderpricedError, mempool.InsertTransaction(createSequenceTestTx("A1", 1, 109)))
+	assert.Equal(ReplacementUnderpricedError, mempool.InsertTransaction(createSequenceTestTx("A1", 1, 50)))
+	assert.Equal([]common.Bytes{tx1}, getPendingRawTxs(mempool))
+}
+
+func TestMempoolReplacementAccepted(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	tx3 := createSequenceTestTx("B1", 1, 100)
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Nil(mempool.InsertTransaction(tx2))
+	assert.Nil(mempool.InsertTransaction(tx3))
+
+	tx1b := createSequenceTestTx("A1", 1, 110)
+	assert.Nil(mempool.InsertTransaction(tx1b))
+	assert.Equal(3, mempool.Size())
+	assert.Equal([]common.Bytes{tx1b, tx2, tx3}, mempool.Reap(-1))
+
+	// The replaced transaction is not accepted again
+	assert.Equal(DuplicateTxError, mempool.InsertTransaction(tx1))
+}
+
+func TestMempoolReplacementFailedScreening(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	ledger.balances[common.HexToAddress("A1")] = 1000
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	tx2 := createSequenceTestTx("A1", 2, 100)
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Nil(mempool.InsertTransaction(tx2))
+
+	// The replacement exceeds the balance of the account, the replaced transaction is restored
+	err := mempool.InsertTransaction(createSequenceTestTxWithValue("A1", 1, 200, 900))
+	assert.NotNil(err)
+	_, isScreeningError := err.(TxScreeningError)
+	assert.True(isScreeningError)
+	assert.Equal([]common.Bytes{tx1, tx2}, getPendingRawTxs(mempool))
+
+	// The screened state includes the restored transactions
+	tx3 := createSequenceTestTx("A1", 3, 100)
+	assert.Nil(mempool.InsertTransaction(tx3))
+	assert.NotNil(mempool.InsertTransaction(createSequenceTestTxWithValue("A1", 4, 100, 701)))
+	assert.Equal([]common.Bytes{tx1, tx2, tx3}, getPendingRawTxs(mempool))
+}
+
+func TestMempoolReplacementDropsInvalidatedTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	ledger.balances[common.HexToAddress("A1")] = 1000
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	tx1 := createSequenceTestTx("A1", 1, 100)
+	tx2 := createSequenceTestTxWithValue("A1", 2, 100, 700)
+	assert.Nil(mempool.InsertTransaction(tx1))
+	assert.Nil(mempool.InsertTransaction(tx2))
+
+	// The replacement itself is valid, but leaves too little balance for the following transaction
+	tx1b := createSequenceTestTxWithValue("A1", 1, 110, 100)
+	assert.Nil(mempool.InsertTransaction(tx1b))
+	assert.Equal([]common.Bytes{tx1b}, getPendingRawTxs(mempool))
+}
+
+func TestMempoolReplacementLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxReplacementsPerAccount, viper.GetInt(common.CfgMempoolMaxReplacementsPerAccount))
+	viper.Set(common.CfgMempoolMaxReplacementsPerAccount, 2)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 100)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 2, 100)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 100)))
+
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 200)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 2, 200)))
+	numScreened := ledger.numScreened
+	assert.Equal(ReplacementLimitExceededError, mempool.InsertTransaction(createSequenceTestTx("A1", 1, 400)))
+	assert.Equal(numScreened, ledger.numScreened) // rejected without screening the pending txs
+
+	// Other accounts are not affected
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 200)))
+
+	// The limit is reset by the next block
+	tx := createSequenceTestTx("B1", 1, 200)
+	ledger.commit(tx)
+	mempool.Update([]common.Bytes{tx})
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 400)))
+}
+
+func TestMempoolReplacementBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxReplacementsPerBlock, viper.GetInt(common.CfgMempoolMaxReplacementsPerBlock))
+	viper.Set(common.CfgMempoolMaxReplacementsPerBlock, 2)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 100)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 100)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("C1", 1, 100)))
+
+	// The budget is shared by all the accounts
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 200)))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 200)))
+	numScreened := ledger.numScreened
+	assert.Equal(ReplacementBudgetExceededError, mempool.InsertTransaction(createSequenceTestTx("C1", 1, 200)))
+	assert.Equal(numScreened, ledger.numScreened) // rejected without screening the pending txs
+	assert.Equal(uint64(1), mempool.GetStats().NumRejected[RejectionReasonReplacementLimit])
+
+	// The budget is reset by the next block
+	tx := createSequenceTestTx("A1", 1, 200)
+	ledger.commit(tx)
+	mempool.Update([]common.Bytes{tx})
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("C1", 1, 200)))
+}
+
+func TestMempoolContent(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	txA1 := createSequenceTestTx("A1", 1, 100)
+	txA2 := createSequenceTestTx("A1", 2, 300)
+	txA4 := createSequenceTestTx("A1", 4, 100)
+	txB1 := createSequenceTestTx("B1", 1, 200)
+	txC3 := createSequenceTestTx("C1", 3, 100)
+	for _, rawTx := range []common.Bytes{txA2, txA1, txA4, txB1, txC3} {
+		assert.Nil(mempool.InsertTransaction(rawTx))
+	}
+	addrA := common.HexToAddress("A1")
+	addrB := common.HexToAddress("B1")
+	addrC := common.HexToAddress("C1")
+
+	// The pending transactions are grouped by account, in the order of their sequences
+	pending, queued := mempool.GetContent(nil)
+	assert.Equal(2, len(pending))
+	assert.Equal(2, len(pending[addrA]))
+	assert.Equal(txA1, pending[addrA][0].RawTx)
+	assert.Equal(uint64(1), pending[addrA][0].Sequence)
+	assert.Equal(getTransactionHash(txA1), pending[addrA][0].Hash)
+	assert.Equal(big.NewInt(100), pending[addrA][0].EffectiveGasPrice)
+	assert.Equal(TxStatusPending, pending[addrA][0].Status)
+	assert.Equal(txA2, pending[addrA][1].RawTx)
+	assert.Equal(1, len(pending[addrB]))
+	assert.Equal(txB1, pending[addrB][0].RawTx)
+
+	// The transactions waiting for the preceding sequences are queued
+	assert.Equal(2, len(queued))
+	assert.Equal(1, len(queued[addrA]))
+	assert.Equal(txA4, queued[addrA][0].RawTx)
+	assert.Equal(TxStatusQueued, queued[addrA][0].Status)
+	assert.Equal(1, len(queued[addrC]))
+	assert.Equal(txC3, queued[addrC][0].RawTx)
+
+	// The content can be restricted to one account
+	pending, queued = mempool.GetContent(&addrA)
+	assert.Equal(1, len(pending))
+	assert.Equal(2, len(pending[addrA]))
+	assert.Equal(1, len(queued))
+	assert.Equal(1, len(queued[addrA]))
+	pending, queued = mempool.GetContent(&addrC)
+	assert.Equal(0, len(pending))
+	assert.Equal(1, len(queued))
+}
+
+func TestMempoolStats(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	stats := mempool.GetStats()
+	assert.Equal(0, stats.NumPendingTxs)
+	assert.Nil(stats.MinGasPrice)
+	assert.Nil(stats.MaxGasPrice)
+	assert.Empty(stats.NumRejected)
+
+	txA1 := createSequenceTestTx("A1", 1, 100)
+	txA2 := createSequenceTestTx("A1", 2, 300)
+	txB1 := createSequenceTestTx("B1", 1, 200)
+	assert.Nil(mempool.InsertTransaction(txA1))
+	assert.Nil(mempool.InsertTransaction(txA2))
+	assert.Nil(mempool.InsertTransaction(txB1))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 4, 100)))
+
+	// Rejected transactions are counted by reason
+	assert.Equal(DuplicateTxError, mempool.InsertTransaction(txA1))
+	assert.Equal(DuplicateTxError, mempool.InsertTransaction(txB1))
+	assert.Equal(ReplacementUnderpricedError, mempool.InsertTransaction(createSequenceTestTx("A1", 1, 105)))
+	assert.NotNil(mempool.InsertTransaction(common.Bytes("invalid transaction")))
+
+	stats = mempool.GetStats()
+	assert.Equal(3, stats.NumPendingTxs)
+	assert.Equal(1, stats.NumQueuedTxs)
+	assert.Equal(2, stats.NumAccounts)
+	assert.Equal(len(txA1)+len(txA2)+len(txB1), stats.SizeInBytes)
+	assert.Equal(big.NewInt(100), stats.MinGasPrice)
+	assert.Equal(big.NewInt(300), stats.MaxGasPrice)
+	assert.Equal(uint64(0), stats.NumEvicted)
+	assert.Equal(map[string]uint64{
+		RejectionReasonDuplicate:   2,
+		RejectionReasonUnderpriced: 1,
+		RejectionReasonInvalid:     1,
+	}, stats.NumRejected)
+
+	// The counters are copied
+	stats.NumRejected[RejectionReasonDuplicate] = 10
+	assert.Equal(uint64(2), mempool.GetStats().NumRejected[RejectionReasonDuplicate])
+}
+
+func TestMempoolEviction(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxNumTxs, viper.GetInt(common.CfgMempoolMaxNumTxs))
+	viper.Set(common.CfgMempoolMaxNumTxs, 3)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	txA1 := createSequenceTestTx("A1", 1, 100)
+	txA2 := createSequenceTestTx("A1", 2, 100)
+	txB1 := createSequenceTestTx("B1", 1, 200)
+	assert.Nil(mempool.InsertTransaction(txA1))
+	assert.Nil(mempool.InsertTransaction(txA2))
+	assert.Nil(mempool.InsertTransaction(txB1))
+
+	// A transaction which does not pay more than the lowest priority group is rejected
+	assert.Equal(MempoolFullError, mempool.InsertTransaction(createSequenceTestTx("C1", 1, 100)))
+
+	// The transactions are evicted from the tail of the lowest priority group
+	txC1 := createSequenceTestTx("C1", 1, 300)
+	assert.Nil(mempool.InsertTransaction(txC1))
+	assert.Equal([]common.Bytes{txA1, txB1, txC1}, getPendingRawTxs(mempool))
+	status, _ := mempool.txBookeepper.getStatus(getTransactionHash(txA2))
+	assert.Equal(TxStatusAbandoned, status)
+}
+
+func TestMempoolEvictedAccountResubmits(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxNumTxs, viper.GetInt(common.CfgMempoolMaxNumTxs))
+	viper.Set(common.CfgMempoolMaxNumTxs, 2)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("A1", 1, 100)))
+	txB1 := createSequenceTestTx("B1", 1, 200)
+	assert.Nil(mempool.InsertTransaction(txB1))
+	txC1 := createSequenceTestTx("C1", 1, 300)
+	assert.Nil(mempool.InsertTransaction(txC1))
+	assert.Equal([]common.Bytes{txB1, txC1}, getPendingRawTxs(mempool))
+
+	// The screened state no longer includes the evicted transaction, so the next sequence of the
+	// account is queued instead of following the evicted one
+	txA2 := createSequenceTestTx("A1", 2, 500)
+	assert.Nil(mempool.InsertTransaction(txA2))
+	assert.Equal([]common.Bytes{txB1, txC1}, getPendingRawTxs(mempool))
+	assert.Equal(1, mempool.futureTxs.size)
+
+	// The account can resubmit the evicted sequence
+	txA1 := createSequenceTestTx("A1", 1, 400)
+	assert.Nil(mempool.InsertTransaction(txA1))
+	assert.Equal([]common.Bytes{txC1, txA1}, getPendingRawTxs(mempool))
+}
+
+func TestMempoolReapBlockTxsLocalAccounts(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+	mempool.SetBlockBuilder(&FIFOBlockBuilder{})
+	mempool.localAddresses[common.HexToAddress("A1")] = true
+	mempool.localAddresses[common.HexToAddress("B1")] = true
+
+	txC1 := createSequenceTestTx("C1", 1, 1000)
+	txA1 := createSequenceTestTx("A1", 1, 10)
+	txB1 := createSequenceTestTx("B1", 1, 100)
+	assert.Nil(mempool.InsertTransaction(txC1))
+	assert.Nil(mempool.InsertTransaction(txA1))
+	assert.Nil(mempool.InsertTransaction(txB1))
+
+	// The transactions of the local accounts come first, in the order of the configured builder
+	mempool.Lock()
+	defer mempool.Unlock()
+	assert.Equal([]common.Bytes{txA1, txB1, txC1}, mempool.ReapBlockTxsUnsafe(10))
+}
+
+func TestMempoolMinGasPrice(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMinGasPrice, viper.GetString(common.CfgMempoolMinGasPrice))
+	defer viper.Set(common.CfgMempoolLocalAddresses, viper.GetStringSlice(common.CfgMempoolLocalAddresses))
+	viper.Set(common.CfgMempoolMinGasPrice, "100")
+	viper.Set(common.CfgMempoolLocalAddresses, []string{common.HexToAddress("A1").Hex()})
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	assert.Equal(GasPriceTooLowError, mempool.InsertTransaction(createSequenceTestTx("B1", 1, 99)))
+	txB1 := createSequenceTestTx("B1", 1, 100)
+	assert.Nil(mempool.InsertTransaction(txB1))
+
+	// The transactions of the local accounts are accepted and included regardless of their price
+	txA1 := createSequenceTestTx("A1", 1, 1)
+	assert.Nil(mempool.InsertTransaction(txA1))
+	mempool.Lock()
+	defer mempool.Unlock()
+	assert.Equal([]common.Bytes{txA1, txB1}, mempool.ReapBlockTxsUnsafe(10))
+}
+
+func TestMempoolLocalTxsNeverEvicted(t *testing.T) {
+	assert := assert.New(t)
+
+	defer viper.Set(common.CfgMempoolMaxNumTxs, viper.GetInt(common.CfgMempoolMaxNumTxs))
+	defer viper.Set(common.CfgMempoolLocalAddresses, viper.GetStringSlice(common.CfgMempoolLocalAddresses))
+	viper.Set(common.CfgMempoolMaxNumTxs, 2)
+	viper.Set(common.CfgMempoolLocalAddresses, []string{common.HexToAddress("A1").Hex()})
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+
+	txA1 := createSequenceTestTx("A1", 1, 1)
+	assert.Nil(mempool.InsertTransaction(txA1))
+	assert.Nil(mempool.InsertTransaction(createSequenceTestTx("B1", 1, 100)))
+
+	// The higher priced transaction evicts the other account instead of the local one
+	txC1 := createSequenceTestTx("C1", 1, 1000)
+	assert.Nil(mempool.InsertTransaction(txC1))
+	assert.Equal([]common.Bytes{txA1, txC1}, getPendingRawTxs(mempool))
+	assert.Equal(MempoolFullError, mempool.InsertTransaction(createSequenceTestTx("D1", 1, 500)))
+
+	// The local transactions can evict any other transactions
+	txA2 := createSequenceTestTx("A1", 2, 1)
+	assert.Nil(mempool.InsertTransaction(txA2))
+	assert.Equal([]common.Bytes{txA1, txA2}, getPendingRawTxs(mempool))
+}
+
 // --------------- Test Utilities --------------- //
 
 func newTestMempool(peerID string, simnet *p2psim.Simnet) (*Mempool, context.Context) {
+	return newTestMempoolWithLedger(peerID, simnet, newTestLedger())
+}
+
+func newTestMempoolWithLedger(peerID string, simnet *p2psim.Simnet, ledger core.Ledger) (*Mempool, context.Context) {
 	ctx := context.Background()
 
 	messenger := simnet.AddEndpoint(peerID)
-	dispatcher := dp.NewDispatcher(messenger, nil)
-	mempool := CreateMempool(dispatcher)
-	mempool.SetLedger(newTestLedger())
+	dispatcher := dp.NewDispatcher(messenger, (*p2plmsg.Messenger)(nil)) // the dispatcher expects a typed nil
+	mempool := CreateMempool(dispatcher, testSyncChecker{synced: true})
+	mempool.SetLedger(ledger)
 	txMsgHandler := CreateMempoolMessageHandler(mempool)
 	messenger.RegisterMessageHandler(txMsgHandler)
 	messenger.Start(ctx)
 	return mempool, ctx
 }
 
+type testSyncChecker struct {
+	synced bool
+}
+
+func (c testSyncChecker) HasSynced() bool {
+	return c.synced
+}
+
 type TestLedger struct {
-	counter               int
 	effectiveGasPriceList []uint64
 	addressList           []string
 	sequenceList          []uint64
 }
 
-func newTestLedger() core.Ledger {
+func newTestLedger() *TestLedger {
 	return &TestLedger{
-		counter: 0,
 		effectiveGasPriceList: []uint64{
 			78,      // tx1
 			234234,  // tx2
@@ -455,19 +833,46 @@ func newTestLedger() core.Ledger {
 	}
 }
 
+// getTxInfo returns the information of the test transaction "txN" (N = 1, 2, ...), or "tx_N"
+// (N = 0, 1, ...). The transactions "tx_N" cycle through the information of "tx1" to "tx10",
+// with increasing sequences.
+func (tl *TestLedger) getTxInfo(rawTx common.Bytes) *core.TxInfo {
+	name := string(rawTx)
+	numTxs := len(tl.effectiveGasPriceList)
+	idx, round := 0, 0
+	if strings.HasPrefix(name, "tx_") {
+		n, _ := strconv.Atoi(name[3:])
+		idx, round = n%numTxs, n/numTxs
+	} else {
+		n, _ := strconv.Atoi(name[2:])
+		idx = (n - 1) % numTxs
+	}
+	return &core.TxInfo{
+		EffectiveGasPrice: new(big.Int).SetUint64(tl.effectiveGasPriceList[idx]),
+		Address:           common.HexToAddress(tl.addressList[idx]),
+		Sequence:          tl.sequenceList[idx] + uint64(round)*10000,
+	}
+}
+
 func (tl *TestLedger) ScreenTxUnsafe(rawTx common.Bytes) result.Result {
 	_, res := tl.ScreenTx(rawTx)
 	return res
 }
 
 func (tl *TestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
-	txInfo := &core.TxInfo{
-		EffectiveGasPrice: new(big.Int).SetUint64(tl.effectiveGasPriceList[tl.counter]),
-		Address:           common.HexToAddress(tl.addressList[tl.counter]),
-		Sequence:          tl.sequenceList[tl.counter],
-	}
-	tl.counter = (tl.counter + 1) % len(tl.effectiveGasPriceList)
-	return txInfo, result.OK
+	return tl.getTxInfo(rawTx), result.OK
+}
+
+func (tl *TestLedger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return tl.getTxInfo(rawTx), result.OK
+}
+
+func (tl *TestLedger) PreverifyTx(rawTx common.Bytes) result.Result {
+	return result.OK
+}
+
+func (tl *TestLedger) ResetScreenedState() result.Result {
+	return result.OK
 }
 
 func (tl *TestLedger) GetCurrentBlock() *core.Block {
@@ -482,7 +887,7 @@ func (tl *TestLedger) ApplyBlockTxs(block *core.Block) result.Result {
 	return result.OK
 }
 
-func (tl *TestLedger) ResetState(height uint64, rootHash common.Hash) result.Result {
+func (tl *TestLedger) ResetState(block *core.Block) result.Result {
 	return result.OK
 }
 
@@ -498,6 +903,10 @@ func (tl *TestLedger) GetGuardianCandidatePool(blockHash common.Hash) (*core.Gua
 	return nil, nil
 }
 
+func (tl *TestLedger) GetEliteEdgeNodePoolOfLastCheckpoint(blockHash common.Hash) (core.EliteEdgeNodePool, error) {
+	return nil, nil
+}
+
 func (tl *TestLedger) PruneState(endHeight uint64) error {
 	return nil
 }
@@ -506,6 +915,144 @@ func (tl *TestLedger) ApplyBlockTxsForChainCorrection(block *core.Block) (common
 	return common.Hash{}, result.Result{}
 }
 
+// testTx is the transaction screened by the SequenceTestLedger
+type testTx struct {
+	From     common.Address
+	Sequence uint64
+	GasPrice *big.Int
+	Gas      uint64
+	Lane     uint8
+	Value    uint64 // spent in addition to the fee
+}
+
+func createSequenceTestTx(from string, sequence uint64, gasPrice int64) common.Bytes {
+	return createSequenceTestTxWithValue(from, sequence, gasPrice, 0)
+}
+
+func createSequenceTestTxWithValue(from string, sequence uint64, gasPrice int64, value uint64) common.Bytes {
+	rawTx, err := rlp.EncodeToBytes(&testTx{
+		From:     common.HexToAddress(from),
+		Sequence: sequence,
+		GasPrice: big.NewInt(gasPrice),
+		Gas:      1,
+		Value:    value,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return rawTx
+}
+
+// SequenceTestLedger screens the test transactions like the ledger does: the sequences of each
+// account have to be spent in order, and an account cannot spend more than its balance. The screened
+// state accumulates the effects of the screened transactions until it is reset.
+type SequenceTestLedger struct {
+	TestLedger
+
+	sequences         map[common.Address]uint64 // map: account -> last sequence in the committed state
+	balances          map[common.Address]uint64 // map: account -> balance, unlimited if not set
+	screenedSequences map[common.Address]uint64
+	screenedSpent     map[common.Address]uint64
+	numScreened       int
+}
+
+func newSequenceTestLedger() *SequenceTestLedger {
+	tl := &SequenceTestLedger{
+		sequences: make(map[common.Address]uint64),
+		balances:  make(map[common.Address]uint64),
+	}
+	tl.ResetScreenedState()
+	return tl
+}
+
+func decodeTestTx(rawTx common.Bytes) (*testTx, result.Result) {
+	tx := &testTx{}
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, result.Error("Error decoding tx: %v", err)
+	}
+	return tx, result.OK
+}
+
+func (tl *SequenceTestLedger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	tx, res := decodeTestTx(rawTx)
+	if res.IsError() {
+		return nil, res
+	}
+	return &core.TxInfo{
+		EffectiveGasPrice: tx.GasPrice,
+		Address:           tx.From,
+		Sequence:          tx.Sequence,
+		Gas:               tx.Gas,
+		Lane:              core.TxLane(tx.Lane),
+	}, result.OK
+}
+
+func (tl *SequenceTestLedger) ScreenTxUnsafe(rawTx common.Bytes) result.Result {
+	_, res := tl.ScreenTx(rawTx)
+	return res
+}
+
+func (tl *SequenceTestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	tl.numScreened++
+	tx, res := decodeTestTx(rawTx)
+	if res.IsError() {
+		return nil, res
+	}
+	seq := tl.screenedSequences[tx.From]
+	if tx.Sequence != seq+1 {
+		return nil, result.Error("Got %v, expected %v", tx.Sequence, seq+1).
+			WithErrorCode(result.CodeInvalidSequence).
+			WithInfo(result.InfoExpectedSequence, seq+1)
+	}
+	cost := tx.GasPrice.Uint64()*tx.Gas + tx.Value
+	if balance, ok := tl.balances[tx.From]; ok && tl.screenedSpent[tx.From]+cost > balance {
+		return nil, result.Error("Insufficient fund").WithErrorCode(result.CodeInsufficientFund)
+	}
+	tl.screenedSequences[tx.From] = tx.Sequence
+	tl.screenedSpent[tx.From] += cost
+	return tl.GetTxInfo(rawTx)
+}
+
+func (tl *SequenceTestLedger) ResetScreenedState() result.Result {
+	tl.screenedSequences = make(map[common.Address]uint64)
+	for address, seq := range tl.sequences {
+		tl.screenedSequences[address] = seq
+	}
+	tl.screenedSpent = make(map[common.Address]uint64)
+	return result.OK
+}
+
+// commit applies the transactions to the committed state, and resets the screened state.
+func (tl *SequenceTestLedger) commit(rawTxs ...common.Bytes) {
+	for _, rawTx := range rawTxs {
+		tx, _ := decodeTestTx(rawTx)
+		tl.sequences[tx.From] = tx.Sequence
+		if balance, ok := tl.balances[tx.From]; ok {
+			tl.balances[tx.From] = balance - tx.GasPrice.Uint64()*tx.Gas - tx.Value
+		}
+	}
+	tl.ResetScreenedState()
+}
+
+// getPendingRawTxs returns the pending transactions in their arrival order.
+func getPendingRawTxs(mempool *Mempool) []common.Bytes {
+	pendingTxs := []*mempoolTransaction{}
+	for _, txGroupEl := range *mempool.candidateTxs.ElementList() {
+		txGroup := txGroupEl.(*mempoolTransactionGroup)
+		for _, txEl := range *txGroup.txs.ElementList() {
+			pendingTxs = append(pendingTxs, txEl.(*mempoolTransaction))
+		}
+	}
+	sort.Slice(pendingTxs, func(i, j int) bool {
+		return pendingTxs[i].arrival < pendingTxs[j].arrival
+	})
+	rawTxs := []common.Bytes{}
+	for _, pendingTx := range pendingTxs {
+		rawTxs = append(rawTxs, pendingTx.rawTransaction)
+	}
+	return rawTxs
+}
+
 type TestNetworkMessageInterceptor struct {
 	lock             *sync.Mutex
 	ReceivedMessages chan p2ptypes.Message
@@ -14,13 +14,24 @@ const defaultMaxNumTxs = uint(200000)
 
 const maxTxLife = 1 * time.Minute
 
-// transactionBookkeeper keeps tracks of recently seen transactions
+// maxNumTxOutcomes is the maximum number of transaction outcomes retained
+const maxNumTxOutcomes = 100000
+
+// defaultTxOutcomeRetention is how long the transaction outcomes are retained if not configured otherwise
+const defaultTxOutcomeRetention = 1 * time.Hour
+
+// transactionBookkeeper keeps tracks of recently seen transactions, and of the outcomes of the
+// transactions which left the mempool
 type transactionBookkeeper struct {
 	mutex *sync.Mutex
 
 	txMap  map[string]*TxRecord // map: transaction hash -> bool
 	txList list.List            // FIFO list of transaction hashes
 
+	outcomeMap       map[string]*list.Element // map: transaction hash -> element of outcomeList
+	outcomeList      list.List                // FIFO list of transaction outcomes
+	outcomeRetention time.Duration
+
 	maxNumTxs uint
 }
 
@@ -39,13 +50,40 @@ type TxStatus int
 const (
 	TxStatusPending TxStatus = iota
 	TxStatusAbandoned
+	TxStatusReplaced
+	TxStatusQueued
+)
+
+// TxTerminalReason describes why a transaction left, or never entered, the mempool
+type TxTerminalReason string
+
+const (
+	TxReasonRejected        TxTerminalReason = "rejected"         // rejected by the mempool policy on insertion
+	TxReasonScreeningFailed TxTerminalReason = "screening_failed" // failed the screening against the ledger state
+	TxReasonEvicted         TxTerminalReason = "evicted"          // evicted to make room for other transactions
+	TxReasonReplaced        TxTerminalReason = "replaced"         // replaced by another transaction with the same sequence
+	TxReasonExpired         TxTerminalReason = "expired"          // not included in a block in time
+	TxReasonIncluded        TxTerminalReason = "included"         // included in a block
 )
 
+// TxOutcome records the terminal reason of a transaction
+type TxOutcome struct {
+	Hash        string
+	Reason      TxTerminalReason
+	Message     string      // the error message, if any
+	ReplacedBy  string      // hash of the replacement transaction, if replaced
+	BlockHash   common.Hash // the block including the transaction, if included
+	BlockHeight uint64
+	RecordedAt  time.Time
+}
+
 func createTransactionBookkeeper(maxNumTxs uint) transactionBookkeeper {
 	return transactionBookkeeper{
-		mutex:     &sync.Mutex{},
-		txMap:     make(map[string]*TxRecord),
-		maxNumTxs: maxNumTxs,
+		mutex:            &sync.Mutex{},
+		txMap:            make(map[string]*TxRecord),
+		outcomeMap:       make(map[string]*list.Element),
+		outcomeRetention: defaultTxOutcomeRetention,
+		maxNumTxs:        maxNumTxs,
 	}
 }
 
@@ -68,6 +106,17 @@ func (tb *transactionBookkeeper) hasSeen(rawTx common.Bytes) bool {
 	return exists
 }
 
+func (tb *transactionBookkeeper) hasSeenHash(txhash string) bool {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	// Remove outdated Tx records
+	tb.removeOutdatedTxsUnsafe()
+
+	_, exists := tb.txMap[txhash]
+	return exists
+}
+
 // getStatus returns a tx status and a boolean of whether the tx is known.
 func (tb *transactionBookkeeper) getStatus(txhash string) (TxStatus, bool) {
 	tb.mutex.Lock()
@@ -84,6 +133,18 @@ func (tb *transactionBookkeeper) getStatus(txhash string) (TxStatus, bool) {
 	return txRecord.Status, true
 }
 
+// getRecord returns a copy of the tx record and a boolean of whether the tx is known.
+func (tb *transactionBookkeeper) getRecord(txhash string) (TxRecord, bool) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	txRecord, exists := tb.txMap[txhash]
+	if !exists {
+		return TxRecord{}, false
+	}
+	return *txRecord, true
+}
+
 func (tb *transactionBookkeeper) removeOutdatedTxsUnsafe() {
 	// Loop and remove all outdated Tx records
 	for {
@@ -100,6 +161,10 @@ func (tb *transactionBookkeeper) removeOutdatedTxsUnsafe() {
 			delete(tb.txMap, txRecord.Hash)
 		}
 		tb.txList.Remove(el)
+
+		if txRecord.Status == TxStatusPending || txRecord.Status == TxStatusQueued {
+			tb.addOutcomeUnsafe(&TxOutcome{Hash: txRecord.Hash, Reason: TxReasonExpired}, false)
+		}
 	}
 }
 
@@ -134,17 +199,129 @@ func (tb *transactionBookkeeper) record(rawTx common.Bytes) bool {
 	return true
 }
 
-func (tb *transactionBookkeeper) markAbandoned(rawTx common.Bytes) {
+// markDropped marks the transaction abandoned, and records the reason it left the mempool.
+func (tb *transactionBookkeeper) markDropped(rawTx common.Bytes, reason TxTerminalReason, message string) {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
 
 	txhash := getTransactionHash(rawTx)
+	tb.addOutcomeUnsafe(&TxOutcome{Hash: txhash, Reason: reason, Message: message}, true)
 	if _, exists := tb.txMap[txhash]; !exists {
 		return
 	}
 	tb.txMap[txhash].Status = TxStatusAbandoned
 }
 
+// markRejected records the reason the transaction was rejected on insertion, unless the outcome of
+// the transaction is already known, e.g. a transaction included earlier and submitted again.
+func (tb *transactionBookkeeper) markRejected(rawTx common.Bytes, reason TxTerminalReason, message string) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	tb.addOutcomeUnsafe(&TxOutcome{Hash: getTransactionHash(rawTx), Reason: reason, Message: message}, false)
+}
+
+// markIncluded records the block including the transaction.
+func (tb *transactionBookkeeper) markIncluded(rawTx common.Bytes, blockHash common.Hash, blockHeight uint64) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	tb.addOutcomeUnsafe(&TxOutcome{
+		Hash:        getTransactionHash(rawTx),
+		Reason:      TxReasonIncluded,
+		BlockHash:   blockHash,
+		BlockHeight: blockHeight,
+	}, true)
+}
+
+func (tb *transactionBookkeeper) markQueued(rawTx common.Bytes) {
+	tb.setStatus(rawTx, TxStatusQueued)
+}
+
+func (tb *transactionBookkeeper) markPending(rawTx common.Bytes) {
+	tb.setStatus(rawTx, TxStatusPending)
+}
+
+func (tb *transactionBookkeeper) setStatus(rawTx common.Bytes, status TxStatus) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	txhash := getTransactionHash(rawTx)
+	if _, exists := tb.txMap[txhash]; !exists {
+		return
+	}
+	tb.txMap[txhash].Status = status
+}
+
+func (tb *transactionBookkeeper) markReplaced(rawTx common.Bytes, replacementRawTx common.Bytes) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	txhash := getTransactionHash(rawTx)
+	tb.addOutcomeUnsafe(&TxOutcome{
+		Hash:       txhash,
+		Reason:     TxReasonReplaced,
+		ReplacedBy: getTransactionHash(replacementRawTx),
+	}, true)
+	if _, exists := tb.txMap[txhash]; !exists {
+		return
+	}
+	tb.txMap[txhash].Status = TxStatusReplaced
+}
+
+// getOutcome returns a copy of the outcome of the transaction and a boolean of whether it is known.
+func (tb *transactionBookkeeper) getOutcome(txhash string) (TxOutcome, bool) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	tb.removeOutdatedTxsUnsafe()
+	tb.removeOutdatedOutcomesUnsafe()
+
+	el, exists := tb.outcomeMap[txhash]
+	if !exists {
+		return TxOutcome{}, false
+	}
+	return *el.Value.(*TxOutcome), true
+}
+
+// addOutcomeUnsafe records the outcome of the transaction. An already known outcome of the
+// transaction is only overwritten if overwrite is true.
+func (tb *transactionBookkeeper) addOutcomeUnsafe(outcome *TxOutcome, overwrite bool) {
+	tb.removeOutdatedOutcomesUnsafe()
+
+	if el, exists := tb.outcomeMap[outcome.Hash]; exists {
+		if !overwrite {
+			return
+		}
+		tb.removeOutcomeUnsafe(el)
+	}
+
+	if tb.outcomeList.Len() >= maxNumTxOutcomes { // remove the oldest outcomes
+		tb.removeOutcomeUnsafe(tb.outcomeList.Front())
+	}
+
+	outcome.RecordedAt = time.Now()
+	tb.outcomeMap[outcome.Hash] = tb.outcomeList.PushBack(outcome)
+}
+
+func (tb *transactionBookkeeper) removeOutdatedOutcomesUnsafe() {
+	for {
+		el := tb.outcomeList.Front()
+		if el == nil {
+			return
+		}
+		if time.Since(el.Value.(*TxOutcome).RecordedAt) <= tb.outcomeRetention {
+			return
+		}
+		tb.removeOutcomeUnsafe(el)
+	}
+}
+
+func (tb *transactionBookkeeper) removeOutcomeUnsafe(el *list.Element) {
+	delete(tb.outcomeMap, el.Value.(*TxOutcome).Hash)
+	tb.outcomeList.Remove(el)
+}
+
 func (tb *transactionBookkeeper) remove(rawTx common.Bytes) {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
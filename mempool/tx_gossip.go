@@ -0,0 +1,309 @@
+package mempool
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/metrics"
+	"github.com/scripttoken/script/common/result"
+	dp "github.com/scripttoken/script/dispatcher"
+)
+
+const (
+	// maxKnownTxsPerPeer is the maximum number of transaction hashes remembered for each peer
+	maxKnownTxsPerPeer = 32768
+
+	// maxRecentTxs is the maximum number of recently relayed transactions kept to serve the fetch requests
+	maxRecentTxs = 4096
+
+	// txFetchTimeout is how long to wait for a fetched transaction before fetching it again
+	txFetchTimeout = 5 * time.Second
+
+	// peerRateLimitBurstSecs is how many seconds worth of messages a peer can send in a burst
+	peerRateLimitBurstSecs = 5
+
+	// peerBanDuration is how long the messages of a peer are ignored once its penalty reaches the threshold
+	peerBanDuration = 10 * time.Minute
+
+	// peerPenaltyDecayInterval is how often the penalty of a peer decreases by one
+	peerPenaltyDecayInterval = 1 * time.Minute
+)
+
+var (
+	txGossipPushedMeter    = metrics.NewRegisteredMeter("mempool/gossip/pushed", nil)
+	txGossipAnnouncedMeter = metrics.NewRegisteredMeter("mempool/gossip/announced", nil)
+	txGossipFetchedMeter   = metrics.NewRegisteredMeter("mempool/gossip/fetched", nil)
+	txGossipPenaltyMeter   = metrics.NewRegisteredMeter("mempool/gossip/penalty", nil)
+)
+
+// knownTxSet is a bounded set of transaction hashes, which forgets the oldest hashes first.
+type knownTxSet struct {
+	hashes  map[string]struct{}
+	order   list.List
+	maxSize int
+}
+
+func newKnownTxSet(maxSize int) *knownTxSet {
+	return &knownTxSet{
+		hashes:  make(map[string]struct{}),
+		maxSize: maxSize,
+	}
+}
+
+func (s *knownTxSet) add(txHash string) {
+	if _, ok := s.hashes[txHash]; ok {
+		return
+	}
+	if s.order.Len() >= s.maxSize {
+		oldest := s.order.Front()
+		delete(s.hashes, oldest.Value.(string))
+		s.order.Remove(oldest)
+	}
+	s.hashes[txHash] = struct{}{}
+	s.order.PushBack(txHash)
+}
+
+func (s *knownTxSet) has(txHash string) bool {
+	_, ok := s.hashes[txHash]
+	return ok
+}
+
+// gossipPeer keeps track of the transactions known to a peer, and of its message rate and misbehavior.
+type gossipPeer struct {
+	knownTxs *knownTxSet
+
+	tokens     float64
+	lastRefill time.Time
+
+	penalty     int
+	lastPenalty time.Time
+	bannedUntil time.Time
+}
+
+// txGossiper relays the transactions over the ChannelIDTransaction channel. Transactions up to the
+// announce threshold are pushed to the peers in full, and larger ones are announced by hash for the
+// peers to fetch on demand. A transaction is never sent to a peer which is known to have it.
+type txGossiper struct {
+	mutex      *sync.Mutex
+	dispatcher *dp.Dispatcher
+
+	peers        map[string]*gossipPeer
+	recentTxs    map[string]common.Bytes // map: tx hash -> raw tx, for serving the fetch requests
+	recentTxList list.List
+	requested    map[string]time.Time // map: tx hash -> time of the fetch request
+
+	announceThreshold int     // in bytes
+	rateLimit         float64 // messages per second
+	penaltyThreshold  int
+}
+
+func newTxGossiper(dispatcher *dp.Dispatcher, announceThreshold int, rateLimit float64, penaltyThreshold int) *txGossiper {
+	return &txGossiper{
+		mutex:             &sync.Mutex{},
+		dispatcher:        dispatcher,
+		peers:             make(map[string]*gossipPeer),
+		recentTxs:         make(map[string]common.Bytes),
+		requested:         make(map[string]time.Time),
+		announceThreshold: announceThreshold,
+		rateLimit:         rateLimit,
+		penaltyThreshold:  penaltyThreshold,
+	}
+}
+
+func (g *txGossiper) getPeer(peerID string) *gossipPeer {
+	peer, ok := g.peers[peerID]
+	if !ok {
+		peer = &gossipPeer{
+			knownTxs:   newKnownTxSet(maxKnownTxsPerPeer),
+			tokens:     g.rateLimit * peerRateLimitBurstSecs,
+			lastRefill: time.Now(),
+		}
+		g.peers[peerID] = peer
+	}
+	return peer
+}
+
+// relay sends the transaction to all the peers which do not know it yet. The peer the transaction
+// was received from, if any, is marked as knowing it.
+func (g *txGossiper) relay(rawTx common.Bytes, fromPeerID string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	txHash := getTransactionHash(rawTx)
+	if fromPeerID != "" {
+		g.getPeer(fromPeerID).knownTxs.add(txHash)
+	}
+	g.addRecentTx(txHash, rawTx)
+
+	peerIDs := g.dispatcher.Peers(true) // backward compatibility, only relay to blockchain nodes
+	connected := make(map[string]bool)
+	targetPeerIDs := []string{}
+	for _, peerID := range peerIDs {
+		connected[peerID] = true
+		peer := g.getPeer(peerID)
+		if peer.knownTxs.has(txHash) {
+			continue
+		}
+		peer.knownTxs.add(txHash)
+		targetPeerIDs = append(targetPeerIDs, peerID)
+	}
+	for peerID := range g.peers {
+		if !connected[peerID] && peerID != fromPeerID && !g.dispatcher.PeerExists(peerID) {
+			delete(g.peers, peerID) // the peer has disconnected
+		}
+	}
+
+	if len(targetPeerIDs) == 0 {
+		return
+	}
+	if len(rawTx) <= g.announceThreshold {
+		g.dispatcher.SendData(targetPeerIDs, dp.DataResponse{
+			ChannelID: common.ChannelIDTransaction,
+			Payload:   rawTx,
+		})
+		txGossipPushedMeter.Mark(int64(len(targetPeerIDs)))
+	} else {
+		g.dispatcher.SendInventory(targetPeerIDs, dp.InventoryResponse{
+			ChannelID: common.ChannelIDTransaction,
+			Entries:   []string{txHash},
+		})
+		txGossipAnnouncedMeter.Mark(int64(len(targetPeerIDs)))
+	}
+}
+
+func (g *txGossiper) addRecentTx(txHash string, rawTx common.Bytes) {
+	if _, ok := g.recentTxs[txHash]; ok {
+		return
+	}
+	if g.recentTxList.Len() >= maxRecentTxs {
+		oldest := g.recentTxList.Front()
+		delete(g.recentTxs, oldest.Value.(string))
+		g.recentTxList.Remove(oldest)
+	}
+	g.recentTxs[txHash] = rawTx
+	g.recentTxList.PushBack(txHash)
+}
+
+// markKnown marks the transaction as known to the peer.
+func (g *txGossiper) markKnown(peerID string, txHash string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.getPeer(peerID).knownTxs.add(txHash)
+	delete(g.requested, txHash)
+}
+
+// selectTxsToFetch marks the announced transactions as known to the peer, and returns the ones
+// which need to be fetched from it, i.e. which are neither seen nor being fetched from another peer.
+func (g *txGossiper) selectTxsToFetch(peerID string, txHashes []string, hasSeen func(txHash string) bool) []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Now()
+	peer := g.getPeer(peerID)
+	toFetch := []string{}
+	for _, txHash := range txHashes {
+		peer.knownTxs.add(txHash)
+		if hasSeen(txHash) {
+			continue
+		}
+		if requestedAt, ok := g.requested[txHash]; ok && now.Sub(requestedAt) < txFetchTimeout {
+			continue
+		}
+		g.requested[txHash] = now
+		toFetch = append(toFetch, txHash)
+	}
+	for txHash, requestedAt := range g.requested {
+		if now.Sub(requestedAt) >= txFetchTimeout {
+			delete(g.requested, txHash)
+		}
+	}
+	txGossipFetchedMeter.Mark(int64(len(toFetch)))
+	return toFetch
+}
+
+// getRecentTxs returns the recently relayed transactions requested by the peer.
+func (g *txGossiper) getRecentTxs(txHashes []string) []common.Bytes {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	rawTxs := []common.Bytes{}
+	for _, txHash := range txHashes {
+		if rawTx, ok := g.recentTxs[txHash]; ok {
+			rawTxs = append(rawTxs, rawTx)
+		}
+	}
+	return rawTxs
+}
+
+// allow returns whether the given number of messages from the peer should be processed. Peers
+// exceeding the rate limit are penalized.
+func (g *txGossiper) allow(peerID string, numMessages int) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	peer := g.getPeer(peerID)
+	now := time.Now()
+	if now.Before(peer.bannedUntil) {
+		return false
+	}
+
+	maxTokens := g.rateLimit * peerRateLimitBurstSecs
+	peer.tokens += now.Sub(peer.lastRefill).Seconds() * g.rateLimit
+	if peer.tokens > maxTokens {
+		peer.tokens = maxTokens
+	}
+	peer.lastRefill = now
+
+	if peer.tokens < float64(numMessages) {
+		logger.Debugf("Peer %v exceeded the transaction rate limit", peerID)
+		g.penalizeUnsafe(peerID, peer, 1)
+		return false
+	}
+	peer.tokens -= float64(numMessages)
+	return true
+}
+
+// penalize adds to the penalty of the peer, and bans the peer once the penalty reaches the threshold.
+func (g *txGossiper) penalize(peerID string, penalty int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.penalizeUnsafe(peerID, g.getPeer(peerID), penalty)
+}
+
+func (g *txGossiper) penalizeUnsafe(peerID string, peer *gossipPeer, penalty int) {
+	now := time.Now()
+	if !peer.lastPenalty.IsZero() {
+		peer.penalty -= int(now.Sub(peer.lastPenalty) / peerPenaltyDecayInterval)
+		if peer.penalty < 0 {
+			peer.penalty = 0
+		}
+	}
+	peer.penalty += penalty
+	peer.lastPenalty = now
+	txGossipPenaltyMeter.Mark(int64(penalty))
+
+	if peer.penalty >= g.penaltyThreshold {
+		logger.Warnf("Ignore the transactions from peer %v for %v, penalty: %v", peerID, peerBanDuration, peer.penalty)
+		peer.bannedUntil = now.Add(peerBanDuration)
+		peer.penalty = 0
+	}
+}
+
+// isInvalidTxError returns whether relaying a transaction rejected with the given error indicates
+// a misbehaving peer. Transactions rejected due to the local mempool policy, or which could have
+// become invalid only recently, e.g. due to a sequence already used, are not counted.
+func isInvalidTxError(err error) bool {
+	screeningErr, ok := err.(TxScreeningError)
+	if !ok {
+		return false
+	}
+	switch screeningErr.Code {
+	case result.CodeInvalidSequence, result.CodeInsufficientFund:
+		return false
+	}
+	return true
+}
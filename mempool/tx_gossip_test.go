@@ -0,0 +1,231 @@
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	dp "github.com/scripttoken/script/dispatcher"
+	p2psim "github.com/scripttoken/script/p2p/simulation"
+	p2ptypes "github.com/scripttoken/script/p2p/types"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxGossipRateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	g := newTxGossiper(nil, 1024, 2, 100)
+
+	// A peer can send a burst of peerRateLimitBurstSecs seconds worth of messages
+	assert.True(g.allow("peer1", 2*peerRateLimitBurstSecs))
+	assert.False(g.allow("peer1", 1))
+	assert.True(g.allow("peer2", 1))
+
+	// The tokens refill at the rate limit
+	g.peers["peer1"].lastRefill = time.Now().Add(-time.Second)
+	assert.True(g.allow("peer1", 2))
+	assert.False(g.allow("peer1", 1))
+
+	// The tokens do not accumulate beyond the burst
+	g.peers["peer1"].lastRefill = time.Now().Add(-time.Hour)
+	assert.False(g.allow("peer1", 2*peerRateLimitBurstSecs+1))
+	assert.Equal(3, g.peers["peer1"].penalty) // every violation is penalized
+}
+
+func TestTxGossipPenaltyBan(t *testing.T) {
+	assert := assert.New(t)
+
+	g := newTxGossiper(nil, 1024, 100, 3)
+
+	g.penalize("peer1", 2)
+	assert.True(g.allow("peer1", 1))
+
+	// The penalty decays over time
+	g.peers["peer1"].lastPenalty = time.Now().Add(-2 * peerPenaltyDecayInterval)
+	g.penalize("peer1", 2)
+	assert.Equal(2, g.peers["peer1"].penalty)
+	assert.True(g.allow("peer1", 1))
+
+	// The peer is banned once the penalty reaches the threshold
+	g.penalize("peer1", 1)
+	assert.False(g.allow("peer1", 1))
+	assert.True(g.allow("peer2", 1))
+
+	g.peers["peer1"].bannedUntil = time.Now().Add(-time.Second)
+	assert.True(g.allow("peer1", 1))
+}
+
+func TestTxGossipPenalizesInvalidTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	ledger := newSequenceTestLedger()
+	mempool, _ := newTestMempoolWithLedger("peer0", p2psim.NewSimnetWithHandler(nil), ledger)
+	mmh := CreateMempoolMessageHandler(mempool)
+
+	// Transactions which could have become invalid only recently are not penalized
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", createSequenceTestTx("A1", 1, 100))))
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", createSequenceTestTx("A1", 1, 100))))
+	ledger.balances[common.HexToAddress("B1")] = 0
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", createSequenceTestTx("B1", 1, 100))))
+	assert.Equal(0, mempool.gossip.peers["peer1"].penalty)
+
+	// Undecodable transactions are
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", common.Bytes("invalid"))))
+	assert.Equal(1, mempool.gossip.peers["peer1"].penalty)
+}
+
+func TestTxGossipPushAndAnnounce(t *testing.T) {
+	assert := assert.New(t)
+
+	interceptor := newTestNetworkMessageInterceptor()
+	p2psimnet := p2psim.NewSimnetWithHandler(interceptor)
+	mempool, ctx := newTestMempool("peer0", p2psimnet)
+	mempool.gossip.announceThreshold = 8
+	p2psimnet.AddEndpoint("peer1")
+	p2psimnet.AddEndpoint("peer2")
+	p2psimnet.Start(ctx)
+
+	// The small transactions are pushed in full
+	smallTx := createTestRawTx("tx1")
+	mempool.BroadcastTx(smallTx)
+	for i := 0; i < 2; i++ {
+		msg, ok := receiveTxGossipTestMessage(interceptor)
+		assert.True(ok)
+		assert.Equal(dp.DataResponse{ChannelID: common.ChannelIDTransaction, Payload: smallTx}, msg.Content)
+	}
+
+	// The large transactions are announced by hash, and only to the peers which do not know them
+	largeTx := createTestRawTx("tx_1234567890")
+	mempool.gossip.markKnown("peer1", getTransactionHash(largeTx))
+	mempool.BroadcastTx(largeTx)
+	msg, ok := receiveTxGossipTestMessage(interceptor)
+	assert.True(ok)
+	assert.Equal(dp.InventoryResponse{ChannelID: common.ChannelIDTransaction, Entries: []string{getTransactionHash(largeTx)}}, msg.Content)
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+
+	// The transactions are not sent twice to the same peer
+	mempool.BroadcastTx(smallTx)
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+}
+
+func TestTxGossipFetch(t *testing.T) {
+	assert := assert.New(t)
+
+	interceptor := newTestNetworkMessageInterceptor()
+	p2psimnet := p2psim.NewSimnetWithHandler(interceptor)
+	mempool, ctx := newTestMempool("peer0", p2psimnet)
+	p2psimnet.AddEndpoint("peer1")
+	p2psimnet.AddEndpoint("peer2")
+	p2psimnet.Start(ctx)
+	mmh := CreateMempoolMessageHandler(mempool)
+
+	// The announced transactions are fetched from the announcing peer, unless already seen
+	seenTx := createTestRawTx("tx1")
+	assert.Nil(mempool.InsertTransaction(seenTx))
+	newTxHash := getTransactionHash(createTestRawTx("tx2"))
+	announcement := dp.InventoryResponse{
+		ChannelID: common.ChannelIDTransaction,
+		Entries:   []string{getTransactionHash(seenTx), newTxHash},
+	}
+	assert.Nil(mmh.HandleMessage(p2ptypes.Message{PeerID: "peer1", ChannelID: common.ChannelIDTransaction, Content: announcement}))
+	msg, ok := receiveTxGossipTestMessage(interceptor)
+	assert.True(ok)
+	assert.Equal(dp.DataRequest{ChannelID: common.ChannelIDTransaction, Entries: []string{newTxHash}}, msg.Content)
+
+	// A transaction being fetched is not fetched again from another peer
+	assert.Nil(mmh.HandleMessage(p2ptypes.Message{PeerID: "peer2", ChannelID: common.ChannelIDTransaction, Content: announcement}))
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+
+	// The fetch requests are served from the recently relayed transactions
+	mempool.gossip.markKnown("peer1", getTransactionHash(seenTx))
+	mempool.gossip.markKnown("peer2", getTransactionHash(seenTx))
+	mempool.BroadcastTx(seenTx)
+	request := dp.DataRequest{
+		ChannelID: common.ChannelIDTransaction,
+		Entries:   []string{getTransactionHash(seenTx), newTxHash},
+	}
+	assert.Nil(mmh.HandleMessage(p2ptypes.Message{PeerID: "peer2", ChannelID: common.ChannelIDTransaction, Content: request}))
+	msg, ok = receiveTxGossipTestMessage(interceptor)
+	assert.True(ok)
+	assert.Equal(dp.DataResponse{ChannelID: common.ChannelIDTransaction, Payload: seenTx}, msg.Content)
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+}
+
+func TestTxGossipMessageEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	mmh := CreateMempoolMessageHandler(nil)
+	for _, content := range []interface{}{
+		dp.InventoryResponse{ChannelID: common.ChannelIDTransaction, Entries: []string{"0x01", "0x02"}},
+		dp.DataRequest{ChannelID: common.ChannelIDTransaction, Entries: []string{"0x03"}},
+	} {
+		raw, err := mmh.EncodeMessage(content)
+		assert.Nil(err)
+		msg, err := mmh.ParseMessage("peer1", common.ChannelIDTransaction, raw)
+		assert.Nil(err)
+		assert.Equal(content, msg.Content)
+	}
+
+	rawTx := createTestRawTx("tx1")
+	raw, err := mmh.EncodeMessage(dp.DataResponse{ChannelID: common.ChannelIDTransaction, Payload: rawTx})
+	assert.Nil(err)
+	msg, err := mmh.ParseMessage("peer1", common.ChannelIDTransaction, raw)
+	assert.Nil(err)
+	assert.Equal(rawTx, msg.Content)
+}
+
+func TestTxGossipRelay(t *testing.T) {
+	assert := assert.New(t)
+
+	interceptor := newTestNetworkMessageInterceptor()
+	p2psimnet := p2psim.NewSimnetWithHandler(interceptor)
+	mempool, ctx := newTestMempool("peer0", p2psimnet)
+	p2psimnet.AddEndpoint("peer1")
+	p2psimnet.AddEndpoint("peer2")
+	p2psimnet.Start(ctx)
+	mmh := CreateMempoolMessageHandler(mempool)
+
+	// The gossiped transactions are relayed to the peers other than the sender
+	tx1 := createTestRawTx("tx1")
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", tx1)))
+	assert.Equal(1, mempool.Size())
+	msg, ok := receiveTxGossipTestMessage(interceptor)
+	assert.True(ok)
+	assert.Equal(dp.DataResponse{ChannelID: common.ChannelIDTransaction, Payload: tx1}, msg.Content)
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+
+	// When using libp2p gossip, the transactions are not relayed
+	defer viper.Set(common.CfgP2POpt, viper.GetInt(common.CfgP2POpt))
+	viper.Set(common.CfgP2POpt, int(common.P2POptLibp2p))
+	assert.Nil(mmh.HandleMessage(newTxGossipTestMessage("peer1", createTestRawTx("tx2"))))
+	assert.Equal(2, mempool.Size())
+	_, ok = receiveTxGossipTestMessage(interceptor)
+	assert.False(ok)
+}
+
+// --------------- Test Utilities --------------- //
+
+func newTxGossipTestMessage(peerID string, rawTx common.Bytes) p2ptypes.Message {
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: common.ChannelIDTransaction,
+		Content:   rawTx,
+	}
+}
+
+// receiveTxGossipTestMessage returns the next message sent over the simulated network, or false
+// if no message is sent in time.
+func receiveTxGossipTestMessage(interceptor *TestNetworkMessageInterceptor) (p2ptypes.Message, bool) {
+	select {
+	case msg := <-interceptor.ReceivedMessages:
+		return msg, true
+	case <-time.After(200 * time.Millisecond):
+		return p2ptypes.Message{}, false
+	}
+}
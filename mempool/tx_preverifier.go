@@ -0,0 +1,72 @@
+package mempool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/scripttoken/script/common/metrics"
+)
+
+var (
+	txPreverifyQueuedGauge  = metrics.NewRegisteredGauge("mempool/preverify/queued", nil)
+	txPreverifyDroppedMeter = metrics.NewRegisteredMeter("mempool/preverify/dropped", nil)
+)
+
+// txPreverifier runs the insertion of the gossiped transactions on a pool of workers. Since the
+// transactions are decoded and their signatures verified before the mempool lock is taken, the
+// transactions received in a burst are verified concurrently instead of one after the other.
+type txPreverifier struct {
+	jobs       chan func()
+	numWorkers int
+	running    int32 // accessed atomically
+}
+
+func newTxPreverifier(numWorkers int, queueSize int) *txPreverifier {
+	return &txPreverifier{
+		jobs:       make(chan func(), queueSize),
+		numWorkers: numWorkers,
+	}
+}
+
+// start launches the workers, which run until the context is cancelled.
+func (p *txPreverifier) start(ctx context.Context, wg *sync.WaitGroup) {
+	atomic.StoreInt32(&p.running, 1)
+	for i := 0; i < p.numWorkers; i++ {
+		wg.Add(1)
+		go p.work(ctx, wg)
+	}
+	go func() {
+		<-ctx.Done()
+		atomic.StoreInt32(&p.running, 0)
+	}()
+}
+
+func (p *txPreverifier) work(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			txPreverifyQueuedGauge.Update(int64(len(p.jobs)))
+			job()
+		}
+	}
+}
+
+// submit queues the job for the workers. The job is run by the caller if the workers are not
+// running, and dropped if the queue is full.
+func (p *txPreverifier) submit(job func()) {
+	if atomic.LoadInt32(&p.running) == 0 {
+		job()
+		return
+	}
+	select {
+	case p.jobs <- job:
+		txPreverifyQueuedGauge.Update(int64(len(p.jobs)))
+	default:
+		logger.Debugf("Transaction preverification queue is full, dropping the transaction")
+		txPreverifyDroppedMeter.Mark(1)
+	}
+}
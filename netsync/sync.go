@@ -140,6 +140,7 @@ func (sm *SyncManager) GetChannelIDs() []common.ChannelIDEnum {
 		common.ChannelIDGuardian,
 		common.ChannelIDEliteEdgeNodeVote,
 		common.ChannelIDAggregatedEliteEdgeNodeVotes,
+		common.ChannelIDDoubleSignProof,
 	}
 }
 
@@ -659,6 +660,24 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 			"peer":            peerID,
 		}).Debug("Received aggregated elite edge node vote")
 		m.handleAggregatedEliteEdgeNodeVotes(vote)
+	case common.ChannelIDDoubleSignProof:
+		proof := &core.DoubleSignProof{}
+		err := rlp.DecodeBytes(data.Payload, proof)
+		if err != nil {
+			m.logger.WithFields(log.Fields{
+				"channelID": data.ChannelID,
+				"payload":   data.Payload,
+				"error":     err,
+				"peerID":    peerID,
+			}).Warn("Failed to decode DataResponse payload")
+			return
+		}
+		m.logger.WithFields(log.Fields{
+			"proof.Offender": proof.Offender.Hex(),
+			"proof.Height":   proof.Height(),
+			"peer":           peerID,
+		}).Debug("Received double sign proof")
+		m.handleDoubleSignProof(proof)
 	case common.ChannelIDHeader:
 		headers := &Headers{}
 		err := rlp.DecodeBytes(data.Payload, headers)
@@ -825,3 +844,7 @@ func (sm *SyncManager) handleEliteEdgeNodeVote(vote *core.EENVote) {
 func (sm *SyncManager) handleAggregatedEliteEdgeNodeVotes(vote *core.AggregatedEENVotes) {
 	sm.PassdownMessage(vote)
 }
+
+func (sm *SyncManager) handleDoubleSignProof(proof *core.DoubleSignProof) {
+	sm.PassdownMessage(proof)
+}
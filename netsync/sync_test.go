@@ -20,6 +20,7 @@ import (
 	"github.com/scripttoken/script/blockchain"
 	"github.com/scripttoken/script/p2p/simulation"
 	"github.com/scripttoken/script/p2p/types"
+	"github.com/scripttoken/script/signer"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -86,7 +87,8 @@ func TestSyncManager(t *testing.T) {
 	valMgr := consensus.NewFixedValidatorManager()
 	db := kvstore.NewKVStore(backend.NewMemDatabase())
 	dispatch := dispatcher.NewDispatcher(net1, nil)
-	consensus := consensus.NewConsensusEngine(privKey, db, initChain, dispatch, valMgr)
+	localSigner, _ := signer.NewLocalSigner(privKey)
+	consensus := consensus.NewConsensusEngine(localSigner, db, initChain, dispatch, valMgr)
 	mockMsgConsumer := NewMockMessageConsumer()
 
 	sm := NewSyncManager(initChain, consensus, net1, nil, dispatch, mockMsgConsumer)
@@ -185,19 +187,20 @@ func NewMockConsensus(chain *blockchain.Chain, lfb *core.ExtendedBlock) *MockCon
 }
 
 // ID() string
-// PrivateKey() *crypto.PrivateKey
+// Signer() Signer
 // GetTip(includePendingBlockingLeaf bool) *ExtendedBlock
 // GetEpoch() uint64
 // GetLedger() Ledger
 // AddMessage(msg interface{})
 // FinalizedBlocks() chan *Block
 // GetLastFinalizedBlock() *ExtendedBlock
+// GetDoubleSignProofs() []*DoubleSignProof
 
 func (c *MockConsensus) ID() string {
 	return ""
 }
 
-func (c *MockConsensus) PrivateKey() *crypto.PrivateKey {
+func (c *MockConsensus) Signer() core.Signer {
 	return nil
 }
 
@@ -221,6 +224,9 @@ func (c *MockConsensus) FinalizedBlocks() chan *core.Block {
 func (c *MockConsensus) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return c.lfb
 }
+func (c *MockConsensus) GetDoubleSignProofs() []*core.DoubleSignProof {
+	return nil
+}
 
 func TestCollectBlocks(t *testing.T) {
 	assert := assert.New(t)
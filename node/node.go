@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"log"
+	"path"
 	"reflect"
 	"sync"
 
@@ -50,6 +51,7 @@ type Node struct {
 type Params struct {
 	ChainID             string
 	PrivateKey          *crypto.PrivateKey
+	Signer              core.Signer
 	Root                *core.Block
 	NetworkOld          p2p.Network
 	Network             p2pl.Network
@@ -58,6 +60,8 @@ type Params struct {
 	SnapshotPath        string
 	ChainImportDirPath  string
 	ChainCorrectionPath string
+	DataPath            string
+	SigningHistoryPath  string
 }
 
 func NewNode(params *Params) *Node {
@@ -65,14 +69,43 @@ func NewNode(params *Params) *Node {
 	chain := blockchain.NewChain(params.ChainID, store, params.Root)
 	params.RollingDB.SetChain(chain)
 
-	validatorManager := consensus.NewRotatingValidatorManager()
+	var validatorManager core.ValidatorManager = consensus.NewRotatingValidatorManager()
+	if viper.GetBool(common.CfgConsensusVRFProposerEnabled) {
+		validatorManager = consensus.NewVRFValidatorManager(validatorManager)
+	}
 	dispatcher := dp.NewDispatcher(params.NetworkOld, params.Network)
-	consensus := consensus.NewConsensusEngine(params.PrivateKey, store, chain, dispatcher, validatorManager)
+	var signingHistory *consensus.SigningHistory
+	if params.SigningHistoryPath != "" {
+		var err error
+		signingHistory, err = consensus.NewSigningHistory(params.SigningHistoryPath)
+		if err != nil {
+			log.Fatalf("Failed to open the signing history: %v, err: %v", params.SigningHistoryPath, err)
+		}
+	}
+	var wal *consensus.WAL
+	if viper.GetBool(common.CfgConsensusWALEnabled) {
+		walPath := path.Join(params.DataPath, "consensus", "wal")
+		var err error
+		wal, err = consensus.NewWAL(walPath, viper.GetInt64(common.CfgConsensusWALMaxSize))
+		if err != nil {
+			log.Fatalf("Failed to open the consensus WAL: %v, err: %v", walPath, err)
+		}
+	}
+	consensus := consensus.NewConsensusEngine(params.Signer, store, chain, dispatcher, validatorManager)
+	if signingHistory != nil {
+		consensus.SetSigningHistory(signingHistory)
+	}
+	if wal != nil {
+		consensus.SetWAL(wal)
+	}
 	reporter := rp.NewReporter(dispatcher, consensus, chain)
 
 	// TODO: check if this is a guardian node
 	syncMgr := netsync.NewSyncManager(chain, consensus, params.NetworkOld, params.Network, dispatcher, consensus, reporter)
 	mempool := mp.CreateMempool(dispatcher, consensus)
+	if viper.GetBool(common.CfgMempoolJournalEnabled) {
+		mempool.SetJournal(path.Join(params.DataPath, "mempool", "journal"))
+	}
 	ledger := ld.NewLedger(params.ChainID, params.RollingDB, params.RollingDB, chain, consensus, validatorManager, mempool)
 
 	validatorManager.SetConsensusEngine(consensus)
@@ -204,9 +204,15 @@ func (se *SimnetEndpoint) Send(id string, message p2ptypes.Message) bool {
 	return true
 }
 
-// Peers returns the IDs of all peers
+// Peers returns the IDs of all peers, i.e. the other endpoints of the network
 func (se *SimnetEndpoint) Peers(skipEdgeNode bool) []string {
-	return []string{}
+	peerIDs := []string{}
+	for _, endpoint := range se.network.Endpoints {
+		if endpoint.ID() != se.ID() {
+			peerIDs = append(peerIDs, endpoint.ID())
+		}
+	}
+	return peerIDs
 }
 
 // PeerURLs returns the URLs of all peers
@@ -216,6 +222,11 @@ func (se *SimnetEndpoint) PeerURLs(skipEdgeNode bool) []string {
 
 // PeerExists indicates if the given peerID is a neighboring peer
 func (se *SimnetEndpoint) PeerExists(peerID string) bool {
+	for _, endpoint := range se.network.Endpoints {
+		if endpoint.ID() == peerID && peerID != se.ID() {
+			return true
+		}
+	}
 	return false
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
 	"github.com/scripttoken/script/ledger/state"
 	"github.com/scripttoken/script/ledger/types"
 	"github.com/scripttoken/script/ledger/vm"
@@ -13,7 +14,11 @@ import (
 // ------------------------------- CallSmartContract -----------------------------------
 
 type CallSmartContractArgs struct {
-	SctxBytes string `json:"sctx_bytes"`
+	SctxBytes     string            `json:"sctx_bytes"`
+	Height        common.JSONUint64 `json:"height"`         // optional, call against the state of the finalized block at the height
+	BlockHash     common.Hash       `json:"block_hash"`     // optional, call against the state of the finalized block with the hash
+	StateOverride StateOverride     `json:"state_override"` // optional, simulate the call against a modified state
+	BlockOverride *BlockOverride    `json:"block_override"` // optional, simulate the call in a modified block context
 }
 
 type CallSmartContractResult struct {
@@ -21,23 +26,21 @@ type CallSmartContractResult struct {
 	ContractAddress common.Address    `json:"contract_address"`
 	GasUsed         common.JSONUint64 `json:"gas_used"`
 	VmError         string            `json:"vm_error"`
+	VmErrorInfo     *VmErrorInfo      `json:"vm_error_info,omitempty"`
 }
 
 // CallSmartContract calls the smart contract. However, calling a smart contract does NOT modify
 // the globally consensus state. It can be used for dry run, or for retrieving info from smart contracts
-// without actually spending gas.
+// without actually spending gas. By default the call is executed against the delivered state. If a
+// height or a block hash is given, it is executed against the state right after that finalized block
+// instead, as long as the state has not been pruned. The state and the block context can further
+// be overridden to simulate the call against a hypothetical state.
 func (t *ScriptRPCService) CallSmartContract(args *CallSmartContractArgs, result *CallSmartContractResult) (err error) {
-	var ledgerState *state.StoreView
-	ledgerState, err = t.ledger.GetDeliveredSnapshot()
+	ledgerState, parentBlockInfo, err := t.getSimulationState(uint64(args.Height), args.BlockHash, args.StateOverride, args.BlockOverride)
 	if err != nil {
 		return err
 	}
 
-	blockHeight := ledgerState.Height() + 1 // the view points to the parent of the current block
-	if blockHeight < common.HeightEnableSmartContract {
-		return fmt.Errorf("Smart contract feature not enabled until block height %v.", common.HeightEnableSmartContract)
-	}
-
 	sctxBytes, err := hex.DecodeString(args.SctxBytes)
 	if err != nil {
 		return err
@@ -52,17 +55,208 @@ func (t *ScriptRPCService) CallSmartContract(args *CallSmartContractArgs, result
 		return fmt.Errorf("Failed to parse SmartContractTx: %v", args.SctxBytes)
 	}
 
-	pb := t.ledger.State().ParentBlock()
-	parentBlockInfo := vm.NewBlockInfo(pb.Height, pb.Timestamp, pb.ChainID)
+	// The overridden state is a throwaway copy, which must not be saved to the node DB.
 	vmRet, contractAddr, gasUsed, vmErr := vm.Execute(parentBlockInfo, sctx, ledgerState)
-	ledgerState.Save()
 
 	result.VmReturn = hex.EncodeToString(vmRet)
 	result.ContractAddress = contractAddr
 	result.GasUsed = common.JSONUint64(gasUsed)
 	if vmErr != nil {
 		result.VmError = vmErr.Error()
+		result.VmErrorInfo = newVmErrorInfo(result.VmError, vmRet)
+	}
+
+	return nil
+}
+
+// ------------------------------- EstimateGas -----------------------------------
+
+type EstimateGasArgs struct {
+	SctxBytes     string            `json:"sctx_bytes"`
+	Height        common.JSONUint64 `json:"height"`         // optional, estimate against the state of the finalized block at the height
+	BlockHash     common.Hash       `json:"block_hash"`     // optional, estimate against the state of the finalized block with the hash
+	StateOverride StateOverride     `json:"state_override"` // optional, estimate against a modified state
+	BlockOverride *BlockOverride    `json:"block_override"` // optional, estimate in a modified block context
+}
+
+type EstimateGasResult struct {
+	EstimatedGas common.JSONUint64 `json:"estimated_gas"`
+	VmReturn     string            `json:"vm_return"`
+	VmError      string            `json:"vm_error"`
+	VmErrorInfo  *VmErrorInfo      `json:"vm_error_info,omitempty"`
+}
+
+// EstimateGas returns the minimum gas limit with which the smart contract transaction executes
+// successfully. The gas limit encoded in the transaction is ignored. If the transaction fails even
+// with the block gas cap, the estimate is left empty and the VM error together with the decoded
+// revert reason, if any, are returned instead.
+func (t *ScriptRPCService) EstimateGas(args *EstimateGasArgs, result *EstimateGasResult) (err error) {
+	sctxBytes, err := hex.DecodeString(args.SctxBytes)
+	if err != nil {
+		return err
+	}
+	tx, err := types.TxFromBytes(sctxBytes)
+	if err != nil {
+		return fmt.Errorf("Failed to parse SmartContractTx, error: %v", err)
+	}
+	sctx, ok := tx.(*types.SmartContractTx)
+	if !ok {
+		return fmt.Errorf("Failed to parse SmartContractTx: %v", args.SctxBytes)
+	}
+
+	ledgerState, parentBlockInfo, err := t.getSimulationState(uint64(args.Height), args.BlockHash, args.StateOverride, args.BlockOverride)
+	if err != nil {
+		return err
+	}
+
+	gasCap := types.GetMaxGasLimit(ledgerState.Height() + 1).Uint64()
+	gas, vmRet, vmErr, err := estimateGas(parentBlockInfo, sctx, ledgerState, gasCap)
+	if err != nil {
+		return err
+	}
+
+	result.VmReturn = hex.EncodeToString(vmRet)
+	if vmErr != nil {
+		result.VmError = vmErr.Error()
+		result.VmErrorInfo = newVmErrorInfo(result.VmError, vmRet)
+		return nil
 	}
+	result.EstimatedGas = common.JSONUint64(gas)
 
 	return nil
 }
+
+// ---------------------------------- Utils ------------------------------------
+
+// VmErrorInfo is the stable code of a VM error, together with the Solidity revert reason or
+// panic code decoded from the return data of a reverted execution.
+type VmErrorInfo struct {
+	Code         vm.ErrorCode    `json:"code"`
+	RevertReason string          `json:"revert_reason,omitempty"`
+	PanicCode    *common.JSONBig `json:"panic_code,omitempty"`
+	PanicReason  string          `json:"panic_reason,omitempty"`
+}
+
+// newVmErrorInfo returns the error info for the VM error with the given message, or nil if the
+// execution succeeded.
+func newVmErrorInfo(vmErr string, vmRet []byte) *VmErrorInfo {
+	failure := vm.DecodeExecutionFailure(vmErr, vmRet)
+	if failure == nil {
+		return nil
+	}
+	info := &VmErrorInfo{
+		Code:         failure.Code,
+		RevertReason: failure.RevertReason,
+	}
+	if failure.PanicCode != nil {
+		info.PanicCode = (*common.JSONBig)(failure.PanicCode)
+		info.PanicReason = vm.PanicReason(failure.PanicCode)
+	}
+	return info
+}
+
+// getExecutionState returns a snapshot of the state to execute a smart contract transaction against,
+// together with the info of its parent block. By default the delivered state is used. A non-empty
+// block hash, or otherwise a non-zero height, selects the state right after that finalized block.
+func (t *ScriptRPCService) getExecutionState(height uint64, blockHash common.Hash) (*state.StoreView, *vm.BlockInfo, error) {
+	var block *core.ExtendedBlock
+	if !blockHash.IsEmpty() {
+		var err error
+		block, err = t.chain.FindBlock(blockHash)
+		if err != nil || !block.Status.IsFinalized() {
+			return nil, nil, fmt.Errorf("finalized block %v not found", blockHash.Hex())
+		}
+	} else if height != 0 {
+		block = t.findFinalizedBlockByHeight(height)
+		if block == nil {
+			return nil, nil, fmt.Errorf("Historical data at height %v is not available on current node", height)
+		}
+	}
+
+	var ledgerState *state.StoreView
+	var parentBlockInfo *vm.BlockInfo
+	if block == nil {
+		var err error
+		ledgerState, err = t.ledger.GetDeliveredSnapshot()
+		if err != nil {
+			return nil, nil, err
+		}
+		pb := t.ledger.State().ParentBlock()
+		parentBlockInfo = vm.NewBlockInfo(pb.Height, pb.Timestamp, pb.ChainID)
+	} else {
+		var err error
+		ledgerState, err = t.getStoreViewOfBlock(block)
+		if err != nil {
+			return nil, nil, err
+		}
+		parentBlockInfo = vm.NewBlockInfo(block.Height, block.Timestamp, block.ChainID)
+	}
+
+	blockHeight := ledgerState.Height() + 1 // the view points to the parent of the current block
+	if blockHeight < common.HeightEnableSmartContract {
+		return nil, nil, fmt.Errorf("Smart contract feature not enabled until block height %v.", common.HeightEnableSmartContract)
+	}
+	return ledgerState, parentBlockInfo, nil
+}
+
+// getSimulationState returns the execution state selected by the height or the block hash, with
+// the state and block context overrides applied.
+func (t *ScriptRPCService) getSimulationState(height uint64, blockHash common.Hash, stateOverride StateOverride,
+	blockOverride *BlockOverride) (*state.StoreView, *vm.BlockInfo, error) {
+	ledgerState, parentBlockInfo, err := t.getExecutionState(height, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := stateOverride.apply(ledgerState); err != nil {
+		return nil, nil, err
+	}
+	parentBlockInfo, err = blockOverride.apply(parentBlockInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ledgerState, parentBlockInfo, nil
+}
+
+// estimateGas binary searches the lowest gas limit, up to gasCap, with which the transaction
+// executes without error. Each attempt runs on a fresh copy of the given state. If the
+// transaction fails with the gas cap, the return value and the error of that run are returned.
+func estimateGas(parentBlockInfo *vm.BlockInfo, sctx *types.SmartContractTx, ledgerState *state.StoreView,
+	gasCap uint64) (gas uint64, vmRet common.Bytes, vmErr error, err error) {
+	execute := func(gasLimit uint64) (common.Bytes, uint64, error, error) {
+		view, err := ledgerState.Copy()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		tx := *sctx
+		tx.GasLimit = gasLimit
+		vmRet, _, gasUsed, vmErr := vm.Execute(parentBlockInfo, &tx, view)
+		return vmRet, gasUsed, vmErr, nil
+	}
+
+	vmRet, gasUsed, vmErr, err := execute(gasCap)
+	if err != nil || vmErr != nil {
+		return 0, vmRet, vmErr, err
+	}
+
+	// The gas used with the cap is the lower bound. With less gas a nested call could run out of gas
+	// without failing the transaction, which changes its outcome. A higher limit might be needed
+	// though, e.g. due to the 63/64 rule for the gas passed on to the nested calls.
+	var lo uint64
+	if gasUsed > 0 {
+		lo = gasUsed - 1
+	}
+	hi := gasCap
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		_, _, failure, err := execute(mid)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		if failure != nil {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, vmRet, nil, nil
+}
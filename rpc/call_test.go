@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSmartContractTx(code string) *types.SmartContractTx {
+	data, _ := hex.DecodeString(code)
+	return &types.SmartContractTx{
+		From: types.TxInput{
+			Address:  common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab"),
+			Coins:    types.NewCoins(0, 0),
+			Sequence: 1,
+		},
+		GasLimit: 21000,
+		GasPrice: big.NewInt(1),
+		Data:     data,
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	require := require.New(t)
+
+	parentBlockInfo := vm.NewBlockInfo(0, big.NewInt(0), "privatenet")
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	gasCap := uint64(10000000)
+
+	// Calls the identity precompile with all the available gas. With less gas the nested call
+	// runs out of gas while the transaction itself still succeeds.
+	sctx := newTestSmartContractTx("600060006000600060006004" + "5af100")
+	gas, _, vmErr, err := estimateGas(parentBlockInfo, sctx, storeView, gasCap)
+	require.Nil(err)
+	require.Nil(vmErr)
+
+	execute := func(gasLimit uint64) (uint64, error) {
+		view, err := storeView.Copy()
+		require.Nil(err)
+		tx := *sctx
+		tx.GasLimit = gasLimit
+		_, _, gasUsed, vmErr := vm.Execute(parentBlockInfo, &tx, view)
+		return gasUsed, vmErr
+	}
+	gasUsedAtCap, vmErr := execute(gasCap)
+	require.Nil(vmErr)
+	gasUsed, vmErr := execute(gas)
+	require.Nil(vmErr)
+	require.Equal(gasUsedAtCap, gasUsed)
+	gasUsed, vmErr = execute(gas - 1)
+	require.True(vmErr != nil || gasUsed != gasUsedAtCap)
+
+	// The state is not modified by the estimation
+	require.Equal(uint64(0), storeView.GetNonce(sctx.From.Address))
+
+	// ASM:
+	// push 0x64, push 0xc, push 0x0, codecopy
+	// push 0x64, push 0x0, revert
+	// followed by the ABI encoding of Error("nope")
+	sctx = newTestSmartContractTx("6064600c600039" + "60646000fd" +
+		"08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000")
+	gas, vmRet, vmErr, err := estimateGas(parentBlockInfo, sctx, storeView, gasCap)
+	require.Nil(err)
+	require.Equal(uint64(0), gas)
+	require.True(vm.IsExecutionReverted(vmErr))
+	reason, ok := vm.UnpackRevertReason(vmRet)
+	require.True(ok)
+	require.Equal("nope", reason)
+
+	// Fails at the gas cap
+	sctx = newTestSmartContractTx(strings.Repeat("5b", 10) + "600056")
+	_, _, vmErr, err = estimateGas(parentBlockInfo, sctx, storeView, gasCap)
+	require.Nil(err)
+	require.Equal(vm.ErrOutOfGas, vmErr)
+}
+
+func TestCallSmartContractHistoricalState(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+
+	// The contract returns its storage slot 0
+	contractAddr := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+	code, _ := hex.DecodeString("60005460005260206000f3")
+	storeView := state.NewStoreView(0, common.Hash{}, db)
+	storeView.CreateAccount(contractAddr)
+	storeView.SetCode(contractAddr, code)
+	storeView.SetState(contractAddr, common.Hash{}, common.BigToHash(big.NewInt(7)))
+	stateRoot := storeView.Save()
+
+	// The state of e1 is available, while the state of e2 has been pruned
+	core.CreateTestBlock("a0", "")
+	e1 := core.CreateTestBlock("e1", "a0")
+	e1.StateHash = stateRoot
+	chain := blockchain.CreateTestChainByBlocks([]string{"e1", "a0", "e2", "e1"})
+	e2 := core.CreateTestBlock("e2", "e1")
+	require.Nil(chain.FinalizePreviousBlocks(e2.Hash()))
+
+	service := &ScriptRPCService{
+		chain:  chain,
+		ledger: ledger.NewLedger("testchain", db, nil, chain, nil, nil, nil),
+	}
+
+	sctx := newTestSmartContractTx("")
+	sctx.To = types.TxOutput{Address: contractAddr}
+	sctx.GasLimit = 100000
+	sctxBytes, err := types.TxToBytes(sctx)
+	require.Nil(err)
+
+	result := &CallSmartContractResult{}
+	err = service.CallSmartContract(&CallSmartContractArgs{
+		SctxBytes: hex.EncodeToString(sctxBytes),
+		Height:    common.JSONUint64(e1.Height),
+	}, result)
+	require.Nil(err)
+	require.Equal("", result.VmError)
+	require.Equal(common.BigToHash(big.NewInt(7)).Hex()[2:], result.VmReturn)
+
+	result = &CallSmartContractResult{}
+	err = service.CallSmartContract(&CallSmartContractArgs{
+		SctxBytes: hex.EncodeToString(sctxBytes),
+		BlockHash: e1.Hash(),
+	}, result)
+	require.Nil(err)
+	require.Equal(common.BigToHash(big.NewInt(7)).Hex()[2:], result.VmReturn)
+
+	// The state overrides are not written to the node DB. The code returns the balance of the contract.
+	numKeys := db.Len()
+	overrideCode := hexutil.Bytes(common.Hex2Bytes("303160005260206000f3"))
+	result = &CallSmartContractResult{}
+	err = service.CallSmartContract(&CallSmartContractArgs{
+		SctxBytes: hex.EncodeToString(sctxBytes),
+		BlockHash: e1.Hash(),
+		StateOverride: StateOverride{
+			contractAddr: AccountOverride{
+				Code:    &overrideCode,
+				Balance: (*common.JSONBig)(big.NewInt(8)),
+			},
+		},
+	}, result)
+	require.Nil(err)
+	require.Equal("", result.VmError)
+	require.Equal(common.BigToHash(big.NewInt(8)).Hex()[2:], result.VmReturn)
+	require.Equal(numKeys, db.Len())
+
+	err = service.CallSmartContract(&CallSmartContractArgs{
+		SctxBytes: hex.EncodeToString(sctxBytes),
+		Height:    common.JSONUint64(e2.Height),
+	}, &CallSmartContractResult{})
+	require.NotNil(err)
+	require.True(strings.HasPrefix(err.Error(), "state pruned"))
+
+	err = service.CallSmartContract(&CallSmartContractArgs{
+		SctxBytes: hex.EncodeToString(sctxBytes),
+		Height:    common.JSONUint64(100),
+	}, &CallSmartContractResult{})
+	require.NotNil(err)
+}
+
+func TestVmErrorInfo(t *testing.T) {
+	require := require.New(t)
+
+	require.Nil(newVmErrorInfo("", nil))
+
+	info := newVmErrorInfo(vm.ErrOutOfGas.Error(), nil)
+	require.Equal(vm.ErrorCodeOutOfGas, info.Code)
+
+	info = newVmErrorInfo("invalid opcode 0xfe", nil)
+	require.Equal(vm.ErrorCodeInvalidOpcode, info.Code)
+
+	info = newVmErrorInfo("stack underflow (0 <=> 2)", nil)
+	require.Equal(vm.ErrorCodeStackUnderflow, info.Code)
+
+	info = newVmErrorInfo("stack limit reached 1024 (1023)", nil)
+	require.Equal(vm.ErrorCodeStackOverflow, info.Code)
+
+	info = newVmErrorInfo("evm: write protection", nil)
+	require.Equal(vm.ErrorCodeWriteProtection, info.Code)
+
+	info = newVmErrorInfo(vm.ErrInsufficientBalance.Error(), nil)
+	require.Equal(vm.ErrorCodeInsufficientBalance, info.Code)
+
+	info = newVmErrorInfo("something unexpected", nil)
+	require.Equal(vm.ErrorCodeUnknown, info.Code)
+
+	// Error("nope")
+	ret, _ := hex.DecodeString("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000")
+	info = newVmErrorInfo("evm: execution reverted", ret)
+	require.Equal(vm.ErrorCodeExecutionReverted, info.Code)
+	require.Equal("nope", info.RevertReason)
+	require.Nil(info.PanicCode)
+
+	// Panic(0x11)
+	ret, _ = hex.DecodeString("4e487b71" +
+		"0000000000000000000000000000000000000000000000000000000000000011")
+	info = newVmErrorInfo("evm: execution reverted", ret)
+	require.Equal(vm.ErrorCodeExecutionReverted, info.Code)
+	require.Equal("", info.RevertReason)
+	require.Equal(big.NewInt(0x11), (*big.Int)(info.PanicCode))
+	require.Equal("arithmetic underflow or overflow", info.PanicReason)
+
+	// Malformed reason
+	info = newVmErrorInfo("evm: execution reverted", ret[:20])
+	require.Equal(vm.ErrorCodeExecutionReverted, info.Code)
+	require.Equal("", info.RevertReason)
+	require.Nil(info.PanicCode)
+}
@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/common/math"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+)
+
+// DebugRPCService serves the "debug" JSON-RPC namespace, which re-executes transactions
+// and calls with a tracer attached to the EVM.
+type DebugRPCService struct {
+	service *ScriptRPCService
+	eth     *EthRPCService
+}
+
+// NewDebugRPCService creates a new instance of DebugRPCService.
+func NewDebugRPCService(service *ScriptRPCService) *DebugRPCService {
+	return &DebugRPCService{
+		service: service,
+		eth:     NewEthRPCService(service),
+	}
+}
+
+const (
+	// debugCallTracer selects the call tracer, which returns the nested call tree instead
+	// of the opcode level struct logs
+	debugCallTracer = "callTracer"
+)
+
+// TraceConfig selects the tracer and its options. By default the struct logger is used.
+type TraceConfig struct {
+	Tracer         string `json:"tracer"`
+	DisableStack   bool   `json:"disableStack"`
+	DisableMemory  bool   `json:"disableMemory"`
+	DisableStorage bool   `json:"disableStorage"`
+	Limit          int    `json:"limit"`
+}
+
+// ExecutionTraceResult is the result of a trace with the struct logger.
+type ExecutionTraceResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes is a single opcode level step of a trace with the struct logger.
+type StructLogRes struct {
+	Pc      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Error   string            `json:"error,omitempty"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// ------------------------------- debug_traceTransaction -----------------------------------
+
+type DebugTraceTransactionArgs struct {
+	Hash   common.Hash
+	Config TraceConfig
+}
+
+func (a *DebugTraceTransactionArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Hash, &a.Config)
+}
+
+// TraceTransaction re-executes a committed smart contract transaction against the state it was
+// originally executed on, i.e. the state of the parent block with the preceding transactions of
+// the block applied, and returns its trace.
+func (d *DebugRPCService) TraceTransaction(args *DebugTraceTransactionArgs, result *interface{}) (err error) {
+	raw, block, found := d.service.chain.FindTxByHash(args.Hash)
+	if !found || !block.Status.IsFinalized() {
+		return fmt.Errorf("transaction %v not found", args.Hash.Hex())
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return err
+	}
+	sctx, ok := tx.(*types.SmartContractTx)
+	if !ok {
+		return fmt.Errorf("transaction %v is not a smart contract transaction", args.Hash.Hex())
+	}
+
+	txHash := crypto.Keccak256Hash(raw)
+	txIndex := -1
+	for i, blockRawTx := range block.Txs {
+		if crypto.Keccak256Hash(blockRawTx) == txHash {
+			txIndex = i
+			break
+		}
+	}
+	if txIndex < 0 {
+		return fmt.Errorf("transaction %v not found in block %v", args.Hash.Hex(), block.Hash().Hex())
+	}
+
+	ledgerState, parentBlock, err := d.service.ledger.ReplayBlockTxs(block.Block, txIndex)
+	if err != nil {
+		return err
+	}
+	parentBlockInfo := vm.NewBlockInfo(parentBlock.Height, parentBlock.Timestamp, parentBlock.ChainID)
+
+	*result, err = traceSmartContractTx(parentBlockInfo, sctx, ledgerState, &args.Config)
+	return err
+}
+
+// ------------------------------- debug_traceCall -----------------------------------
+
+type DebugTraceCallArgs struct {
+	Msg    EthCallMsg
+	Block  ethBlockTag
+	Config TraceConfig
+}
+
+func (a *DebugTraceCallArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Msg, &a.Block, &a.Config)
+}
+
+// TraceCall executes a call the same way as eth_call and returns its trace.
+func (d *DebugRPCService) TraceCall(args *DebugTraceCallArgs, result *interface{}) (err error) {
+	ledgerState, parentBlockInfo, err := d.eth.executionStateAt(args.Block)
+	if err != nil {
+		return err
+	}
+	sctx := args.Msg.toSmartContractTx(ledgerState)
+
+	*result, err = traceSmartContractTx(parentBlockInfo, sctx, ledgerState, &args.Config)
+	return err
+}
+
+// ---------------------------------- Utils ------------------------------------
+
+// traceSmartContractTx executes the transaction on the given state with the tracer selected
+// by the config, and returns the trace result.
+func traceSmartContractTx(parentBlockInfo *vm.BlockInfo, sctx *types.SmartContractTx, ledgerState *state.StoreView,
+	config *TraceConfig) (interface{}, error) {
+	switch config.Tracer {
+	case "":
+		structLogger := vm.NewStructLogger(&vm.LogConfig{
+			DisableStack:   config.DisableStack,
+			DisableMemory:  config.DisableMemory,
+			DisableStorage: config.DisableStorage,
+			Limit:          config.Limit,
+		})
+		vmRet, _, gasUsed, vmErr := vm.ExecuteWithConfig(parentBlockInfo, sctx, ledgerState, vm.Config{
+			Debug:  true,
+			Tracer: structLogger,
+		})
+		return &ExecutionTraceResult{
+			Gas:         gasUsed,
+			Failed:      vmErr != nil,
+			ReturnValue: fmt.Sprintf("%x", vmRet),
+			StructLogs:  formatStructLogs(structLogger.StructLogs()),
+		}, nil
+	case debugCallTracer:
+		tracer := vm.NewCallTracer()
+		_, _, gasUsed, vmErr := vm.ExecuteWithConfig(parentBlockInfo, sctx, ledgerState, vm.Config{
+			Debug:  true,
+			Tracer: tracer,
+		})
+		frame := tracer.Result()
+		if frame == nil {
+			// The execution failed before entering the EVM, e.g. due to insufficient intrinsic gas
+			frame = &vm.CallFrame{
+				Type:  vm.CALL.String(),
+				From:  sctx.From.Address,
+				To:    sctx.To.Address,
+				Input: hexutil.Bytes(sctx.Data),
+			}
+			if vmErr != nil {
+				frame.Error = vmErr.Error()
+			}
+		}
+		frame.GasUsed = hexutil.Uint64(gasUsed) // include the intrinsic gas
+		return frame, nil
+	}
+	return nil, fmt.Errorf("unknown tracer: %v", config.Tracer)
+}
+
+// formatStructLogs formats the struct logs in the format expected by the Ethereum tooling.
+func formatStructLogs(logs []vm.StructLog) []StructLogRes {
+	formatted := make([]StructLogRes, len(logs))
+	for index, trace := range logs {
+		formatted[index] = StructLogRes{
+			Pc:      trace.Pc,
+			Op:      trace.Op.String(),
+			Gas:     trace.Gas,
+			GasCost: trace.GasCost,
+			Depth:   trace.Depth,
+			Error:   trace.ErrorString(),
+		}
+		if trace.Stack != nil {
+			stack := make([]string, len(trace.Stack))
+			for i, value := range trace.Stack {
+				stack[i] = fmt.Sprintf("%x", math.PaddedBigBytes(value, 32))
+			}
+			formatted[index].Stack = stack
+		}
+		if trace.Memory != nil {
+			memory := make([]string, 0, (len(trace.Memory)+31)/32)
+			for i := 0; i+32 <= len(trace.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
+			}
+			formatted[index].Memory = memory
+		}
+		if trace.Storage != nil {
+			storage := make(map[string]string)
+			for key, value := range trace.Storage {
+				storage[fmt.Sprintf("%x", key)] = fmt.Sprintf("%x", value)
+			}
+			formatted[index].Storage = storage
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugTraceParamsDecoding(t *testing.T) {
+	require := require.New(t)
+
+	args := &DebugTraceTransactionArgs{}
+	params := []byte(`["0x39ef1ebdb7e3ecc7e1e9a0a44e0a65c0b4b7be78ee1a0c0d6ad9d2e0f6d7d3b0",{"tracer":"callTracer"}]`)
+	require.Nil(json.Unmarshal(params, args))
+	require.Equal(common.HexToHash("0x39ef1ebdb7e3ecc7e1e9a0a44e0a65c0b4b7be78ee1a0c0d6ad9d2e0f6d7d3b0"), args.Hash)
+	require.Equal(debugCallTracer, args.Config.Tracer)
+
+	callArgs := &DebugTraceCallArgs{}
+	require.Nil(json.Unmarshal([]byte(`[{"data":"0x00"},"latest",{"disableStack":true,"limit":10}]`), callArgs))
+	require.True(callArgs.Config.DisableStack)
+	require.Equal(10, callArgs.Config.Limit)
+	require.Equal("", callArgs.Config.Tracer)
+}
+
+func TestTraceSmartContractTx(t *testing.T) {
+	require := require.New(t)
+
+	// ASM:
+	// push 0x0 (out size), push 0x0 (out offset), push 0x0 (in size), push 0x0 (in offset)
+	// push 0x0 (value)
+	// push 0x4 (identity precompile)
+	// gas
+	// call
+	// stop
+	code, _ := hex.DecodeString("600060006000600060006004" + "5af100")
+
+	newTx := func() *types.SmartContractTx {
+		return &types.SmartContractTx{
+			From: types.TxInput{
+				Address:  common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab"),
+				Coins:    types.NewCoins(0, 0),
+				Sequence: 1,
+			},
+			GasLimit: 100000,
+			GasPrice: big.NewInt(1),
+			Data:     code,
+		}
+	}
+	parentBlockInfo := vm.NewBlockInfo(0, big.NewInt(0), "privatenet")
+
+	// Struct logger
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	res, err := traceSmartContractTx(parentBlockInfo, newTx(), storeView, &TraceConfig{})
+	require.Nil(err)
+	execRes := res.(*ExecutionTraceResult)
+	require.False(execRes.Failed)
+	require.Equal(9, len(execRes.StructLogs))
+	require.Equal("PUSH1", execRes.StructLogs[0].Op)
+	require.Equal("CALL", execRes.StructLogs[7].Op)
+	require.Equal(7, len(execRes.StructLogs[7].Stack))
+	require.Equal("STOP", execRes.StructLogs[8].Op)
+	require.Equal(1, execRes.StructLogs[8].Depth)
+
+	// Struct logger without the stack
+	storeView = state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	res, err = traceSmartContractTx(parentBlockInfo, newTx(), storeView, &TraceConfig{DisableStack: true, Limit: 3})
+	require.Nil(err)
+	execRes = res.(*ExecutionTraceResult)
+	require.Equal(3, len(execRes.StructLogs))
+	require.Nil(execRes.StructLogs[0].Stack)
+
+	// Call tracer
+	storeView = state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	res, err = traceSmartContractTx(parentBlockInfo, newTx(), storeView, &TraceConfig{Tracer: debugCallTracer})
+	require.Nil(err)
+	frame := res.(*vm.CallFrame)
+	require.Equal("CREATE", frame.Type)
+	require.Equal("", frame.Error)
+	require.Equal(1, len(frame.Calls))
+	require.Equal("CALL", frame.Calls[0].Type)
+	require.Equal(common.BytesToAddress([]byte{0x4}), frame.Calls[0].To)
+	require.Equal(0, len(frame.Calls[0].Calls))
+
+	// Unknown tracer
+	_, err = traceSmartContractTx(parentBlockInfo, newTx(), storeView, &TraceConfig{Tracer: "prestateTracer"})
+	require.NotNil(err)
+}
@@ -0,0 +1,914 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+	"github.com/scripttoken/script/rlp"
+	"github.com/scripttoken/script/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+// EthRPCService serves the Ethereum compatible "eth" JSON-RPC namespace, so that
+// standard Ethereum tooling (wallets, web3 libraries, etc) can talk to the node
+// directly. The codec maps method names like "eth_chainId" onto "eth.ChainId".
+type EthRPCService struct {
+	service *ScriptRPCService
+}
+
+// NewEthRPCService creates a new instance of EthRPCService.
+func NewEthRPCService(service *ScriptRPCService) *EthRPCService {
+	return &EthRPCService{
+		service: service,
+	}
+}
+
+const (
+	ethBlockTagLatest    = "latest"
+	ethBlockTagPending   = "pending"
+	ethBlockTagEarliest  = "earliest"
+	ethBlockTagSafe      = "safe"
+	ethBlockTagFinalized = "finalized"
+
+	// ethRevertErrorCode is the error code used by Ethereum clients for reverted calls
+	ethRevertErrorCode = 3
+)
+
+// sha3Uncles of a block without uncles, i.e. Keccak256(RLP([]))
+var ethEmptyUncleHash = common.HexToHash("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347")
+
+// ------------------------------- eth_chainId -----------------------------------
+
+type EthChainIdArgs struct{}
+
+func (e *EthRPCService) ChainId(args *EthChainIdArgs, result *hexutil.Big) (err error) {
+	height := e.service.consensus.GetLastFinalizedBlock().Height
+	chainID := types.MapChainID(e.service.consensus.Chain().ChainID, height)
+	*result = hexutil.Big(*chainID)
+	return nil
+}
+
+// ------------------------------- eth_blockNumber -----------------------------------
+
+type EthBlockNumberArgs struct{}
+
+func (e *EthRPCService) BlockNumber(args *EthBlockNumberArgs, result *hexutil.Uint64) (err error) {
+	*result = hexutil.Uint64(e.service.consensus.GetLastFinalizedBlock().Height)
+	return nil
+}
+
+// ------------------------------- eth_getBalance -----------------------------------
+
+type EthGetBalanceArgs struct {
+	Address common.Address
+	Block   ethBlockTag
+}
+
+func (a *EthGetBalanceArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Address, &a.Block)
+}
+
+func (e *EthRPCService) GetBalance(args *EthGetBalanceArgs, result *hexutil.Big) (err error) {
+	ledgerState, err := e.stateAt(args.Block)
+	if err != nil {
+		return err
+	}
+	balance := big.NewInt(0)
+	if account := ledgerState.GetAccount(args.Address); account != nil {
+		balance = account.Balance.NoNil().SPAYWei
+	}
+	*result = hexutil.Big(*balance)
+	return nil
+}
+
+// ------------------------------- eth_getTransactionCount -----------------------------------
+
+type EthGetTransactionCountArgs struct {
+	Address common.Address
+	Block   ethBlockTag
+}
+
+func (a *EthGetTransactionCountArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Address, &a.Block)
+}
+
+func (e *EthRPCService) GetTransactionCount(args *EthGetTransactionCountArgs, result *hexutil.Uint64) (err error) {
+	ledgerState, err := e.stateAt(args.Block)
+	if err != nil {
+		return err
+	}
+	var sequence uint64
+	if account := ledgerState.GetAccount(args.Address); account != nil {
+		sequence = account.Sequence
+	}
+	*result = hexutil.Uint64(sequence) // ETH nonce starts from 0, while the Script sequence starts from 1
+	return nil
+}
+
+// ------------------------------- eth_call -----------------------------------
+
+// EthCallMsg is the transaction call object accepted by eth_call and eth_estimateGas.
+type EthCallMsg struct {
+	From     *common.Address `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+	Input    *hexutil.Bytes  `json:"input"`
+}
+
+type EthCallArgs struct {
+	Msg   EthCallMsg
+	Block ethBlockTag
+}
+
+func (a *EthCallArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Msg, &a.Block)
+}
+
+func (e *EthRPCService) Call(args *EthCallArgs, result *hexutil.Bytes) (err error) {
+	ledgerState, parentBlockInfo, err := e.executionStateAt(args.Block)
+	if err != nil {
+		return err
+	}
+	sctx := args.Msg.toSmartContractTx(ledgerState)
+	vmRet, _, _, vmErr := vm.Execute(parentBlockInfo, sctx, ledgerState)
+	if vmErr != nil {
+		return newEthExecutionError(vmRet, vmErr)
+	}
+	*result = hexutil.Bytes(vmRet)
+	return nil
+}
+
+// ------------------------------- eth_estimateGas -----------------------------------
+
+type EthEstimateGasArgs struct {
+	Msg   EthCallMsg
+	Block ethBlockTag
+}
+
+func (a *EthEstimateGasArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Msg, &a.Block)
+}
+
+func (e *EthRPCService) EstimateGas(args *EthEstimateGasArgs, result *hexutil.Uint64) (err error) {
+	ledgerState, parentBlockInfo, err := e.executionStateAt(args.Block)
+	if err != nil {
+		return err
+	}
+	sctx := args.Msg.toSmartContractTx(ledgerState)
+	gasCap := types.GetMaxGasLimit(ledgerState.Height() + 1).Uint64()
+	if args.Msg.Gas != nil && uint64(*args.Msg.Gas) < gasCap {
+		gasCap = uint64(*args.Msg.Gas)
+	}
+	gas, vmRet, vmErr, err := estimateGas(parentBlockInfo, sctx, ledgerState, gasCap)
+	if err != nil {
+		return err
+	}
+	if vmErr != nil {
+		return newEthExecutionError(vmRet, vmErr)
+	}
+	*result = hexutil.Uint64(gas)
+	return nil
+}
+
+// ------------------------------- eth_sendRawTransaction -----------------------------------
+
+type EthSendRawTransactionArgs struct {
+	RawTx hexutil.Bytes
+}
+
+func (a *EthSendRawTransactionArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.RawTx)
+}
+
+func (e *EthRPCService) SendRawTransaction(args *EthSendRawTransactionArgs, result *common.Hash) (err error) {
+	broadcastResult := &BroadcastRawTransactionAsyncResult{}
+	err = e.service.BroadcastRawEthTransactionAsync(&BroadcastRawTransactionAsyncArgs{
+		TxBytes: args.RawTx.String(),
+	}, broadcastResult)
+	if err != nil {
+		return err
+	}
+	*result = common.HexToHash(broadcastResult.TxHash)
+	return nil
+}
+
+// ------------------------------- eth_getTransactionReceipt -----------------------------------
+
+type EthGetTransactionReceiptArgs struct {
+	Hash common.Hash
+}
+
+func (a *EthGetTransactionReceiptArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Hash)
+}
+
+// EthTransactionReceipt is the Ethereum representation of a transaction receipt.
+type EthTransactionReceipt struct {
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	Logs              []*EthLog       `json:"logs"`
+	LogsBloom         core.Bloom      `json:"logsBloom"`
+	Status            hexutil.Uint64  `json:"status"`
+	Type              hexutil.Uint64  `json:"type"`
+}
+
+// EthLog is the Ethereum representation of a contract log event.
+type EthLog struct {
+	Address          common.Address `json:"address"`
+	Topics           []common.Hash  `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+func (e *EthRPCService) GetTransactionReceipt(args *EthGetTransactionReceiptArgs, result **EthTransactionReceipt) (err error) {
+	raw, block, found := e.service.chain.FindTxByHash(args.Hash)
+	if !found || !block.Status.IsFinalized() {
+		return nil
+	}
+
+	txHash := crypto.Keccak256Hash(raw)
+	receipts, err := e.service.getEthBlockReceipts(block)
+	if err != nil {
+		return err
+	}
+	for _, receipt := range receipts {
+		if receipt.canonicalHash == txHash {
+			*result = receipt.EthTransactionReceipt
+			return nil
+		}
+	}
+	return nil
+}
+
+// ------------------------------- eth_getBlockByNumber -----------------------------------
+
+type EthGetBlockByNumberArgs struct {
+	Block  ethBlockTag
+	FullTx bool
+}
+
+func (a *EthGetBlockByNumberArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Block, &a.FullTx)
+}
+
+// EthBlock is the Ethereum representation of a block.
+type EthBlock struct {
+	Number           hexutil.Uint64 `json:"number"`
+	Hash             common.Hash    `json:"hash"`
+	ParentHash       common.Hash    `json:"parentHash"`
+	Nonce            hexutil.Bytes  `json:"nonce"`
+	Sha3Uncles       common.Hash    `json:"sha3Uncles"`
+	LogsBloom        core.Bloom     `json:"logsBloom"`
+	TransactionsRoot common.Hash    `json:"transactionsRoot"`
+	StateRoot        common.Hash    `json:"stateRoot"`
+	ReceiptsRoot     common.Hash    `json:"receiptsRoot"`
+	Miner            common.Address `json:"miner"`
+	Difficulty       hexutil.Uint64 `json:"difficulty"`
+	TotalDifficulty  hexutil.Uint64 `json:"totalDifficulty"`
+	ExtraData        hexutil.Bytes  `json:"extraData"`
+	Size             hexutil.Uint64 `json:"size"`
+	GasLimit         hexutil.Uint64 `json:"gasLimit"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Timestamp        hexutil.Uint64 `json:"timestamp"`
+	Transactions     []interface{}  `json:"transactions"`
+	Uncles           []common.Hash  `json:"uncles"`
+}
+
+// EthRPCTransaction is the Ethereum representation of a transaction included in a block.
+type EthRPCTransaction struct {
+	Hash             common.Hash     `json:"hash"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	BlockHash        common.Hash     `json:"blockHash"`
+	BlockNumber      hexutil.Uint64  `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64  `json:"transactionIndex"`
+	From             common.Address  `json:"from"`
+	To               *common.Address `json:"to"`
+	Value            *hexutil.Big    `json:"value"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Input            hexutil.Bytes   `json:"input"`
+	ChainID          *hexutil.Big    `json:"chainId"`
+	V                *hexutil.Big    `json:"v"`
+	R                *hexutil.Big    `json:"r"`
+	S                *hexutil.Big    `json:"s"`
+	Type             hexutil.Uint64  `json:"type"`
+}
+
+func (e *EthRPCService) GetBlockByNumber(args *EthGetBlockByNumberArgs, result **EthBlock) (err error) {
+	height, err := e.heightOf(args.Block)
+	if err != nil {
+		return err
+	}
+	block := e.service.findFinalizedBlockByHeight(height)
+	if block == nil {
+		return nil
+	}
+	*result, err = e.service.newEthBlock(block, args.FullTx)
+	return err
+}
+
+// ------------------------------- eth_getBlockByHash -----------------------------------
+
+type EthGetBlockByHashArgs struct {
+	Hash   common.Hash
+	FullTx bool
+}
+
+func (a *EthGetBlockByHashArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Hash, &a.FullTx)
+}
+
+func (e *EthRPCService) GetBlockByHash(args *EthGetBlockByHashArgs, result **EthBlock) (err error) {
+	block, err := e.service.chain.FindBlock(args.Hash)
+	if err != nil || !block.Status.IsFinalized() {
+		return nil
+	}
+	*result, err = e.service.newEthBlock(block, args.FullTx)
+	return err
+}
+
+// ------------------------------- eth_getLogs -----------------------------------
+
+// EthFilterQuery is the filter object accepted by eth_getLogs.
+type EthFilterQuery struct {
+	BlockHash *common.Hash   `json:"blockHash"`
+	FromBlock ethBlockTag    `json:"fromBlock"`
+	ToBlock   ethBlockTag    `json:"toBlock"`
+	Addresses ethAddressList `json:"address"`
+	Topics    ethTopicList   `json:"topics"`
+}
+
+type EthGetLogsArgs struct {
+	Filter EthFilterQuery
+}
+
+func (a *EthGetLogsArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Filter)
+}
+
+func (e *EthRPCService) GetLogs(args *EthGetLogsArgs, result *[]*EthLog) (err error) {
+	filter := &args.Filter
+	criteria := &logFilterCriteria{
+		addresses: filter.Addresses,
+		topics:    filter.Topics,
+	}
+
+	var logs []*filteredLog
+	if filter.BlockHash != nil {
+		if filter.FromBlock != "" || filter.ToBlock != "" {
+			return errors.New("cannot specify both blockHash and fromBlock/toBlock")
+		}
+		logs, err = e.service.filterLogsByBlockHash(criteria, *filter.BlockHash)
+	} else {
+		var fromHeight, toHeight uint64
+		if fromHeight, err = e.heightOf(filter.FromBlock); err != nil {
+			return err
+		}
+		if toHeight, err = e.heightOf(filter.ToBlock); err != nil {
+			return err
+		}
+		logs, err = e.service.filterLogs(criteria, fromHeight, toHeight)
+	}
+	if err != nil {
+		return err
+	}
+
+	*result = make([]*EthLog, len(logs))
+	for i, log := range logs {
+		(*result)[i] = log.EthLog
+	}
+	return nil
+}
+
+// ------------------------------- eth_getProof -----------------------------------
+
+type EthGetProofArgs struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+	Block       ethBlockTag
+}
+
+func (a *EthGetProofArgs) UnmarshalJSON(input []byte) error {
+	return decodeEthParams(input, &a.Address, &a.StorageKeys, &a.Block)
+}
+
+// EthStorageProof is the Ethereum representation of a storage slot proof.
+type EthStorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// EthAccountProof is the Ethereum representation of an account proof. Unlike Ethereum, the proof
+// is against the Script state trie, where the account is stored under state.AccountKey().
+type EthAccountProof struct {
+	Address      common.Address     `json:"address"`
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     common.Hash        `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  common.Hash        `json:"storageHash"`
+	StorageProof []*EthStorageProof `json:"storageProof"`
+}
+
+func (e *EthRPCService) GetProof(args *EthGetProofArgs, result *EthAccountProof) (err error) {
+	height, err := e.heightOf(args.Block)
+	if err != nil {
+		return err
+	}
+	block := e.service.findFinalizedBlockByHeight(height)
+	if block == nil {
+		return fmt.Errorf("Historical data at height %v is not available on current node", height)
+	}
+	proof, err := e.service.getProof(block, args.Address, args.StorageKeys)
+	if err != nil {
+		return err
+	}
+
+	result.Address = proof.Address
+	result.AccountProof = proof.AccountProof
+	result.Balance = (*hexutil.Big)(big.NewInt(0))
+	result.CodeHash = types.EmptyCodeHash
+	if account := proof.Account; account != nil {
+		result.Balance = (*hexutil.Big)(account.Balance.NoNil().SPAYWei)
+		result.CodeHash = account.CodeHash
+		result.Nonce = hexutil.Uint64(account.Sequence)
+	}
+	result.StorageHash = proof.StorageHash
+	result.StorageProof = make([]*EthStorageProof, len(proof.StorageProof))
+	for i, sp := range proof.StorageProof {
+		result.StorageProof[i] = &EthStorageProof{
+			Key:   sp.Key,
+			Value: (*hexutil.Big)(sp.Value.Big()),
+			Proof: sp.Proof,
+		}
+	}
+	return nil
+}
+
+// ------------------------------ Utils ------------------------------
+
+// ethBlockTag is either a hex encoded block number or one of the Ethereum block tags.
+type ethBlockTag string
+
+func (tag *ethBlockTag) UnmarshalJSON(input []byte) error {
+	var str string
+	if err := json.Unmarshal(input, &str); err != nil {
+		return fmt.Errorf("invalid block tag %s", input)
+	}
+	*tag = ethBlockTag(str)
+	return nil
+}
+
+// heightOf resolves the tag to a block height. Tags which refer to the head of the chain resolve
+// to the latest finalized block.
+func (e *EthRPCService) heightOf(tag ethBlockTag) (uint64, error) {
+	switch tag {
+	case "", ethBlockTagLatest, ethBlockTagPending, ethBlockTagSafe, ethBlockTagFinalized:
+		return e.service.consensus.GetLastFinalizedBlock().Height, nil
+	case ethBlockTagEarliest:
+		return e.service.chain.Root().Height, nil
+	}
+	height, err := hexutil.DecodeUint64(string(tag))
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number %v: %v", tag, err)
+	}
+	return height, nil
+}
+
+// isHead returns true if the tag refers to the head of the chain rather than a specific block.
+func (tag ethBlockTag) isHead() bool {
+	switch tag {
+	case "", ethBlockTagLatest, ethBlockTagPending, ethBlockTagSafe, ethBlockTagFinalized:
+		return true
+	}
+	return false
+}
+
+// stateAt returns a snapshot of the ledger state for the given block tag. The "pending" tag
+// includes the transactions already screened into the mempool.
+func (e *EthRPCService) stateAt(tag ethBlockTag) (*state.StoreView, error) {
+	switch tag {
+	case ethBlockTagPending:
+		return e.service.ledger.GetScreenedSnapshot()
+	case "", ethBlockTagLatest, ethBlockTagSafe, ethBlockTagFinalized:
+		return e.service.ledger.GetFinalizedSnapshot()
+	}
+	height, err := e.heightOf(tag)
+	if err != nil {
+		return nil, err
+	}
+	return e.service.getStoreViewByHeight(height)
+}
+
+// executionStateAt returns the state and the parent block info to execute a call against.
+func (e *EthRPCService) executionStateAt(tag ethBlockTag) (*state.StoreView, *vm.BlockInfo, error) {
+	if tag.isHead() {
+		return e.service.getExecutionState(0, common.Hash{})
+	}
+	height, err := e.heightOf(tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	block := e.service.findFinalizedBlockByHeight(height)
+	if block == nil {
+		return nil, nil, fmt.Errorf("Historical data at height %v is not available on current node", height)
+	}
+	return e.service.getExecutionState(height, block.Hash())
+}
+
+// toSmartContractTx converts the call message into a SmartContractTx, filling in the defaults
+// for the missing fields.
+func (msg *EthCallMsg) toSmartContractTx(ledgerState *state.StoreView) *types.SmartContractTx {
+	blockHeight := ledgerState.Height() + 1
+
+	var from common.Address
+	if msg.From != nil {
+		from = *msg.From
+	}
+	var to common.Address
+	if msg.To != nil {
+		to = *msg.To
+	}
+	value := big.NewInt(0)
+	if msg.Value != nil {
+		value = msg.Value.ToInt()
+	}
+	gasLimit := types.GetMaxGasLimit(blockHeight).Uint64()
+	if msg.Gas != nil {
+		gasLimit = uint64(*msg.Gas)
+	}
+	gasPrice := types.GetMinimumGasPrice(blockHeight)
+	if msg.GasPrice != nil {
+		gasPrice = msg.GasPrice.ToInt()
+	}
+	var data []byte
+	if msg.Input != nil {
+		data = *msg.Input
+	} else if msg.Data != nil {
+		data = *msg.Data
+	}
+
+	var sequence uint64 = 1
+	if account := ledgerState.GetAccount(from); account != nil {
+		sequence = account.Sequence + 1
+	}
+
+	return &types.SmartContractTx{
+		From: types.TxInput{
+			Address:  from,
+			Coins:    types.Coins{SCPTWei: big.NewInt(0), SPAYWei: value},
+			Sequence: sequence,
+		},
+		To:       types.TxOutput{Address: to},
+		GasLimit: gasLimit,
+		GasPrice: gasPrice,
+		Data:     data,
+	}
+}
+
+// newEthExecutionError converts a VM error into a JSON-RPC error. Reverted executions carry the
+// return data so that clients can decode the revert reason.
+func newEthExecutionError(vmRet common.Bytes, vmErr error) error {
+	if vm.IsExecutionReverted(vmErr) {
+		return &jsonrpc2.Error{
+			Code:    ethRevertErrorCode,
+			Message: "execution reverted",
+			Data:    hexutil.Bytes(vmRet).String(),
+		}
+	}
+	return vmErr
+}
+
+// ethBlockReceipt is the receipt of a transaction together with its canonical (i.e. native)
+// transaction hash.
+type ethBlockReceipt struct {
+	*EthTransactionReceipt
+	canonicalHash common.Hash
+}
+
+// getEthBlockReceipts returns the Ethereum style receipts of all the transactions in the block, in
+// the order of the block, with the cumulative gas and log indexes computed across the block. The
+// transactions other than the smart contract transactions consume no gas and emit no logs.
+func (t *ScriptRPCService) getEthBlockReceipts(block *core.ExtendedBlock) ([]*ethBlockReceipt, error) {
+	blockHash := block.Hash()
+	receipts := []*ethBlockReceipt{}
+	var cumulativeGasUsed uint64
+	var logIndex uint64
+	for txIndex, raw := range block.Txs {
+		tx, err := types.TxFromBytes(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		canonicalHash := crypto.Keccak256Hash(raw)
+		txHash := canonicalHash
+		if ethTxHash, err := blockchain.CalcEthTxHash(block, raw); err == nil {
+			txHash = ethTxHash
+		}
+
+		receipt := &EthTransactionReceipt{
+			TransactionHash:   txHash,
+			TransactionIndex:  hexutil.Uint64(txIndex),
+			BlockHash:         blockHash,
+			BlockNumber:       hexutil.Uint64(block.Height),
+			From:              getEthTxSender(tx),
+			CumulativeGasUsed: hexutil.Uint64(cumulativeGasUsed),
+			EffectiveGasPrice: (*hexutil.Big)(big.NewInt(0)),
+			Logs:              []*EthLog{},
+			Status:            1,
+		}
+		receipts = append(receipts, &ethBlockReceipt{
+			EthTransactionReceipt: receipt,
+			canonicalHash:         canonicalHash,
+		})
+
+		sctx, ok := tx.(*types.SmartContractTx)
+		if !ok {
+			continue
+		}
+		entry, found := t.chain.FindTxReceiptByHash(blockHash, canonicalHash)
+		if !found {
+			return nil, fmt.Errorf("receipt of tx %v not found in block %v", canonicalHash.Hex(), blockHash.Hex())
+		}
+		cumulativeGasUsed += entry.GasUsed
+
+		receipt.CumulativeGasUsed = hexutil.Uint64(cumulativeGasUsed)
+		receipt.GasUsed = hexutil.Uint64(entry.GasUsed)
+		receipt.EffectiveGasPrice = (*hexutil.Big)(sctx.GasPrice)
+		receipt.LogsBloom = types.LogsBloom(entry.Logs)
+		if (sctx.To.Address == common.Address{}) {
+			contractAddress := entry.ContractAddress
+			receipt.ContractAddress = &contractAddress
+		} else {
+			to := sctx.To.Address
+			receipt.To = &to
+		}
+		if entry.EvmErr != "" {
+			receipt.Status = 0
+		}
+		for _, log := range entry.Logs {
+			topics := log.Topics
+			if topics == nil {
+				topics = []common.Hash{}
+			}
+			receipt.Logs = append(receipt.Logs, &EthLog{
+				Address:          log.Address,
+				Topics:           topics,
+				Data:             log.Data,
+				BlockNumber:      hexutil.Uint64(block.Height),
+				TransactionHash:  txHash,
+				TransactionIndex: hexutil.Uint64(txIndex),
+				BlockHash:        blockHash,
+				LogIndex:         hexutil.Uint64(logIndex),
+			})
+			logIndex++
+		}
+	}
+	return receipts, nil
+}
+
+// getEthTxSender returns the account which signed the transaction, or the zero address if there is none.
+func getEthTxSender(tx types.Tx) common.Address {
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		return tx.Proposer.Address
+	case *types.SlashTx:
+		return tx.Proposer.Address
+	case *types.DoubleSignSlashTx:
+		return tx.Proposer.Address
+	case *types.SendTx:
+		if len(tx.Inputs) > 0 {
+			return tx.Inputs[0].Address
+		}
+	case *types.ReserveFundTx:
+		return tx.Source.Address
+	case *types.ReleaseFundTx:
+		return tx.Source.Address
+	case *types.ServicePaymentTx:
+		return tx.Target.Address
+	case *types.SplitRuleTx:
+		return tx.Initiator.Address
+	case *types.SmartContractTx:
+		return tx.From.Address
+	case *types.DepositStakeTx:
+		return tx.Source.Address
+	case *types.DepositStakeTxV2:
+		return tx.Source.Address
+	case *types.WithdrawStakeTx:
+		return tx.Source.Address
+	case *types.StakeRewardDistributionTx:
+		return tx.Holder.Address
+	}
+	return common.Address{}
+}
+
+// newEthBlock converts the block into its Ethereum representation. The transactions are included
+// either as hashes or as full transaction objects. The transactions other than the smart contract
+// transactions are represented as zero value transfers from their signers.
+func (t *ScriptRPCService) newEthBlock(block *core.ExtendedBlock, fullTx bool) (*EthBlock, error) {
+	receipts, err := t.getEthBlockReceipts(block)
+	if err != nil {
+		return nil, err
+	}
+	var gasUsed uint64
+	var bloom core.Bloom
+	for _, receipt := range receipts {
+		gasUsed += uint64(receipt.GasUsed)
+		bloom.Or(receipt.LogsBloom)
+	}
+
+	size := 0
+	if raw, err := rlp.EncodeToBytes(block.Block); err == nil {
+		size = len(raw)
+	}
+
+	result := &EthBlock{
+		Number:           hexutil.Uint64(block.Height),
+		Hash:             block.Hash(),
+		ParentHash:       block.Parent,
+		Nonce:            make(hexutil.Bytes, 8),
+		Sha3Uncles:       ethEmptyUncleHash,
+		LogsBloom:        bloom,
+		TransactionsRoot: block.TxHash,
+		StateRoot:        block.StateHash,
+		ReceiptsRoot:     block.ReceiptHash,
+		Miner:            block.Proposer,
+		ExtraData:        hexutil.Bytes{},
+		Size:             hexutil.Uint64(size),
+		GasLimit:         hexutil.Uint64(types.GetMaxGasLimit(block.Height).Uint64()),
+		GasUsed:          hexutil.Uint64(gasUsed),
+		Transactions:     []interface{}{},
+		Uncles:           []common.Hash{},
+	}
+	if block.Timestamp != nil {
+		result.Timestamp = hexutil.Uint64(block.Timestamp.Uint64())
+	}
+
+	chainID := types.MapChainID(block.ChainID, block.Height)
+	for txIndex, raw := range block.Txs {
+		tx, err := types.TxFromBytes(raw)
+		if err != nil {
+			return nil, err
+		}
+		txHash := crypto.Keccak256Hash(raw)
+		if ethTxHash, err := blockchain.CalcEthTxHash(block, raw); err == nil {
+			txHash = ethTxHash
+		}
+		if !fullTx {
+			result.Transactions = append(result.Transactions, txHash)
+			continue
+		}
+		sctx, ok := tx.(*types.SmartContractTx)
+		if !ok {
+			result.Transactions = append(result.Transactions, &EthRPCTransaction{
+				Hash:             txHash,
+				BlockHash:        result.Hash,
+				BlockNumber:      result.Number,
+				TransactionIndex: hexutil.Uint64(txIndex),
+				From:             getEthTxSender(tx),
+				Value:            (*hexutil.Big)(big.NewInt(0)),
+				GasPrice:         (*hexutil.Big)(big.NewInt(0)),
+				Input:            hexutil.Bytes{},
+				ChainID:          (*hexutil.Big)(chainID),
+				V:                (*hexutil.Big)(big.NewInt(0)),
+				R:                (*hexutil.Big)(big.NewInt(0)),
+				S:                (*hexutil.Big)(big.NewInt(0)),
+			})
+			continue
+		}
+
+		var to *common.Address
+		if (sctx.To.Address != common.Address{}) {
+			address := sctx.To.Address
+			to = &address
+		}
+		r, s, v := crypto.DecodeSignature(sctx.From.Signature)
+		vPrime := new(big.Int).Mul(chainID, big.NewInt(2))
+		vPrime.Add(vPrime, big.NewInt(8))
+		vPrime.Add(vPrime, v)
+
+		result.Transactions = append(result.Transactions, &EthRPCTransaction{
+			Hash:             txHash,
+			Nonce:            hexutil.Uint64(sctx.From.Sequence - 1), // off-by-one, ETH tx nonce starts from 0, while Script tx sequence starts from 1
+			BlockHash:        result.Hash,
+			BlockNumber:      result.Number,
+			TransactionIndex: hexutil.Uint64(txIndex),
+			From:             sctx.From.Address,
+			To:               to,
+			Value:            (*hexutil.Big)(sctx.From.Coins.NoNil().SPAYWei),
+			Gas:              hexutil.Uint64(sctx.GasLimit),
+			GasPrice:         (*hexutil.Big)(sctx.GasPrice),
+			Input:            hexutil.Bytes(sctx.Data),
+			ChainID:          (*hexutil.Big)(chainID),
+			V:                (*hexutil.Big)(vPrime),
+			R:                (*hexutil.Big)(r),
+			S:                (*hexutil.Big)(s),
+		})
+	}
+	return result, nil
+}
+
+// ethAddressList accepts either a single address or an array of addresses.
+type ethAddressList []common.Address
+
+func (l *ethAddressList) UnmarshalJSON(input []byte) error {
+	input = bytes.TrimSpace(input)
+	if len(input) > 0 && input[0] == '[' {
+		var addresses []common.Address
+		if err := json.Unmarshal(input, &addresses); err != nil {
+			return err
+		}
+		*l = addresses
+		return nil
+	}
+	var address common.Address
+	if err := json.Unmarshal(input, &address); err != nil {
+		return err
+	}
+	*l = ethAddressList{address}
+	return nil
+}
+
+// ethTopicList is the topic filter of eth_getLogs. Each position is either null (matches any
+// topic), a single topic, or an array of alternative topics.
+type ethTopicList [][]common.Hash
+
+func (l *ethTopicList) UnmarshalJSON(input []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(input, &raws); err != nil {
+		return err
+	}
+	topics := make([][]common.Hash, len(raws))
+	for i, raw := range raws {
+		raw = bytes.TrimSpace(raw)
+		switch {
+		case bytes.Equal(raw, []byte("null")):
+		case len(raw) > 0 && raw[0] == '[':
+			if err := json.Unmarshal(raw, &topics[i]); err != nil {
+				return err
+			}
+		default:
+			var topic common.Hash
+			if err := json.Unmarshal(raw, &topic); err != nil {
+				return err
+			}
+			topics[i] = []common.Hash{topic}
+		}
+	}
+	*l = topics
+	return nil
+}
+
+// decodeEthParams decodes the positional parameters of an Ethereum style JSON-RPC request into
+// the given destinations. Trailing parameters may be omitted. Rejecting anything other than an
+// array makes the codec fall back to passing in the complete parameter list.
+func decodeEthParams(input []byte, dests ...interface{}) error {
+	input = bytes.TrimSpace(input)
+	if len(input) == 0 || input[0] != '[' {
+		return errors.New("positional parameters expected")
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(input, &raws); err != nil {
+		return err
+	}
+	if len(raws) > len(dests) {
+		return fmt.Errorf("too many arguments, want at most %v", len(dests))
+	}
+	for i, raw := range raws {
+		if strings.TrimSpace(string(raw)) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(raw, dests[i]); err != nil {
+			return fmt.Errorf("invalid argument %v: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/scripttoken/script/store/kvstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthParamsDecoding(t *testing.T) {
+	require := require.New(t)
+
+	params := []byte(`[{"to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","data":"0x70a08231"},"0x10"]`)
+
+	// The codec first tries to decode the first element only, which must fail so that
+	// it falls back to decoding the complete parameter list.
+	args := &EthCallArgs{}
+	var first [1]interface{}
+	first[0] = args
+	require.NotNil(json.Unmarshal(params, &first))
+
+	require.Nil(json.Unmarshal(params, args))
+	require.Equal(common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"), *args.Msg.To)
+	require.Equal([]byte{0x70, 0xa0, 0x82, 0x31}, []byte(*args.Msg.Data))
+	require.Equal(ethBlockTag("0x10"), args.Block)
+
+	// Trailing parameters are optional
+	args = &EthCallArgs{}
+	require.Nil(json.Unmarshal([]byte(`[{}]`), args))
+	require.Equal(ethBlockTag(""), args.Block)
+	require.True(args.Block.isHead())
+
+	// Too many parameters
+	require.NotNil(json.Unmarshal([]byte(`[{}, "latest", 1]`), args))
+}
+
+func newTestSignedSmartContractTx(privKey *crypto.PrivateKey, sequence uint64) *types.SmartContractTx {
+	tx := &types.SmartContractTx{
+		From: types.TxInput{
+			Address:  privKey.PublicKey().Address(),
+			Coins:    types.NewCoins(0, 0),
+			Sequence: sequence,
+		},
+		To:       types.TxOutput{Address: common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")},
+		GasLimit: 100000,
+		GasPrice: big.NewInt(4000),
+		Data:     common.Bytes{0x01},
+	}
+	sig, _ := privKey.Sign(tx.SignBytes("testchain"))
+	tx.SetSignature(tx.From.Address, sig)
+	return tx
+}
+
+func TestEthBlockReceipts(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	sender := privKey.PublicKey().Address()
+	proposer := common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab")
+	contract := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+
+	coinbaseTx := &types.CoinbaseTx{Proposer: types.TxInput{Address: proposer}, BlockHeight: 1}
+	sctx1 := newTestSignedSmartContractTx(privKey, 1)
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: sender, Coins: types.NewCoins(0, 10), Sequence: 2}},
+		Outputs: []types.TxOutput{{Address: proposer, Coins: types.NewCoins(0, 10)}},
+	}
+	sctx2 := newTestSignedSmartContractTx(privKey, 3)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("e0", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	b1 := core.NewBlock()
+	b1.ChainID = "testchain"
+	b1.Height = root.Height + 1
+	b1.Parent = root.Hash()
+	for _, tx := range []types.Tx{coinbaseTx, sctx1, sendTx, sctx2} {
+		raw, err := types.TxToBytes(tx)
+		require.Nil(err)
+		b1.Txs = append(b1.Txs, raw)
+	}
+	_, err = chain.AddBlock(b1)
+	require.Nil(err)
+	block, err := chain.FindBlock(b1.Hash())
+	require.Nil(err)
+	service := &ScriptRPCService{chain: chain}
+
+	// The receipt of a smart contract transaction is missing
+	_, err = service.getEthBlockReceipts(block)
+	require.NotNil(err)
+
+	logs := func(n int) []*types.Log {
+		logs := []*types.Log{}
+		for i := 0; i < n; i++ {
+			logs = append(logs, &types.Log{Address: contract, Topics: []common.Hash{common.BigToHash(big.NewInt(int64(i)))}})
+		}
+		return logs
+	}
+	chain.AddTxReceipt(b1, sctx1, logs(1), nil, nil, common.Address{}, 100, nil)
+	chain.AddTxReceipt(b1, sctx2, logs(2), nil, nil, common.Address{}, 50, nil)
+
+	// Every transaction of the block has a receipt
+	receipts, err := service.getEthBlockReceipts(block)
+	require.Nil(err)
+	require.Equal(4, len(receipts))
+	expectedSenders := []common.Address{proposer, sender, sender, sender}
+	expectedGasUsed := []uint64{0, 100, 0, 50}
+	expectedCumulativeGasUsed := []uint64{0, 100, 100, 150}
+	expectedNumLogs := []int{0, 1, 0, 2}
+	for i, receipt := range receipts {
+		require.Equal(crypto.Keccak256Hash(block.Txs[i]), receipt.canonicalHash)
+		require.Equal(uint64(i), uint64(receipt.TransactionIndex))
+		require.Equal(expectedSenders[i], receipt.From)
+		require.Equal(expectedGasUsed[i], uint64(receipt.GasUsed))
+		require.Equal(expectedCumulativeGasUsed[i], uint64(receipt.CumulativeGasUsed))
+		require.Equal(expectedNumLogs[i], len(receipt.Logs))
+		require.Equal(uint64(1), uint64(receipt.Status))
+	}
+	require.Equal(uint64(0), uint64(receipts[1].Logs[0].LogIndex))
+	require.Equal(uint64(1), uint64(receipts[3].Logs[0].LogIndex))
+	require.Equal(uint64(2), uint64(receipts[3].Logs[1].LogIndex))
+	require.Equal(uint64(3), uint64(receipts[3].Logs[1].TransactionIndex))
+
+	// The block lists the same transactions as the receipts
+	ethBlock, err := service.newEthBlock(block, false)
+	require.Nil(err)
+	require.Equal(uint64(150), uint64(ethBlock.GasUsed))
+	require.Equal(len(receipts), len(ethBlock.Transactions))
+	for i, tx := range ethBlock.Transactions {
+		require.Equal(receipts[i].TransactionHash, tx.(common.Hash))
+	}
+	ethBlock, err = service.newEthBlock(block, true)
+	require.Nil(err)
+	require.Equal(len(receipts), len(ethBlock.Transactions))
+	for i, tx := range ethBlock.Transactions {
+		ethTx := tx.(*EthRPCTransaction)
+		require.Equal(receipts[i].TransactionHash, ethTx.Hash)
+		require.Equal(uint64(i), uint64(ethTx.TransactionIndex))
+		require.Equal(expectedSenders[i], ethTx.From)
+	}
+}
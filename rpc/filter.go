@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+)
+
+const (
+	// maxLogsQueryBlockRange is the maximum number of blocks a single log query may cover
+	maxLogsQueryBlockRange = 50000
+
+	// maxLogsQueryResults is the maximum number of logs a single log query may return
+	maxLogsQueryResults = 10000
+)
+
+// logFilterCriteria selects logs by the emitting contract address and the topics.
+type logFilterCriteria struct {
+	addresses []common.Address
+	topics    [][]common.Hash // nil or empty entries are wildcards
+}
+
+// filteredLog is a log matching the filter criteria together with the native hash of the
+// transaction that emitted it.
+type filteredLog struct {
+	*EthLog
+	canonicalTxHash common.Hash
+}
+
+// matches returns true if a log with the given address and topics satisfies the criteria.
+func (c *logFilterCriteria) matches(address common.Address, topics []common.Hash) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if addr == address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.topics) > len(topics) {
+		return false
+	}
+	for i, alternatives := range c.topics {
+		if len(alternatives) == 0 {
+			continue // wildcard
+		}
+		found := false
+		for _, topic := range alternatives {
+			if topic == topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesBloom returns false if none of the logs covered by the bloom filter can satisfy
+// the criteria.
+func (c *logFilterCriteria) matchesBloom(bloom core.Bloom) bool {
+	if bloom == (core.Bloom{}) {
+		return false // no logs at all
+	}
+	if len(c.addresses) > 0 {
+		found := false
+		for _, addr := range c.addresses {
+			if bloom.ContainsBytes(addr.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, alternatives := range c.topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range alternatives {
+			if bloom.ContainsBytes(topic.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterLogs returns the logs matching the criteria in the finalized blocks between fromHeight
+// and toHeight (inclusive). The bloom indexes maintained by the chain are used to skip the
+// sections and heights that cannot contain a matching log.
+func (t *ScriptRPCService) filterLogs(criteria *logFilterCriteria, fromHeight, toHeight uint64) ([]*filteredLog, error) {
+	if fromHeight > toHeight {
+		return nil, fmt.Errorf("from height (%v) is greater than to height (%v)", fromHeight, toHeight)
+	}
+	if toHeight-fromHeight >= maxLogsQueryBlockRange {
+		return nil, fmt.Errorf("can't retrieve logs for more than %v blocks", maxLogsQueryBlockRange)
+	}
+
+	logs := []*filteredLog{}
+	for height := fromHeight; height <= toHeight; height++ {
+		if height == fromHeight || height%blockchain.BloomSectionSize == 0 {
+			section := height / blockchain.BloomSectionSize
+			bloom, indexed := t.chain.FindSectionBloom(section)
+			if indexed && !criteria.matchesBloom(bloom) {
+				height = (section+1)*blockchain.BloomSectionSize - 1
+				continue
+			}
+		}
+		bloom, indexed := t.chain.FindHeightBloom(height)
+		if indexed && !criteria.matchesBloom(bloom) {
+			continue
+		}
+
+		block := t.findFinalizedBlockByHeight(height)
+		if block == nil {
+			continue
+		}
+		blockLogs, err := t.filterBlockLogs(criteria, block)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, blockLogs...)
+		if len(logs) > maxLogsQueryResults {
+			return nil, fmt.Errorf("query returned more than %v results", maxLogsQueryResults)
+		}
+	}
+	return logs, nil
+}
+
+// filterLogsByBlockHash returns the logs matching the criteria in the given finalized block.
+func (t *ScriptRPCService) filterLogsByBlockHash(criteria *logFilterCriteria, blockHash common.Hash) ([]*filteredLog, error) {
+	block, err := t.chain.FindBlock(blockHash)
+	if err != nil || !block.Status.IsFinalized() {
+		return nil, fmt.Errorf("finalized block %v not found", blockHash.Hex())
+	}
+	return t.filterBlockLogs(criteria, block)
+}
+
+func (t *ScriptRPCService) filterBlockLogs(criteria *logFilterCriteria, block *core.ExtendedBlock) ([]*filteredLog, error) {
+	receipts, err := t.getEthBlockReceipts(block)
+	if err != nil {
+		return nil, err
+	}
+	logs := []*filteredLog{}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if criteria.matches(log.Address, log.Topics) {
+				logs = append(logs, &filteredLog{
+					EthLog:          log,
+					canonicalTxHash: receipt.canonicalHash,
+				})
+			}
+		}
+	}
+	return logs, nil
+}
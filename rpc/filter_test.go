@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFilterCriteria(t *testing.T) {
+	assert := assert.New(t)
+
+	addr1 := common.HexToAddress("0x01")
+	addr2 := common.HexToAddress("0x02")
+	topic1 := common.HexToHash("0xa1")
+	topic2 := common.HexToHash("0xa2")
+	topic3 := common.HexToHash("0xa3")
+
+	query := &EthFilterQuery{}
+	err := json.Unmarshal([]byte(`{"address":"0x0000000000000000000000000000000000000001","topics":[null,["0x00000000000000000000000000000000000000000000000000000000000000a2","0x00000000000000000000000000000000000000000000000000000000000000a3"]]}`), query)
+	assert.Nil(err)
+
+	criteria := logFilterCriteria{addresses: query.Addresses, topics: query.Topics}
+	assert.True(criteria.matches(addr1, []common.Hash{topic1, topic2}))
+	assert.True(criteria.matches(addr1, []common.Hash{topic1, topic3, topic1}))
+	assert.False(criteria.matches(addr2, []common.Hash{topic1, topic2}))
+	assert.False(criteria.matches(addr1, []common.Hash{topic1, topic1}))
+	assert.False(criteria.matches(addr1, []common.Hash{topic2}))
+
+	criteria = logFilterCriteria{}
+	assert.True(criteria.matches(addr2, nil))
+}
+
+func TestLogFilterCriteriaBloom(t *testing.T) {
+	assert := assert.New(t)
+
+	addr1 := common.HexToAddress("0x01")
+	addr2 := common.HexToAddress("0x02")
+	topic1 := common.HexToHash("0xa1")
+	topic2 := common.HexToHash("0xa2")
+
+	bloom := types.LogsBloom([]*types.Log{{Address: addr1, Topics: []common.Hash{topic1}}})
+
+	criteria := logFilterCriteria{}
+	assert.True(criteria.matchesBloom(bloom))
+	assert.False(criteria.matchesBloom(core.Bloom{}))
+
+	criteria = logFilterCriteria{addresses: []common.Address{addr2, addr1}}
+	assert.True(criteria.matchesBloom(bloom))
+
+	criteria = logFilterCriteria{addresses: []common.Address{addr2}}
+	assert.False(criteria.matchesBloom(bloom))
+
+	criteria = logFilterCriteria{topics: [][]common.Hash{nil, {topic1}}}
+	assert.True(criteria.matchesBloom(bloom))
+
+	criteria = logFilterCriteria{addresses: []common.Address{addr1}, topics: [][]common.Hash{{topic2}}}
+	assert.False(criteria.matchesBloom(bloom))
+}
@@ -119,6 +119,43 @@ func TestServer(t *testing.T) {
 	}
 }
 
+func TestServerNamespacedMethod(t *testing.T) {
+	cli, srv := net.Pipe()
+	defer cli.Close()
+	go ServeConn(srv)
+	dec := json.NewDecoder(cli)
+
+	fmt.Fprintf(cli, `{"jsonrpc": "2.0", "method": "Arith_mul", "id": 1, "params": {"A": 3, "B": 4}}`)
+	var resp ArithAddResp
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("resp.Error: %s", resp.Error)
+	}
+	if resp.Result.C != 12 {
+		t.Fatalf("resp: bad result: 3*4=%d", resp.Result.C)
+	}
+}
+
+func TestNormalizeServiceMethod(t *testing.T) {
+	cases := map[string]string{
+		"eth_chainId":             "eth.ChainId",
+		"debug_traceTransaction":  "debug.TraceTransaction",
+		"script.GetAccount":       "script.GetAccount",
+		"JSONRPC2.Batch":          "JSONRPC2.Batch",
+		"nounderscore":            "nounderscore",
+		"_leading":                "_leading",
+		"trailing_":               "trailing_",
+		"net_peer_count_extended": "net.Peer_count_extended",
+	}
+	for in, want := range cases {
+		if got := normalizeServiceMethod(in); got != want {
+			t.Errorf("normalizeServiceMethod(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func TestClient(t *testing.T) {
 	// Assume server is okay (TestServer is above).
 	// Test client against server.
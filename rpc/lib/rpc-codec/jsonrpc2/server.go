@@ -11,6 +11,7 @@ import (
 	"errors"
 	"io"
 	"net/rpc"
+	"strings"
 	"sync"
 )
 
@@ -169,7 +170,7 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 		return err
 	}
 
-	r.ServiceMethod = c.req.Method
+	r.ServiceMethod = normalizeServiceMethod(c.req.Method)
 
 	// JSON request id can be any JSON value;
 	// RPC package expects uint64.  Translate to
@@ -225,6 +226,21 @@ func (c *serverCodec) ReadRequestBody(x interface{}) error {
 
 var null = json.RawMessage([]byte("null"))
 
+// normalizeServiceMethod maps Ethereum style "namespace_method" names (e.g.
+// "eth_chainId") onto the "Service.Method" form expected by net/rpc (e.g.
+// "eth.ChainId"). Names that already contain a dot are returned unchanged.
+func normalizeServiceMethod(method string) string {
+	if strings.Contains(method, ".") {
+		return method
+	}
+	idx := strings.Index(method, "_")
+	if idx <= 0 || idx == len(method)-1 {
+		return method
+	}
+	name := method[idx+1:]
+	return method[:idx] + "." + strings.ToUpper(name[:1]) + name[1:]
+}
+
 func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
 	// If return error: nothing happens.
 	// In r.Error will be "" or .Error() of error returned by:
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"sort"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/mempool"
+)
+
+// ------------------------------- GetMempoolContent -----------------------------------
+
+type GetMempoolContentArgs struct {
+	Address string `json:"address"` // optional, only return the transactions of the account
+}
+
+type MempoolTx struct {
+	Hash     common.Hash       `json:"hash"`
+	Type     byte              `json:"type"`
+	Sequence common.JSONUint64 `json:"sequence"`
+	GasPrice *common.JSONBig   `json:"gas_price"` // the effective gas price, which determines the priority
+	Status   TxStatus          `json:"status"`
+	Age      common.JSONUint64 `json:"age"` // in seconds
+}
+
+type MempoolAccountTxs struct {
+	Address common.Address `json:"address"`
+	Txs     []*MempoolTx   `json:"txs"` // ordered by sequence
+}
+
+type GetMempoolContentResult struct {
+	Pending []*MempoolAccountTxs `json:"pending"` // transactions which can be included in the next block
+	Queued  []*MempoolAccountTxs `json:"queued"`  // transactions waiting for the preceding sequences
+}
+
+// GetMempoolContent returns the transactions in the mempool grouped by sender.
+func (t *ScriptRPCService) GetMempoolContent(args *GetMempoolContentArgs, result *GetMempoolContentResult) (err error) {
+	var address *common.Address
+	if args.Address != "" {
+		addr := common.HexToAddress(args.Address)
+		address = &addr
+	}
+
+	pending, queued := t.mempool.GetContent(address)
+	result.Pending = toMempoolAccountTxs(pending)
+	result.Queued = toMempoolAccountTxs(queued)
+	return nil
+}
+
+func toMempoolAccountTxs(content map[common.Address][]*mempool.MempoolTxDetails) []*MempoolAccountTxs {
+	accountTxsList := []*MempoolAccountTxs{}
+	for address, txs := range content {
+		accountTxs := &MempoolAccountTxs{
+			Address: address,
+			Txs:     []*MempoolTx{},
+		}
+		for _, details := range txs {
+			mempoolTx := &MempoolTx{
+				Hash:     common.HexToHash(details.Hash),
+				Sequence: common.JSONUint64(details.Sequence),
+				GasPrice: (*common.JSONBig)(details.EffectiveGasPrice),
+				Status:   toTxStatus(details.Status),
+				Age:      common.JSONUint64(details.Age.Seconds()),
+			}
+			if tx, err := types.TxFromBytes(details.RawTx); err == nil {
+				mempoolTx.Type = getTxType(tx)
+			}
+			accountTxs.Txs = append(accountTxs.Txs, mempoolTx)
+		}
+		accountTxsList = append(accountTxsList, accountTxs)
+	}
+	sort.Slice(accountTxsList, func(i, j int) bool {
+		return accountTxsList[i].Address.Hex() < accountTxsList[j].Address.Hex()
+	})
+	return accountTxsList
+}
+
+// ------------------------------- GetMempoolStats -----------------------------------
+
+type GetMempoolStatsArgs struct {
+}
+
+type GetMempoolStatsResult struct {
+	NumPendingTxs       common.JSONUint64            `json:"num_pending_txs"`
+	NumQueuedTxs        common.JSONUint64            `json:"num_queued_txs"`
+	NumAccounts         common.JSONUint64            `json:"num_accounts"`
+	Bytes               common.JSONUint64            `json:"bytes"`
+	MaxNumTxs           common.JSONUint64            `json:"max_num_txs"`
+	MaxNumTxsPerAccount common.JSONUint64            `json:"max_num_txs_per_account"`
+	MaxBytes            common.JSONUint64            `json:"max_bytes"`
+	MinGasPrice         *common.JSONBig              `json:"min_gas_price"` // nil if there is no pending transaction
+	MaxGasPrice         *common.JSONBig              `json:"max_gas_price"` // nil if there is no pending transaction
+	NumEvicted          common.JSONUint64            `json:"num_evicted"`
+	NumRejected         map[string]common.JSONUint64 `json:"num_rejected"` // by reason
+}
+
+// GetMempoolStats returns the statistics of the mempool.
+func (t *ScriptRPCService) GetMempoolStats(args *GetMempoolStatsArgs, result *GetMempoolStatsResult) (err error) {
+	stats := t.mempool.GetStats()
+
+	result.NumPendingTxs = common.JSONUint64(stats.NumPendingTxs)
+	result.NumQueuedTxs = common.JSONUint64(stats.NumQueuedTxs)
+	result.NumAccounts = common.JSONUint64(stats.NumAccounts)
+	result.Bytes = common.JSONUint64(stats.SizeInBytes)
+	result.MaxNumTxs = common.JSONUint64(stats.MaxNumTxs)
+	result.MaxNumTxsPerAccount = common.JSONUint64(stats.MaxNumTxsPerAccount)
+	result.MaxBytes = common.JSONUint64(stats.MaxBytes)
+	result.MinGasPrice = (*common.JSONBig)(stats.MinGasPrice)
+	result.MaxGasPrice = (*common.JSONBig)(stats.MaxGasPrice)
+	result.NumEvicted = common.JSONUint64(stats.NumEvicted)
+	result.NumRejected = make(map[string]common.JSONUint64)
+	for reason, count := range stats.NumRejected {
+		result.NumRejected[reason] = common.JSONUint64(count)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/vm"
+)
+
+// AccountOverride overrides the state of an account for a simulated call. The fields left
+// empty keep their current values.
+type AccountOverride struct {
+	Balance       *common.JSONBig             `json:"balance"`        // SPAY balance in wei
+	ScriptBalance *common.JSONBig             `json:"script_balance"` // Script balance in wei
+	Nonce         *common.JSONUint64          `json:"nonce"`
+	Code          *hexutil.Bytes              `json:"code"`
+	State         map[common.Hash]common.Hash `json:"state"`      // replaces the complete storage
+	StateDiff     map[common.Hash]common.Hash `json:"state_diff"` // replaces the given storage slots only
+}
+
+// StateOverride overrides the state of a set of accounts for a simulated call.
+type StateOverride map[common.Address]AccountOverride
+
+// BlockOverride overrides the context of the block a simulated call is executed in. Note that
+// the height only changes the block number seen by the contracts, while the protocol rules
+// still follow the height of the state.
+type BlockOverride struct {
+	Height    *common.JSONUint64 `json:"height"`
+	Timestamp *common.JSONBig    `json:"timestamp"`
+}
+
+// apply applies the overrides to the given state, which must be a copy private to the call.
+func (o StateOverride) apply(ledgerState *state.StoreView) error {
+	for addr, override := range o {
+		if override.State != nil && override.StateDiff != nil {
+			return fmt.Errorf("account %v has both state and state_diff overrides", addr.Hex())
+		}
+
+		account := ledgerState.GetOrCreateAccount(addr)
+		account.Balance = account.Balance.NoNil()
+		if override.Balance != nil {
+			account.Balance.SPAYWei = new(big.Int).Set((*big.Int)(override.Balance))
+		}
+		if override.ScriptBalance != nil {
+			account.Balance.SCPTWei = new(big.Int).Set((*big.Int)(override.ScriptBalance))
+		}
+		if override.Nonce != nil {
+			account.Sequence = uint64(*override.Nonce)
+		}
+		if override.State != nil {
+			account.Root = common.Hash{} // start from an empty storage
+		}
+		ledgerState.SetAccount(addr, account)
+
+		if override.Code != nil {
+			ledgerState.SetCode(addr, *override.Code)
+		}
+		for key, value := range override.State {
+			ledgerState.SetState(addr, key, value)
+		}
+		for key, value := range override.StateDiff {
+			ledgerState.SetState(addr, key, value)
+		}
+	}
+	return nil
+}
+
+// apply returns the parent block info with the overrides applied.
+func (o *BlockOverride) apply(parentBlockInfo *vm.BlockInfo) (*vm.BlockInfo, error) {
+	if o == nil {
+		return parentBlockInfo, nil
+	}
+	overridden := *parentBlockInfo
+	if o.Height != nil {
+		if *o.Height == 0 {
+			return nil, fmt.Errorf("the height override must be positive")
+		}
+		overridden.Height = uint64(*o.Height) - 1 // the block info is the one of the parent block
+	}
+	if o.Timestamp != nil {
+		overridden.Timestamp = new(big.Int).Set((*big.Int)(o.Timestamp))
+	}
+	return &overridden, nil
+}
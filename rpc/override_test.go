@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateOverride(t *testing.T) {
+	require := require.New(t)
+
+	contractAddr := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+	otherAddr := common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab")
+
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	storeView.SetState(contractAddr, common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(11)))
+	storeView.SetState(contractAddr, common.BigToHash(big.NewInt(2)), common.BigToHash(big.NewInt(22)))
+
+	// The code returns the storage slot 1
+	args := &CallSmartContractArgs{}
+	err := json.Unmarshal([]byte(`{
+		"state_override": {
+			"0x7ad6cea2bc3162e30a3c98d84f821b3233c22647": {
+				"code": "0x60015460005260206000f3",
+				"state_diff": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000033"}
+			},
+			"0x2e833968e5bb786ae419c4d13189fb081cc43bab": {"balance": "1000", "nonce": "5"}
+		}
+	}`), args)
+	require.Nil(err)
+	require.Nil(args.StateOverride.apply(storeView))
+
+	require.Equal(big.NewInt(1000), storeView.GetBalance(otherAddr))
+	require.Equal(uint64(5), storeView.GetNonce(otherAddr))
+	require.Equal(common.BigToHash(big.NewInt(0x33)), storeView.GetState(contractAddr, common.BigToHash(big.NewInt(1))))
+	require.Equal(common.BigToHash(big.NewInt(22)), storeView.GetState(contractAddr, common.BigToHash(big.NewInt(2))))
+
+	sctx := newTestSmartContractTx("")
+	sctx.To = types.TxOutput{Address: contractAddr}
+	sctx.GasLimit = 100000
+	parentBlockInfo := vm.NewBlockInfo(0, big.NewInt(0), "privatenet")
+	vmRet, _, _, vmErr := vm.Execute(parentBlockInfo, sctx, storeView)
+	require.Nil(vmErr)
+	require.Equal(common.BigToHash(big.NewInt(0x33)).Bytes(), []byte(vmRet))
+
+	// The full state override wipes out the other slots
+	code := hexutil.Bytes(common.Hex2Bytes("60015460005260206000f3"))
+	override := StateOverride{
+		contractAddr: AccountOverride{
+			Code:  &code,
+			State: map[common.Hash]common.Hash{common.BigToHash(big.NewInt(1)): common.BigToHash(big.NewInt(44))},
+		},
+	}
+	require.Nil(override.apply(storeView))
+	require.Equal(common.BigToHash(big.NewInt(44)), storeView.GetState(contractAddr, common.BigToHash(big.NewInt(1))))
+	require.Equal(common.Hash{}, storeView.GetState(contractAddr, common.BigToHash(big.NewInt(2))))
+
+	// State and state diff are exclusive
+	override[contractAddr] = AccountOverride{
+		State:     map[common.Hash]common.Hash{},
+		StateDiff: map[common.Hash]common.Hash{},
+	}
+	require.NotNil(override.apply(storeView))
+}
+
+func TestBlockOverride(t *testing.T) {
+	require := require.New(t)
+
+	contractAddr := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+
+	// ASM: number, push 0x0, mstore, timestamp, push 0x20, mstore, push 0x40, push 0x0, return
+	code, _ := hex.DecodeString("4360005242602052" + "60406000f3")
+	storeView.SetCode(contractAddr, code)
+
+	sctx := newTestSmartContractTx("")
+	sctx.To = types.TxOutput{Address: contractAddr}
+	sctx.GasLimit = 100000
+
+	blockOverride := &BlockOverride{}
+	require.Nil(json.Unmarshal([]byte(`{"height": "1000", "timestamp": "1600000000"}`), blockOverride))
+	parentBlockInfo, err := blockOverride.apply(vm.NewBlockInfo(10, big.NewInt(12345), "privatenet"))
+	require.Nil(err)
+
+	vmRet, _, _, vmErr := vm.Execute(parentBlockInfo, sctx, storeView)
+	require.Nil(vmErr)
+	require.Equal(big.NewInt(1000), new(big.Int).SetBytes(vmRet[:32]))
+	require.Equal(big.NewInt(1600000000), new(big.Int).SetBytes(vmRet[32:]))
+
+	// No override
+	var noOverride *BlockOverride
+	parentBlockInfo, err = noOverride.apply(vm.NewBlockInfo(10, big.NewInt(12345), "privatenet"))
+	require.Nil(err)
+	require.Equal(uint64(10), parentBlockInfo.Height)
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+)
+
+// ------------------------------- GetProof -----------------------------------
+
+type GetProofArgs struct {
+	Address     string            `json:"address"`
+	StorageKeys []common.Hash     `json:"storage_keys"` // optional, the storage slots of a smart contract to prove
+	Height      common.JSONUint64 `json:"height"`       // optional, prove against the state of the finalized block at the height
+	BlockHash   common.Hash       `json:"block_hash"`   // optional, prove against the state of the finalized block with the hash
+}
+
+type StorageProofResult struct {
+	Key   common.Hash     `json:"key"`
+	Value common.Hash     `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+type GetProofResult struct {
+	BlockHash    common.Hash           `json:"block_hash"`
+	BlockHeight  common.JSONUint64     `json:"block_height"`
+	StateHash    common.Hash           `json:"state_hash"`
+	Address      common.Address        `json:"address"`
+	Account      *types.Account        `json:"account"` // nil if the account does not exist
+	AccountProof []hexutil.Bytes       `json:"account_proof"`
+	StorageHash  common.Hash           `json:"storage_hash"`
+	StorageProof []*StorageProofResult `json:"storage_proof"`
+}
+
+// GetProof returns the merkle proof of an account against the StateHash of a finalized block, and
+// the merkle proofs of the given storage slots against the storage root of the account. By default
+// the latest finalized block is used. The proofs can be checked with state.VerifyAccountProof() and
+// state.VerifyStorageProof() without trusting the node.
+func (t *ScriptRPCService) GetProof(args *GetProofArgs, result *GetProofResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+
+	var block *core.ExtendedBlock
+	if !args.BlockHash.IsEmpty() {
+		block, err = t.chain.FindBlock(args.BlockHash)
+		if err != nil || !block.Status.IsFinalized() {
+			return fmt.Errorf("finalized block %v not found", args.BlockHash.Hex())
+		}
+	} else if args.Height != 0 {
+		block = t.findFinalizedBlockByHeight(uint64(args.Height))
+		if block == nil {
+			return fmt.Errorf("Historical data at height %v is not available on current node", args.Height)
+		}
+	} else {
+		block = t.consensus.GetLastFinalizedBlock()
+	}
+
+	proof, err := t.getProof(block, common.HexToAddress(args.Address), args.StorageKeys)
+	if err != nil {
+		return err
+	}
+	*result = *proof
+	return nil
+}
+
+// getProof returns the account and storage proofs against the state of the given block.
+func (t *ScriptRPCService) getProof(block *core.ExtendedBlock, address common.Address, storageKeys []common.Hash) (*GetProofResult, error) {
+	ledgerState, err := t.getStoreViewOfBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	accountProof, err := ledgerState.ProveAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	result := &GetProofResult{
+		BlockHash:    block.Hash(),
+		BlockHeight:  common.JSONUint64(block.Height),
+		StateHash:    block.StateHash,
+		Address:      address,
+		Account:      ledgerState.GetAccount(address),
+		AccountProof: toHexProof(accountProof),
+		StorageProof: []*StorageProofResult{},
+	}
+	if result.Account != nil {
+		result.StorageHash = result.Account.Root
+	}
+
+	for _, key := range storageKeys {
+		storageProof, err := ledgerState.ProveStorage(address, key)
+		if err != nil {
+			return nil, err
+		}
+		result.StorageProof = append(result.StorageProof, &StorageProofResult{
+			Key:   key,
+			Value: ledgerState.GetState(address, key),
+			Proof: toHexProof(storageProof),
+		})
+	}
+
+	return result, nil
+}
+
+func toHexProof(proof state.ProofList) []hexutil.Bytes {
+	nodes := make([]hexutil.Bytes, len(proof))
+	for i, node := range proof {
+		nodes[i] = hexutil.Bytes(node)
+	}
+	return nodes
+}
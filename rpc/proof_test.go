@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProof(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	alice := common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab")
+	contractAddr := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+	missing := common.HexToAddress("0x9f1233798e905e173560071255140b4a8abd3ec6")
+
+	storeView := state.NewStoreView(0, common.Hash{}, db)
+	aliceAccount := types.NewAccount(alice)
+	aliceAccount.Balance = types.NewCoins(1000, 2000)
+	aliceAccount.Sequence = 5
+	storeView.SetAccount(alice, aliceAccount)
+	storeView.SetCode(contractAddr, []byte{0x60, 0x00})
+	for i := int64(1); i <= 20; i++ {
+		storeView.SetState(contractAddr, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(100+i)))
+	}
+	stateRoot := storeView.Save()
+
+	core.CreateTestBlock("a0", "")
+	b1 := core.CreateTestBlock("b1", "a0")
+	b1.StateHash = stateRoot
+	chain := blockchain.CreateTestChainByBlocks([]string{"b1", "a0", "b2", "b1"})
+	b2 := core.CreateTestBlock("b2", "b1")
+	require.Nil(chain.FinalizePreviousBlocks(b2.Hash()))
+
+	service := &ScriptRPCService{
+		chain:  chain,
+		ledger: ledger.NewLedger("testchain", db, nil, chain, nil, nil, nil),
+	}
+
+	// Account proof
+	result := &GetProofResult{}
+	err := service.GetProof(&GetProofArgs{Address: alice.Hex(), BlockHash: b1.Hash()}, result)
+	require.Nil(err)
+	require.Equal(stateRoot, result.StateHash)
+	account, err := state.VerifyAccountProof(result.StateHash, alice, toProofList(result.AccountProof))
+	require.Nil(err)
+	require.Equal(big.NewInt(2000), account.Balance.SPAYWei)
+	require.Equal(uint64(5), account.Sequence)
+
+	// The proof of one account does not prove another one
+	_, err = state.VerifyAccountProof(result.StateHash, contractAddr, toProofList(result.AccountProof))
+	require.NotNil(err)
+
+	// Tampered proof
+	tampered := toProofList(result.AccountProof)
+	tampered[len(tampered)-1] = append(common.Bytes{}, tampered[len(tampered)-1]...)
+	tampered[len(tampered)-1][len(tampered[len(tampered)-1])-1] ^= 0x1
+	_, err = state.VerifyAccountProof(result.StateHash, alice, tampered)
+	require.NotNil(err)
+
+	// Proof of absence
+	result = &GetProofResult{}
+	err = service.GetProof(&GetProofArgs{Address: missing.Hex(), Height: common.JSONUint64(b1.Height)}, result)
+	require.Nil(err)
+	require.Nil(result.Account)
+	account, err = state.VerifyAccountProof(result.StateHash, missing, toProofList(result.AccountProof))
+	require.Nil(err)
+	require.Nil(account)
+
+	// Storage proofs
+	args := &GetProofArgs{}
+	require.Nil(json.Unmarshal([]byte(`{"address": "0x7ad6cea2bc3162e30a3c98d84f821b3233c22647", "height": "`+
+		new(big.Int).SetUint64(b1.Height).String()+`", "storage_keys": [`+
+		`"0x0000000000000000000000000000000000000000000000000000000000000007",`+
+		`"0x0000000000000000000000000000000000000000000000000000000000000099"]}`), args))
+	result = &GetProofResult{}
+	require.Nil(service.GetProof(args, result))
+	account, err = state.VerifyAccountProof(result.StateHash, contractAddr, toProofList(result.AccountProof))
+	require.Nil(err)
+	require.Equal(account.Root, result.StorageHash)
+	require.Equal(2, len(result.StorageProof))
+
+	value, err := state.VerifyStorageProof(result.StorageHash, result.StorageProof[0].Key, toProofList(result.StorageProof[0].Proof))
+	require.Nil(err)
+	require.Equal(common.BigToHash(big.NewInt(107)), value)
+	require.Equal(value, result.StorageProof[0].Value)
+
+	value, err = state.VerifyStorageProof(result.StorageHash, result.StorageProof[1].Key, toProofList(result.StorageProof[1].Proof))
+	require.Nil(err)
+	require.Equal(common.Hash{}, value)
+
+	// Not finalized
+	err = service.GetProof(&GetProofArgs{Address: alice.Hex(), BlockHash: b2.Hash()}, &GetProofResult{})
+	require.NotNil(err)
+}
+
+func toProofList(proof []hexutil.Bytes) state.ProofList {
+	list := state.ProofList{}
+	for _, node := range proof {
+		list = append(list, common.Bytes(node))
+	}
+	return list
+}
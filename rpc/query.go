@@ -6,16 +6,15 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
-	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
 	"github.com/scripttoken/script/blockchain"
-	"github.com/scripttoken/script/crypto/bls"
 
 	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
 	"github.com/scripttoken/script/core"
 	"github.com/scripttoken/script/crypto"
 	"github.com/scripttoken/script/ledger/state"
@@ -157,8 +156,26 @@ type GetTransactionResult struct {
 	TxHash         common.Hash                       `json:"hash"`
 	Type           byte                              `json:"type"`
 	Tx             types.Tx                          `json:"transaction"`
-	Receipt        *blockchain.TxReceiptEntry        `json:"receipt"`
+	Receipt        *TxReceipt                        `json:"receipt"`
 	BalanceChanges *blockchain.TxBalanceChangesEntry `json:"blance_changes"`
+	Outcome        *TxOutcome                        `json:"outcome,omitempty"` // why the transaction left the mempool, if not found in the chain
+}
+
+// TxOutcome describes why a transaction left, or was rejected by, the mempool.
+type TxOutcome struct {
+	Reason      string             `json:"reason"`
+	Message     string             `json:"message,omitempty"`
+	ReplacedBy  *common.Hash       `json:"replaced_by,omitempty"`
+	BlockHash   *common.Hash       `json:"block_hash,omitempty"`
+	BlockHeight *common.JSONUint64 `json:"block_height,omitempty"`
+	Timestamp   common.JSONUint64  `json:"timestamp"` // when the outcome was recorded, in unix seconds
+}
+
+// TxReceipt is the receipt of a smart contract transaction, with the VM error, if any,
+// classified and decoded.
+type TxReceipt struct {
+	*blockchain.TxReceiptEntry
+	EvmErrInfo *VmErrorInfo `json:",omitempty"`
 }
 
 type TxStatus string
@@ -168,8 +185,55 @@ const (
 	TxStatusPending   = "pending"
 	TxStatusFinalized = "finalized"
 	TxStatusAbandoned = "abandoned"
+	TxStatusReplaced  = "replaced"
+	TxStatusQueued    = "queued"
 )
 
+// toTxStatus converts the status of a transaction in the mempool.
+func toTxStatus(txStatus mempool.TxStatus) TxStatus {
+	switch txStatus {
+	case mempool.TxStatusAbandoned:
+		return TxStatusAbandoned
+	case mempool.TxStatusReplaced:
+		return TxStatusReplaced
+	case mempool.TxStatusQueued:
+		return TxStatusQueued
+	default:
+		return TxStatusPending
+	}
+}
+
+// outcomeTxStatus returns the status of a transaction no longer tracked by the mempool.
+func outcomeTxStatus(outcome mempool.TxOutcome) TxStatus {
+	switch outcome.Reason {
+	case mempool.TxReasonReplaced:
+		return TxStatusReplaced
+	case mempool.TxReasonIncluded:
+		return TxStatusPending // the block is not in the chain, e.g. it is being processed
+	default:
+		return TxStatusAbandoned
+	}
+}
+
+func toTxOutcome(outcome mempool.TxOutcome) *TxOutcome {
+	txOutcome := &TxOutcome{
+		Reason:    string(outcome.Reason),
+		Message:   outcome.Message,
+		Timestamp: common.JSONUint64(outcome.RecordedAt.Unix()),
+	}
+	if outcome.ReplacedBy != "" {
+		replacedBy := common.HexToHash(outcome.ReplacedBy)
+		txOutcome.ReplacedBy = &replacedBy
+	}
+	if outcome.Reason == mempool.TxReasonIncluded {
+		blockHash := outcome.BlockHash
+		blockHeight := common.JSONUint64(outcome.BlockHeight)
+		txOutcome.BlockHash = &blockHash
+		txOutcome.BlockHeight = &blockHeight
+	}
+	return txOutcome
+}
+
 func (t *ScriptRPCService) GetTransaction(args *GetTransactionArgs, result *GetTransactionResult) (err error) {
 	if args.Hash == "" {
 		return errors.New("Transanction hash must be specified")
@@ -178,16 +242,20 @@ func (t *ScriptRPCService) GetTransaction(args *GetTransactionArgs, result *GetT
 
 	raw, block, found := t.chain.FindTxByHash(hash)
 	if !found {
-		txStatus, exists := t.mempool.GetTransactionStatus(args.Hash)
+		mempoolTxHash := hex.EncodeToString(hash[:])
+		txStatus, exists := t.mempool.GetTransactionStatus(mempoolTxHash)
+		outcome, hasOutcome := t.mempool.GetTransactionOutcome(mempoolTxHash)
 		if exists {
-			if txStatus == mempool.TxStatusAbandoned {
-				result.Status = TxStatusAbandoned
-			} else {
-				result.Status = TxStatusPending
-			}
+			result.Status = toTxStatus(txStatus)
+		} else if hasOutcome {
+			result.Status = outcomeTxStatus(outcome)
 		} else {
 			result.Status = TxStatusNotFound
 		}
+		// An earlier outcome is outdated if the transaction has been submitted again since
+		if hasOutcome && (!exists || (txStatus != mempool.TxStatusPending && txStatus != mempool.TxStatusQueued)) {
+			result.Outcome = toTxOutcome(outcome)
+		}
 		return nil
 	}
 	result.BlockHash = block.Hash()
@@ -217,7 +285,10 @@ func (t *ScriptRPCService) GetTransaction(args *GetTransactionArgs, result *GetT
 	blockHash := block.Hash()
 	receipt, found := t.chain.FindTxReceiptByHash(blockHash, canonicalTxHash)
 	if found {
-		result.Receipt = receipt
+		result.Receipt = &TxReceipt{
+			TxReceiptEntry: receipt,
+			EvmErrInfo:     newVmErrorInfo(receipt.EvmErr, receipt.EvmRet),
+		}
 	}
 	balanceChanges, found := t.chain.FindTxBalanceChangesByHash(blockHash, canonicalTxHash)
 	if found {
@@ -307,6 +378,7 @@ const (
 	TxTypeWithdrawStake
 	TxTypeDepositStakeTxV2
 	TxTypeStakeRewardDistributionTx
+	TxTypeDoubleSignSlashTx
 )
 
 func (t *ScriptRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (err error) {
@@ -353,15 +425,7 @@ func (t *ScriptRPCService) GetBlockByHeight(args *GetBlockByHeightArgs, result *
 	// }
 
 	blockHeight := uint64(args.Height)
-	blocks := t.chain.FindBlocksByHeight(blockHeight)
-
-	var block *core.ExtendedBlock
-	for _, b := range blocks {
-		if b.Status.IsFinalized() {
-			block = b
-			break
-		}
-	}
+	block := t.findFinalizedBlockByHeight(blockHeight)
 
 	if blockHeight == 0 && block == nil { // special handling for a node starting from a non-genesis snapshot
 		var genesisHash common.Hash
@@ -745,21 +809,15 @@ type GetGuardianInfoResult struct {
 }
 
 func (t *ScriptRPCService) GetGuardianInfo(args *GetGuardianInfoArgs, result *GetGuardianInfoResult) (err error) {
-	privKey := t.consensus.PrivateKey()
-	blsKey, err := bls.GenKey(strings.NewReader(common.Bytes2Hex(privKey.PublicKey().ToBytes())))
-	if err != nil {
-		return fmt.Errorf("Failed to get BLS key: %v", err.Error())
-	}
-
-	result.Address = privKey.PublicKey().Address().Hex()
-	result.BLSPubkey = hex.EncodeToString(blsKey.PublicKey().ToBytes())
-	popBytes := blsKey.PopProve().ToBytes()
-	result.BLSPop = hex.EncodeToString(popBytes)
-
-	sig, err := privKey.Sign(popBytes)
+	signer := t.consensus.Signer()
+	pop, sig, err := signer.ProveBLSKey()
 	if err != nil {
 		return fmt.Errorf("Failed to generate signature: %v", err.Error())
 	}
+
+	result.Address = signer.PublicKey().Address().Hex()
+	result.BLSPubkey = hex.EncodeToString(signer.BLSPublicKey().ToBytes())
+	result.BLSPop = hex.EncodeToString(pop.ToBytes())
 	result.Signature = hex.EncodeToString(sig.ToBytes())
 
 	return nil
@@ -1099,8 +1157,119 @@ func (t *ScriptRPCService) GetStorageAt(args *GetStorageAtArgs, result *GetStora
 	return nil
 }
 
+// ------------------------------- GetLogs -----------------------------------
+
+type GetLogsArgs struct {
+	Addresses  []common.Address   `json:"addresses"`
+	Topics     [][]common.Hash    `json:"topics"`      // topics by position, a null or empty entry matches any topic
+	FromHeight *common.JSONUint64 `json:"from_height"` // defaults to to_height
+	ToHeight   common.JSONUint64  `json:"to_height"`   // defaults to the latest finalized height
+	BlockHash  common.Hash        `json:"block_hash"`
+}
+
+type GetLogsResult struct {
+	Logs []*LogResult `json:"logs"`
+}
+
+type LogResult struct {
+	Address     common.Address    `json:"address"`
+	Topics      []common.Hash     `json:"topics"`
+	Data        hexutil.Bytes     `json:"data"`
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	TxHash      common.Hash       `json:"tx_hash"`
+	EthTxHash   common.Hash       `json:"eth_tx_hash"`
+	TxIndex     common.JSONUint64 `json:"tx_index"`
+	LogIndex    common.JSONUint64 `json:"log_index"`
+}
+
+// GetLogs returns the contract logs matching the given addresses and topics, either within the
+// block with the given hash, or within the finalized blocks between from_height and to_height.
+func (t *ScriptRPCService) GetLogs(args *GetLogsArgs, result *GetLogsResult) (err error) {
+	criteria := &logFilterCriteria{
+		addresses: args.Addresses,
+		topics:    args.Topics,
+	}
+
+	var logs []*filteredLog
+	if !args.BlockHash.IsEmpty() {
+		if args.FromHeight != nil || args.ToHeight != 0 {
+			return errors.New("block_hash cannot be specified together with from_height or to_height")
+		}
+		logs, err = t.filterLogsByBlockHash(criteria, args.BlockHash)
+	} else {
+		toHeight := uint64(args.ToHeight)
+		if toHeight == 0 {
+			toHeight = t.consensus.GetLastFinalizedBlock().Height
+		}
+		fromHeight := toHeight
+		if args.FromHeight != nil {
+			fromHeight = uint64(*args.FromHeight)
+		}
+		logs, err = t.filterLogs(criteria, fromHeight, toHeight)
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Logs = make([]*LogResult, len(logs))
+	for i, log := range logs {
+		result.Logs[i] = newLogResult(log)
+	}
+	return nil
+}
+
+func newLogResult(log *filteredLog) *LogResult {
+	var ethTxHash common.Hash // 0x000...000 for Script native smart contract transactions
+	if log.TransactionHash != log.canonicalTxHash {
+		ethTxHash = log.TransactionHash
+	}
+	return &LogResult{
+		Address:     log.Address,
+		Topics:      log.Topics,
+		Data:        log.Data,
+		BlockHash:   log.BlockHash,
+		BlockHeight: common.JSONUint64(log.BlockNumber),
+		TxHash:      log.canonicalTxHash,
+		EthTxHash:   ethTxHash,
+		TxIndex:     common.JSONUint64(log.TransactionIndex),
+		LogIndex:    common.JSONUint64(log.LogIndex),
+	}
+}
+
 // ------------------------------ Utils ------------------------------
 
+// findFinalizedBlockByHeight returns the finalized block at the given height, or nil if the node
+// does not have it.
+func (t *ScriptRPCService) findFinalizedBlockByHeight(height uint64) *core.ExtendedBlock {
+	for _, b := range t.chain.FindBlocksByHeight(height) {
+		if b.Status.IsFinalized() {
+			return b
+		}
+	}
+	return nil
+}
+
+// getStoreViewByHeight returns a view of the ledger state right after the finalized block at the
+// given height was applied.
+func (t *ScriptRPCService) getStoreViewByHeight(height uint64) (*state.StoreView, error) {
+	block := t.findFinalizedBlockByHeight(height)
+	if block == nil {
+		return nil, fmt.Errorf("Historical data at height %v is not available on current node", height)
+	}
+	return t.getStoreViewOfBlock(block)
+}
+
+// getStoreViewOfBlock returns a view of the ledger state right after the given block was applied.
+func (t *ScriptRPCService) getStoreViewOfBlock(block *core.ExtendedBlock) (*state.StoreView, error) {
+	ledgerState := state.NewStoreView(block.Height, block.StateHash, t.ledger.State().DB())
+	if ledgerState == nil { // might have been pruned
+		return nil, fmt.Errorf("state pruned: the state of block %v at height %v is no longer available on current node",
+			block.Hash().Hex(), block.Height)
+	}
+	return ledgerState, nil
+}
+
 func (t *ScriptRPCService) gatherTxs(block *core.ExtendedBlock, txs *[]interface{}, includeEthTxHashes bool) error {
 	// Parse and fulfill Txs.
 	//var tx types.Tx
@@ -1176,6 +1345,8 @@ func getTxType(tx types.Tx) byte {
 		t = TxTypeDepositStakeTxV2
 	case *types.StakeRewardDistributionTx:
 		t = TxTypeStakeRewardDistributionTx
+	case *types.DoubleSignSlashTx:
+		t = TxTypeDoubleSignSlashTx
 	}
 
 	return t
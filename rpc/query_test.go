@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/blockchain"
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/consensus"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/mempool"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/scripttoken/script/store/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxOutcome(t *testing.T) {
+	assert := assert.New(t)
+
+	replacedBy := common.HexToHash("0xa1")
+	outcome := mempool.TxOutcome{
+		Reason:     mempool.TxReasonReplaced,
+		ReplacedBy: replacedBy.Hex()[2:],
+		RecordedAt: time.Unix(1700000000, 0),
+	}
+	assert.Equal(TxStatus(TxStatusReplaced), outcomeTxStatus(outcome))
+	txOutcome := toTxOutcome(outcome)
+	assert.Equal("replaced", txOutcome.Reason)
+	assert.Equal(replacedBy, *txOutcome.ReplacedBy)
+	assert.Nil(txOutcome.BlockHash)
+
+	outcome = mempool.TxOutcome{
+		Reason:  mempool.TxReasonScreeningFailed,
+		Message: "Insufficient fund",
+	}
+	assert.Equal(TxStatus(TxStatusAbandoned), outcomeTxStatus(outcome))
+	encoded, err := json.Marshal(toTxOutcome(outcome))
+	assert.Nil(err)
+	assert.NotContains(string(encoded), "replaced_by")
+	assert.Contains(string(encoded), `"message":"Insufficient fund"`)
+
+	outcome = mempool.TxOutcome{
+		Reason:      mempool.TxReasonIncluded,
+		BlockHash:   common.HexToHash("0xb1"),
+		BlockHeight: 12,
+	}
+	assert.Equal(TxStatus(TxStatusPending), outcomeTxStatus(outcome))
+	txOutcome = toTxOutcome(outcome)
+	assert.Equal(common.HexToHash("0xb1"), *txOutcome.BlockHash)
+	assert.Equal(common.JSONUint64(12), *txOutcome.BlockHeight)
+}
+
+func TestGetLogsDefaultRange(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	contract := common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647")
+
+	// The chain is longer than the maximum block range of a query
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.NewBlock()
+	root.ChainID = "testchain"
+	root.Height = 2 * maxLogsQueryBlockRange
+	root.Timestamp = big.NewInt(time.Now().Unix())
+	chain := blockchain.NewChain("testchain", store, root)
+
+	parent := root
+	for i := 0; i < 2; i++ {
+		sctx := newTestSignedSmartContractTx(privKey, uint64(i+1))
+		raw, err := types.TxToBytes(sctx)
+		require.Nil(err)
+		block := core.NewBlock()
+		block.ChainID = "testchain"
+		block.Height = parent.Height + 1
+		block.Parent = parent.Hash()
+		block.Txs = []common.Bytes{raw}
+		_, err = chain.AddBlock(block)
+		require.Nil(err)
+		chain.AddTxReceipt(block, sctx, []*types.Log{{Address: contract}}, nil, nil, common.Address{}, 100, nil)
+		parent = block
+	}
+	require.Nil(chain.FinalizePreviousBlocks(parent.Hash()))
+	lfb, err := chain.FindBlock(parent.Hash())
+	require.Nil(err)
+
+	engine := consensus.NewConsensusEngine(nil, store, chain, nil, nil)
+	engine.State().SetLastFinalizedBlock(lfb)
+	service := &ScriptRPCService{chain: chain, consensus: engine}
+
+	// Both heights default to the latest finalized height
+	result := &GetLogsResult{}
+	require.Nil(service.GetLogs(&GetLogsArgs{Addresses: []common.Address{contract}}, result))
+	require.Equal(1, len(result.Logs))
+	require.Equal(common.JSONUint64(lfb.Height), result.Logs[0].BlockHeight)
+
+	// from_height defaults to to_height
+	result = &GetLogsResult{}
+	require.Nil(service.GetLogs(&GetLogsArgs{ToHeight: common.JSONUint64(lfb.Height - 1)}, result))
+	require.Equal(1, len(result.Logs))
+	require.Equal(common.JSONUint64(lfb.Height-1), result.Logs[0].BlockHeight)
+
+	fromHeight := common.JSONUint64(lfb.Height - 1)
+	result = &GetLogsResult{}
+	require.Nil(service.GetLogs(&GetLogsArgs{FromHeight: &fromHeight}, result))
+	require.Equal(2, len(result.Logs))
+
+	// An explicit range is still limited
+	fromHeight = common.JSONUint64(0)
+	require.NotNil(service.GetLogs(&GetLogsArgs{FromHeight: &fromHeight}, &GetLogsResult{}))
+
+	var args GetLogsArgs
+	require.Nil(json.Unmarshal([]byte(`{"to_height":"5"}`), &args))
+	require.Nil(args.FromHeight)
+	require.Nil(json.Unmarshal([]byte(`{"from_height":"0"}`), &args))
+	require.Equal(common.JSONUint64(0), *args.FromHeight)
+}
@@ -31,7 +31,7 @@ import (
 	"golang.org/x/net/websocket"
 )
 
-var logger *log.Entry
+var logger *log.Entry = util.GetLoggerForModule("rpc")
 
 type ScriptRPCService struct {
 	mempool    *mempool.Mempool
@@ -40,6 +40,8 @@ type ScriptRPCService struct {
 	chain      *blockchain.Chain
 	consensus  *consensus.ConsensusEngine
 
+	subscriptions *SubscriptionManager
+
 	pendingHeavyGetBlocksCounter           uint64
 	pendingHeavyGetBlocksCounterLock       *sync.Mutex
 	pendingHeavyGetBlocksCounterResetTimer *timer.RepeatTimer
@@ -80,9 +82,13 @@ func NewScriptRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, dispatc
 	t.dispatcher = dispatcher
 	t.chain = chain
 	t.consensus = consensus
+	t.subscriptions = NewSubscriptionManager(viper.GetInt(common.CfgRPCSubscriptionBufferSize),
+		viper.GetInt(common.CfgRPCMaxSubscriptionsPerConn))
 
 	s := rpc.NewServer()
 	s.RegisterName("script", t.ScriptRPCService)
+	s.RegisterName("eth", NewEthRPCService(t.ScriptRPCService))
+	s.RegisterName("debug", NewDebugRPCService(t.ScriptRPCService))
 
 	t.handler = s
 
@@ -90,7 +96,9 @@ func NewScriptRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, dispatc
 	t.router.Handle("/", &defaultHTTPHandler{})
 	t.router.Handle("/rpc", corsMiddleware(TimeoutHandler(jsonrpc2.HTTPHandler(s), viper.GetDuration(common.CfgRPCTimeoutSecs)*time.Second, "")))
 	t.router.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
-		s.ServeCodec(jsonrpc2.NewServerCodec(ws, s))
+		ctx, conn := t.subscriptions.AddConn(context.Background(), ws)
+		defer t.subscriptions.RemoveConn(conn)
+		s.ServeCodec(jsonrpc2.NewServerCodecContext(ctx, ws, s))
 	}))
 
 	t.server = &http.Server{
@@ -98,7 +106,7 @@ func NewScriptRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, dispatc
 		IdleTimeout: viper.GetDuration(common.CfgRPCIdleTimeoutSecs) * time.Second,
 	}
 
-	logger = util.GetLoggerForModule("rpc")
+	logger = util.GetLoggerForModule("rpc") // picks up the configured log level
 
 	return t
 }
@@ -117,6 +125,9 @@ func (t *ScriptRPCServer) Start(ctx context.Context) {
 
 	t.wg.Add(1)
 	go t.txCallback()
+
+	t.wg.Add(1)
+	go t.pendingTxLoop()
 }
 
 func (t *ScriptRPCServer) mainLoop() {
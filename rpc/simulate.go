@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+)
+
+// ------------------------------- SimulateTransactions -----------------------------------
+
+type SimulateTransactionsArgs struct {
+	TxBytes  []string `json:"tx_bytes"` // the raw transactions, executed in the given order
+	Screened bool     `json:"screened"` // optional, simulate against the screened state, i.e. including the txs accepted by the mempool
+}
+
+type SimulatedTxResult struct {
+	TxHash          common.Hash            `json:"hash"`
+	Success         bool                   `json:"success"`
+	Error           string                 `json:"error,omitempty"`
+	GasUsed         common.JSONUint64      `json:"gas_used"` // smart contract transactions only
+	VmReturn        string                 `json:"vm_return,omitempty"`
+	ContractAddress *common.Address        `json:"contract_address,omitempty"`
+	VmError         string                 `json:"vm_error,omitempty"`
+	VmErrorInfo     *VmErrorInfo           `json:"vm_error_info,omitempty"`
+	Logs            []*types.Log           `json:"logs"`
+	BalanceChanges  []*types.BalanceChange `json:"balance_changes"`
+	Accounts        []*GetAccountResult    `json:"accounts"` // the accounts touched by the tx, after its execution
+}
+
+type SimulateTransactionsResult struct {
+	Results []*SimulatedTxResult `json:"results"`
+}
+
+// SimulateTransactions dry-runs a bundle of raw transactions one after another against a private
+// copy of the delivered state, or of the screened state if requested. Each transaction sees the state
+// changes of the preceding ones, and a failed transaction does not stop the simulation of the following
+// ones. Nothing is committed, so it can be used for pre-flight checks of a sequence of transactions.
+func (t *ScriptRPCService) SimulateTransactions(args *SimulateTransactionsArgs, result *SimulateTransactionsResult) (err error) {
+	if len(args.TxBytes) == 0 {
+		return errors.New("No transaction to simulate")
+	}
+
+	rawTxs := []common.Bytes{}
+	for i, txBytes := range args.TxBytes {
+		rawTx, err := hex.DecodeString(txBytes)
+		if err != nil {
+			return fmt.Errorf("Failed to decode transaction %v: %v", i, err)
+		}
+		rawTxs = append(rawTxs, rawTx)
+	}
+
+	simResults, err := t.ledger.SimulateTxs(rawTxs, args.Screened)
+	if err != nil {
+		return err
+	}
+
+	result.Results = []*SimulatedTxResult{}
+	for i, simResult := range simResults {
+		txResult := &SimulatedTxResult{
+			TxHash:         crypto.Keccak256Hash(rawTxs[i]),
+			Logs:           []*types.Log{},
+			BalanceChanges: []*types.BalanceChange{},
+			Accounts:       []*GetAccountResult{},
+		}
+		result.Results = append(result.Results, txResult)
+
+		if simResult.Result.IsError() {
+			txResult.Error = simResult.Result.Message
+			continue
+		}
+		txResult.Success = true
+
+		if scResult := simResult.SmartContractResult; scResult != nil {
+			txResult.GasUsed = common.JSONUint64(scResult.GasUsed)
+			txResult.VmReturn = hex.EncodeToString(scResult.EvmRet)
+			if (scResult.ContractAddress != common.Address{}) {
+				contractAddr := scResult.ContractAddress
+				txResult.ContractAddress = &contractAddr
+			}
+			if scResult.EvmErr != nil {
+				txResult.Success = false
+				txResult.VmError = scResult.EvmErr.Error()
+				txResult.VmErrorInfo = newVmErrorInfo(txResult.VmError, scResult.EvmRet)
+			}
+			if scResult.Logs != nil {
+				txResult.Logs = scResult.Logs
+			}
+			if scResult.BalanceChanges != nil {
+				txResult.BalanceChanges = scResult.BalanceChanges
+			}
+		}
+
+		for addr, account := range simResult.Accounts {
+			txResult.Accounts = append(txResult.Accounts, &GetAccountResult{
+				Account: account,
+				Address: addr.Hex(),
+			})
+		}
+		sort.Slice(txResult.Accounts, func(i, j int) bool {
+			return bytes.Compare(txResult.Accounts[i].Account.Address[:], txResult.Accounts[j].Account.Address[:]) < 0
+		})
+	}
+
+	return nil
+}
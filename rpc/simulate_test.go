@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/ledger"
+	"github.com/scripttoken/script/ledger/state"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/ledger/vm"
+	"github.com/scripttoken/script/store/database/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateTransactions(t *testing.T) {
+	require := require.New(t)
+
+	chainID := "testchain"
+	db := backend.NewMemDatabase()
+	alice := types.MakeAccWithInitBalance("alice", types.NewCoins(1e15, 1e17))
+	alice.CodeHash = types.EmptyCodeHash
+	bob := types.MakeAccWithInitBalance("bob", types.NewCoins(0, 0))
+
+	storeView := state.NewStoreView(0, common.Hash{}, db)
+	storeView.SetAccount(alice.Address, &alice.Account)
+	stateRoot := storeView.Save()
+
+	service := &ScriptRPCService{
+		ledger: ledger.NewLedger(chainID, db, nil, nil, nil, nil, nil),
+	}
+	res := service.ledger.ResetState(&core.Block{
+		BlockHeader: &core.BlockHeader{
+			ChainID:   chainID,
+			Height:    0,
+			StateHash: stateRoot,
+			Timestamp: big.NewInt(1600000000),
+		},
+	})
+	require.True(res.IsOK())
+
+	fee := types.GetSendTxMinimumTransactionFeeSPAYWei(2, 1)
+	newSendTx := func(sequence uint64, amount int64) string {
+		tx := &types.SendTx{
+			Fee: types.Coins{SCPTWei: big.NewInt(0), SPAYWei: fee},
+			Inputs: []types.TxInput{{
+				Address:  alice.Address,
+				Coins:    types.Coins{SCPTWei: big.NewInt(amount), SPAYWei: fee},
+				Sequence: sequence,
+			}},
+			Outputs: []types.TxOutput{{
+				Address: bob.Address,
+				Coins:   types.NewCoins(amount, 0),
+			}},
+		}
+		tx.Inputs[0].Signature = alice.Sign(tx.SignBytes(chainID))
+		raw, err := types.TxToBytes(tx)
+		require.Nil(err)
+		return hex.EncodeToString(raw)
+	}
+
+	// ASM: push 0x0, push 0x0, revert
+	code, _ := hex.DecodeString("60006000fd")
+	sctx := &types.SmartContractTx{
+		From: types.TxInput{
+			Address:  alice.Address,
+			Coins:    types.NewCoins(0, 0),
+			Sequence: 3,
+		},
+		GasLimit: 100000,
+		GasPrice: types.GetMinimumGasPrice(1),
+		Data:     code,
+	}
+	sctx.From.Signature = alice.Sign(sctx.SignBytes(chainID))
+	sctxBytes, err := types.TxToBytes(sctx)
+	require.Nil(err)
+
+	result := &SimulateTransactionsResult{}
+	err = service.SimulateTransactions(&SimulateTransactionsArgs{
+		TxBytes: []string{
+			newSendTx(1, 100),
+			newSendTx(2, 200),
+			hex.EncodeToString(sctxBytes),
+			newSendTx(3, 300), // the sequence was consumed by the reverted smart contract tx
+		},
+	}, result)
+	require.Nil(err)
+	require.Equal(4, len(result.Results))
+
+	// The second send sees the state changes of the first one
+	require.True(result.Results[0].Success)
+	require.True(result.Results[1].Success)
+	require.Equal(2, len(result.Results[1].Accounts))
+	for _, account := range result.Results[1].Accounts {
+		if account.Account.Address == bob.Address {
+			require.Equal(big.NewInt(300), account.Balance.SCPTWei)
+		} else {
+			require.Equal(uint64(2), account.Sequence)
+		}
+	}
+
+	require.False(result.Results[2].Success)
+	require.Equal("", result.Results[2].Error)
+	require.NotNil(result.Results[2].VmErrorInfo)
+	require.Equal(vm.ErrorCodeExecutionReverted, result.Results[2].VmErrorInfo.Code)
+	require.NotEqual(uint64(0), uint64(result.Results[2].GasUsed))
+
+	require.False(result.Results[3].Success)
+	require.NotEqual("", result.Results[3].Error)
+
+	// Nothing is committed
+	view, err := service.ledger.GetDeliveredSnapshot()
+	require.Nil(err)
+	require.Equal(uint64(0), view.GetAccount(alice.Address).Sequence)
+	require.Nil(view.GetAccount(bob.Address))
+}
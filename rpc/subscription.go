@@ -0,0 +1,394 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/hexutil"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/scripttoken/script/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+const (
+	SubscriptionTopicNewBlocks  = "new_blocks"
+	SubscriptionTopicPendingTxs = "pending_txs"
+	SubscriptionTopicLogs       = "logs"
+
+	subscriptionNotificationMethod = "script.Subscription"
+)
+
+type subscriberConnKey struct{}
+
+// subscription is a subscription of a websocket connection to one of the topics.
+type subscription struct {
+	id       string
+	topic    string
+	criteria *logFilterCriteria // only for the logs topic
+	conn     *subscriberConn
+}
+
+// subscriberConn buffers the notifications for a websocket connection. The notifications
+// are written out by a dedicated goroutine, so a slow connection never blocks the publisher.
+type subscriberConn struct {
+	writer io.WriteCloser
+	out    chan []byte
+	subs   map[string]*subscription
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *subscriberConn) writeLoop() {
+	for {
+		select {
+		case msg := <-c.out:
+			if _, err := c.writer.Write(msg); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *subscriberConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		go c.writer.Close() // Close might block while a write is in progress
+	})
+}
+
+// SubscriptionManager keeps track of the subscriptions of all the websocket connections and
+// fans out the notifications to them.
+type SubscriptionManager struct {
+	mu                      *sync.Mutex
+	conns                   map[*subscriberConn]bool
+	subs                    map[string]*subscription
+	bufferSize              int
+	maxSubscriptionsPerConn int
+}
+
+// NewSubscriptionManager creates a new instance of SubscriptionManager.
+func NewSubscriptionManager(bufferSize int, maxSubscriptionsPerConn int) *SubscriptionManager {
+	return &SubscriptionManager{
+		mu:                      &sync.Mutex{},
+		conns:                   make(map[*subscriberConn]bool),
+		subs:                    make(map[string]*subscription),
+		bufferSize:              bufferSize,
+		maxSubscriptionsPerConn: maxSubscriptionsPerConn,
+	}
+}
+
+// AddConn registers a websocket connection and returns a context carrying it, which should be
+// passed to the server codec so that the Subscribe calls can find the connection.
+func (m *SubscriptionManager) AddConn(ctx context.Context, writer io.WriteCloser) (context.Context, *subscriberConn) {
+	conn := &subscriberConn{
+		writer: writer,
+		out:    make(chan []byte, m.bufferSize),
+		subs:   make(map[string]*subscription),
+		done:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.conns[conn] = true
+	m.mu.Unlock()
+
+	go conn.writeLoop()
+
+	return context.WithValue(ctx, subscriberConnKey{}, conn), conn
+}
+
+// RemoveConn removes a websocket connection together with all its subscriptions.
+func (m *SubscriptionManager) RemoveConn(conn *subscriberConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeConnUnsafe(conn)
+}
+
+func (m *SubscriptionManager) removeConnUnsafe(conn *subscriberConn) {
+	if !m.conns[conn] {
+		return
+	}
+	for id := range conn.subs {
+		delete(m.subs, id)
+	}
+	delete(m.conns, conn)
+	conn.close()
+}
+
+func (m *SubscriptionManager) subscribe(conn *subscriberConn, topic string, criteria *logFilterCriteria) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.conns[conn] {
+		return "", errors.New("connection closed")
+	}
+	if len(conn.subs) >= m.maxSubscriptionsPerConn {
+		return "", fmt.Errorf("too many subscriptions, at most %v are allowed per connection", m.maxSubscriptionsPerConn)
+	}
+
+	id := newSubscriptionID()
+	sub := &subscription{
+		id:       id,
+		topic:    topic,
+		criteria: criteria,
+		conn:     conn,
+	}
+	conn.subs[id] = sub
+	m.subs[id] = sub
+	return id, nil
+}
+
+func (m *SubscriptionManager) unsubscribe(conn *subscriberConn, id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subs[id]
+	if !ok || sub.conn != conn {
+		return false
+	}
+	delete(conn.subs, id)
+	delete(m.subs, id)
+	return true
+}
+
+// hasSubscribers returns true if there is at least one subscription to the topic.
+func (m *SubscriptionManager) hasSubscribers(topic string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sub := range m.subs {
+		if sub.topic == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// publish notifies the subscriptions to the topic which accept the result. The connections
+// whose buffer is full are dropped.
+func (m *SubscriptionManager) publish(topic string, accept func(*subscription) interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sub := range m.subs {
+		if sub.topic != topic {
+			continue
+		}
+		result := accept(sub)
+		if result == nil {
+			continue
+		}
+		msg, err := json.Marshal(subscriptionNotification{
+			Version: "2.0",
+			Method:  subscriptionNotificationMethod,
+			Params: subscriptionNotificationParams{
+				Subscription: sub.id,
+				Result:       result,
+			},
+		})
+		if err != nil {
+			logger.Warnf("Failed to encode subscription notification: %v", err)
+			continue
+		}
+		select {
+		case sub.conn.out <- msg:
+		default:
+			logger.Infof("Dropping slow subscriber connection, subscription: %v", sub.id)
+			m.removeConnUnsafe(sub.conn)
+		}
+	}
+}
+
+type subscriptionNotification struct {
+	Version string                         `json:"jsonrpc"`
+	Method  string                         `json:"method"`
+	Params  subscriptionNotificationParams `json:"params"`
+}
+
+type subscriptionNotificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+func newSubscriptionID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hexutil.Encode(id)
+}
+
+// ------------------------------- Subscribe -----------------------------------
+
+type SubscribeArgs struct {
+	jsonrpc2.Ctx
+	Topic     string           `json:"topic"`
+	Addresses []common.Address `json:"addresses"` // logs topic only
+	Topics    [][]common.Hash  `json:"topics"`    // logs topic only
+}
+
+type SubscribeResult struct {
+	Subscription string `json:"subscription"`
+}
+
+// Subscribe subscribes the websocket connection to the new finalized blocks, the transactions
+// entering the mempool, or the logs emitted by the finalized blocks. The notifications are sent
+// as "script.Subscription" JSON-RPC notifications.
+func (t *ScriptRPCService) Subscribe(args *SubscribeArgs, result *SubscribeResult) (err error) {
+	conn, err := subscriberConnFromContext(args.Context())
+	if err != nil {
+		return err
+	}
+
+	var criteria *logFilterCriteria
+	switch args.Topic {
+	case SubscriptionTopicNewBlocks, SubscriptionTopicPendingTxs:
+	case SubscriptionTopicLogs:
+		criteria = &logFilterCriteria{
+			addresses: args.Addresses,
+			topics:    args.Topics,
+		}
+	default:
+		return fmt.Errorf("unknown subscription topic: %v", args.Topic)
+	}
+
+	result.Subscription, err = t.subscriptions.subscribe(conn, args.Topic, criteria)
+	return err
+}
+
+// ------------------------------- Unsubscribe -----------------------------------
+
+type UnsubscribeArgs struct {
+	jsonrpc2.Ctx
+	Subscription string `json:"subscription"`
+}
+
+type UnsubscribeResult struct {
+	Success bool `json:"success"`
+}
+
+func (t *ScriptRPCService) Unsubscribe(args *UnsubscribeArgs, result *UnsubscribeResult) (err error) {
+	conn, err := subscriberConnFromContext(args.Context())
+	if err != nil {
+		return err
+	}
+	result.Success = t.subscriptions.unsubscribe(conn, args.Subscription)
+	return nil
+}
+
+func subscriberConnFromContext(ctx context.Context) (*subscriberConn, error) {
+	if ctx != nil {
+		if conn, ok := ctx.Value(subscriberConnKey{}).(*subscriberConn); ok {
+			return conn, nil
+		}
+	}
+	return nil, errors.New("subscriptions are only supported over websocket connections")
+}
+
+// ------------------------------ Notifications ------------------------------
+
+type NewBlockNotification struct {
+	ChainID   string            `json:"chain_id"`
+	Epoch     common.JSONUint64 `json:"epoch"`
+	Height    common.JSONUint64 `json:"height"`
+	Parent    common.Hash       `json:"parent"`
+	TxHash    common.Hash       `json:"transactions_hash"`
+	StateHash common.Hash       `json:"state_hash"`
+	Timestamp *common.JSONBig   `json:"timestamp"`
+	Proposer  common.Address    `json:"proposer"`
+	Hash      common.Hash       `json:"hash"`
+	TxHashes  []common.Hash     `json:"tx_hashes"`
+}
+
+type PendingTxNotification struct {
+	Hash common.Hash `json:"hash"`
+	Type byte        `json:"type"`
+}
+
+// publishFinalizedBlock notifies the subscribers of the new blocks and of the logs.
+func (t *ScriptRPCService) publishFinalizedBlock(block *core.Block) {
+	if t.subscriptions.hasSubscribers(SubscriptionTopicNewBlocks) {
+		notification := &NewBlockNotification{
+			ChainID:   block.ChainID,
+			Epoch:     common.JSONUint64(block.Epoch),
+			Height:    common.JSONUint64(block.Height),
+			Parent:    block.Parent,
+			TxHash:    block.TxHash,
+			StateHash: block.StateHash,
+			Timestamp: (*common.JSONBig)(block.Timestamp),
+			Proposer:  block.Proposer,
+			Hash:      block.Hash(),
+			TxHashes:  []common.Hash{},
+		}
+		for _, raw := range block.Txs {
+			notification.TxHashes = append(notification.TxHashes, crypto.Keccak256Hash(raw))
+		}
+		t.subscriptions.publish(SubscriptionTopicNewBlocks, func(*subscription) interface{} {
+			return notification
+		})
+	}
+
+	if t.subscriptions.hasSubscribers(SubscriptionTopicLogs) {
+		extendedBlock, err := t.chain.FindBlock(block.Hash())
+		if err != nil {
+			logger.Warnf("Failed to find finalized block %v: %v", block.Hash().Hex(), err)
+			return
+		}
+		logs, err := t.filterBlockLogs(&logFilterCriteria{}, extendedBlock)
+		if err != nil {
+			logger.Warnf("Failed to retrieve the logs of block %v: %v", block.Hash().Hex(), err)
+			return
+		}
+		for _, log := range logs {
+			notification := newLogResult(log)
+			t.subscriptions.publish(SubscriptionTopicLogs, func(sub *subscription) interface{} {
+				if !sub.criteria.matches(log.Address, log.Topics) {
+					return nil
+				}
+				return notification
+			})
+		}
+	}
+}
+
+// publishPendingTx notifies the subscribers of the pending transactions.
+func (t *ScriptRPCService) publishPendingTx(raw common.Bytes) {
+	if !t.subscriptions.hasSubscribers(SubscriptionTopicPendingTxs) {
+		return
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return
+	}
+	notification := &PendingTxNotification{
+		Hash: crypto.Keccak256Hash(raw),
+		Type: getTxType(tx),
+	}
+	t.subscriptions.publish(SubscriptionTopicPendingTxs, func(*subscription) interface{} {
+		return notification
+	})
+}
+
+func (t *ScriptRPCService) pendingTxLoop() {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case raw := <-t.mempool.InsertedTxs():
+			t.publishPendingTx(raw)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSubscriberWriter struct {
+	mu      sync.Mutex
+	msgs    [][]byte
+	blocked chan struct{}
+	closed  bool
+}
+
+func (w *testSubscriberWriter) Write(msg []byte) (int, error) {
+	if w.blocked != nil {
+		<-w.blocked
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msg)
+	return len(msg), nil
+}
+
+func (w *testSubscriberWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *testSubscriberWriter) numMsgs() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.msgs)
+}
+
+func TestSubscriptionManager(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	m := NewSubscriptionManager(4, 2)
+
+	writer := &testSubscriberWriter{}
+	ctx, conn := m.AddConn(context.Background(), writer)
+	c, err := subscriberConnFromContext(ctx)
+	require.Nil(err)
+	require.Equal(conn, c)
+
+	_, err = subscriberConnFromContext(context.Background())
+	assert.NotNil(err)
+
+	id1, err := m.subscribe(conn, SubscriptionTopicNewBlocks, nil)
+	require.Nil(err)
+	id2, err := m.subscribe(conn, SubscriptionTopicPendingTxs, nil)
+	require.Nil(err)
+	_, err = m.subscribe(conn, SubscriptionTopicLogs, &logFilterCriteria{})
+	assert.NotNil(err) // exceeds the per connection limit
+
+	assert.True(m.hasSubscribers(SubscriptionTopicNewBlocks))
+	assert.False(m.hasSubscribers(SubscriptionTopicLogs))
+
+	m.publish(SubscriptionTopicNewBlocks, func(*subscription) interface{} { return "block" })
+	require.Eventually(func() bool { return writer.numMsgs() == 1 }, time.Second, 10*time.Millisecond)
+
+	var notification subscriptionNotification
+	require.Nil(json.Unmarshal(writer.msgs[0], &notification))
+	assert.Equal(subscriptionNotificationMethod, notification.Method)
+	assert.Equal(id1, notification.Params.Subscription)
+	assert.Equal("block", notification.Params.Result)
+
+	assert.False(m.unsubscribe(conn, "0x1234"))
+	assert.True(m.unsubscribe(conn, id2))
+	assert.False(m.hasSubscribers(SubscriptionTopicPendingTxs))
+
+	m.RemoveConn(conn)
+	assert.False(m.hasSubscribers(SubscriptionTopicNewBlocks))
+}
+
+func TestSubscriptionManagerDropsSlowSubscriber(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	m := NewSubscriptionManager(2, 8)
+
+	slowWriter := &testSubscriberWriter{blocked: make(chan struct{})}
+	_, slowConn := m.AddConn(context.Background(), slowWriter)
+	_, err := m.subscribe(slowConn, SubscriptionTopicNewBlocks, nil)
+	require.Nil(err)
+
+	fastWriter := &testSubscriberWriter{}
+	_, fastConn := m.AddConn(context.Background(), fastWriter)
+	_, err = m.subscribe(fastConn, SubscriptionTopicNewBlocks, nil)
+	require.Nil(err)
+
+	// The slow writer holds one message in flight and buffers two more, after which
+	// it gets dropped. The publisher is never blocked.
+	for i := 0; i < 8; i++ {
+		m.publish(SubscriptionTopicNewBlocks, func(*subscription) interface{} { return i })
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	m.mu.Lock()
+	assert.False(m.conns[slowConn])
+	assert.True(m.conns[fastConn])
+	m.mu.Unlock()
+
+	require.Eventually(func() bool { return fastWriter.numMsgs() == 8 }, time.Second, 10*time.Millisecond)
+	close(slowWriter.blocked)
+}
@@ -0,0 +1,111 @@
+package signer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/crypto/bls"
+	"github.com/scripttoken/script/ledger/types"
+)
+
+var _ core.Signer = (*LocalSigner)(nil)
+
+// LocalSigner signs with the node key held in memory.
+type LocalSigner struct {
+	privKey *crypto.PrivateKey
+	blsKey  *bls.SecretKey
+}
+
+// NewLocalSigner creates a new instance of LocalSigner. The guardian BLS key is derived from the
+// node key.
+func NewLocalSigner(privKey *crypto.PrivateKey) (*LocalSigner, error) {
+	blsKey, err := bls.GenKey(strings.NewReader(common.Bytes2Hex(privKey.PublicKey().ToBytes())))
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{
+		privKey: privKey,
+		blsKey:  blsKey,
+	}, nil
+}
+
+// PublicKey implements the core.Signer interface.
+func (s *LocalSigner) PublicKey() *crypto.PublicKey {
+	return s.privKey.PublicKey()
+}
+
+// BLSPublicKey implements the core.Signer interface.
+func (s *LocalSigner) BLSPublicKey() *bls.PublicKey {
+	return s.blsKey.PublicKey()
+}
+
+// SignVote implements the core.Signer interface.
+func (s *LocalSigner) SignVote(vote *core.Vote) error {
+	sig, err := s.privKey.Sign(vote.SignBytes())
+	if err != nil {
+		return err
+	}
+	vote.SetSignature(sig)
+	return nil
+}
+
+// SignProposal implements the core.Signer interface.
+func (s *LocalSigner) SignProposal(header *core.BlockHeader) error {
+	sig, err := s.privKey.Sign(header.SignBytes())
+	if err != nil {
+		return err
+	}
+	header.SetSignature(sig)
+	return nil
+}
+
+// SignTx implements the core.Signer interface.
+func (s *LocalSigner) SignTx(chainID string, rawTx common.Bytes) (*crypto.Signature, error) {
+	_, signBytes, err := decodeProposerTx(chainID, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	return s.privKey.Sign(signBytes)
+}
+
+// decodeProposerTx decodes a transaction issued by the block proposer, and returns its proposer
+// and its sign bytes. The other transactions are refused, so that the node key cannot be used to
+// sign e.g. transfers.
+func decodeProposerTx(chainID string, rawTx common.Bytes) (common.Address, common.Bytes, error) {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		return tx.Proposer.Address, tx.SignBytes(chainID), nil
+	case *types.DoubleSignSlashTx:
+		return tx.Proposer.Address, tx.SignBytes(chainID), nil
+	default:
+		return common.Address{}, nil, fmt.Errorf("transaction of type %T cannot be signed with the node key", tx)
+	}
+}
+
+// SignGuardianVote implements the core.Signer interface.
+func (s *LocalSigner) SignGuardianVote(vote *core.AggregatedVotes, signerIdx int) error {
+	vote.Sign(s.blsKey, signerIdx)
+	return nil
+}
+
+// ProveVRF implements the core.Signer interface.
+func (s *LocalSigner) ProveVRF(msg common.Bytes) (common.Hash, common.Bytes, error) {
+	return s.privKey.VRFProve(msg)
+}
+
+// ProveBLSKey implements the core.Signer interface.
+func (s *LocalSigner) ProveBLSKey() (*bls.Signature, *crypto.Signature, error) {
+	pop := s.blsKey.PopProve()
+	sig, err := s.privKey.Sign(pop.ToBytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return pop, sig, nil
+}
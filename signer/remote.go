@@ -0,0 +1,229 @@
+package signer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/crypto/bls"
+	"github.com/scripttoken/script/rlp"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ core.Signer = (*RemoteSigner)(nil)
+
+var errSignerTimeout = errors.New("signer request timed out")
+
+// RemoteSigner delegates the signing to an external signer process, so that the node key never
+// lives on the node host.
+type RemoteSigner struct {
+	mu        *sync.Mutex
+	address   string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	client    *rpc.Client
+
+	pubKey    *crypto.PublicKey
+	blsPubKey *bls.PublicKey
+}
+
+// NewRemoteSigner creates a new instance of RemoteSigner connected to the signer at the given
+// address, either tcp://host:port or unix:///path/to/socket.
+func NewRemoteSigner(address string, tlsConfig TLSConfig, timeout time.Duration) (*RemoteSigner, error) {
+	config, err := tlsConfig.clientConfig(address)
+	if err != nil {
+		return nil, err
+	}
+	s := &RemoteSigner{
+		mu:        &sync.Mutex{},
+		address:   address,
+		tlsConfig: config,
+		timeout:   timeout,
+	}
+
+	result := &GetPublicKeysResult{}
+	if err := s.call("GetPublicKeys", &GetPublicKeysArgs{}, result); err != nil {
+		return nil, fmt.Errorf("failed to get the public keys from the signer: %v", err)
+	}
+	if s.pubKey, err = crypto.PublicKeyFromBytes(result.PublicKey); err != nil {
+		return nil, fmt.Errorf("invalid public key from the signer: %v", err)
+	}
+	if s.blsPubKey, err = bls.PublicKeyFromBytes(result.BLSPublicKey); err != nil {
+		return nil, fmt.Errorf("invalid BLS public key from the signer: %v", err)
+	}
+
+	logger.WithFields(log.Fields{
+		"address": s.pubKey.Address().Hex(),
+		"signer":  address,
+	}).Info("Connected to remote signer")
+
+	return s, nil
+}
+
+// PublicKey implements the core.Signer interface.
+func (s *RemoteSigner) PublicKey() *crypto.PublicKey {
+	return s.pubKey
+}
+
+// BLSPublicKey implements the core.Signer interface.
+func (s *RemoteSigner) BLSPublicKey() *bls.PublicKey {
+	return s.blsPubKey
+}
+
+// SignVote implements the core.Signer interface.
+func (s *RemoteSigner) SignVote(vote *core.Vote) error {
+	payload, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return err
+	}
+	sig, err := s.sign("SignVote", payload, vote.SignBytes())
+	if err != nil {
+		return err
+	}
+	vote.SetSignature(sig)
+	return nil
+}
+
+// SignProposal implements the core.Signer interface.
+func (s *RemoteSigner) SignProposal(header *core.BlockHeader) error {
+	payload, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return err
+	}
+	sig, err := s.sign("SignProposal", payload, header.SignBytes())
+	if err != nil {
+		return err
+	}
+	header.SetSignature(sig)
+	return nil
+}
+
+// SignTx implements the core.Signer interface.
+func (s *RemoteSigner) SignTx(chainID string, rawTx common.Bytes) (*crypto.Signature, error) {
+	_, signBytes, err := decodeProposerTx(chainID, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	result := &SignResult{}
+	if err := s.call("SignTx", &SignTxArgs{ChainID: chainID, Tx: rawTx}, result); err != nil {
+		return nil, err
+	}
+	return s.verifySignature(result, signBytes)
+}
+
+// SignGuardianVote implements the core.Signer interface.
+func (s *RemoteSigner) SignGuardianVote(vote *core.AggregatedVotes, signerIdx int) error {
+	result := &SignResult{}
+	args := &SignGuardianVoteArgs{Block: vote.Block, Gcp: vote.Gcp}
+	if err := s.call("SignGuardianVote", args, result); err != nil {
+		return err
+	}
+	sig, err := bls.SignatureFromBytes(result.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid guardian vote signature from the signer: %v", err)
+	}
+	if !sig.Verify(vote.SignBytes(), s.blsPubKey) {
+		return errors.New("guardian vote signature from the signer does not match the BLS key")
+	}
+	vote.AddSignature(sig, signerIdx)
+	return nil
+}
+
+// ProveVRF implements the core.Signer interface.
+func (s *RemoteSigner) ProveVRF(msg common.Bytes) (common.Hash, common.Bytes, error) {
+	result := &ProveVRFResult{}
+	if err := s.call("ProveVRF", &ProveVRFArgs{Message: msg}, result); err != nil {
+		return common.Hash{}, nil, err
+	}
+	output, err := s.pubKey.VRFVerify(msg, result.Proof)
+	if err != nil || output != result.Output {
+		return common.Hash{}, nil, errors.New("VRF proof from the signer does not match the node key")
+	}
+	return output, result.Proof, nil
+}
+
+// ProveBLSKey implements the core.Signer interface.
+func (s *RemoteSigner) ProveBLSKey() (*bls.Signature, *crypto.Signature, error) {
+	result := &ProveBLSKeyResult{}
+	if err := s.call("ProveBLSKey", &ProveBLSKeyArgs{}, result); err != nil {
+		return nil, nil, err
+	}
+	pop, err := bls.SignatureFromBytes(result.Pop)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid proof of possession from the signer: %v", err)
+	}
+	sig, err := crypto.SignatureFromBytes(result.Signature)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid signature from the signer: %v", err)
+	}
+	if !pop.PopVerify(s.blsPubKey) {
+		return nil, nil, errors.New("proof of possession from the signer does not match the BLS key")
+	}
+	if !sig.Verify(pop.ToBytes(), s.pubKey.Address()) {
+		return nil, nil, errors.New("signature of the proof of possession from the signer does not match the node key")
+	}
+	return pop, sig, nil
+}
+
+// sign requests the signature of the payload, and verifies it against the expected sign bytes.
+func (s *RemoteSigner) sign(method string, payload common.Bytes, signBytes common.Bytes) (*crypto.Signature, error) {
+	result := &SignResult{}
+	if err := s.call(method, &SignArgs{Payload: payload}, result); err != nil {
+		return nil, err
+	}
+	return s.verifySignature(result, signBytes)
+}
+
+// verifySignature checks that the signature returned by the signer was made by the node key.
+func (s *RemoteSigner) verifySignature(result *SignResult, signBytes common.Bytes) (*crypto.Signature, error) {
+	sig, err := crypto.SignatureFromBytes(result.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from the signer: %v", err)
+	}
+	if !sig.Verify(signBytes, s.pubKey.Address()) {
+		return nil, errors.New("signature from the signer does not match the node key")
+	}
+	return sig, nil
+}
+
+// call sends the request to the signer, and reconnects on the next request if it failed.
+func (s *RemoteSigner) call(method string, args interface{}, reply interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		conn, err := dial(s.address, s.tlsConfig, &net.Dialer{Timeout: s.timeout})
+		if err != nil {
+			return err
+		}
+		s.client = jsonrpc.NewClient(conn)
+	}
+
+	call := s.client.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			s.reset()
+			return call.Error
+		}
+		return nil
+	case <-time.After(s.timeout):
+		s.reset()
+		return errSignerTimeout
+	}
+}
+
+func (s *RemoteSigner) reset() {
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+}
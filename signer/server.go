@@ -0,0 +1,228 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/common/util"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto/bls"
+	"github.com/scripttoken/script/rlp"
+	log "github.com/sirupsen/logrus"
+)
+
+var logger *log.Entry = util.GetLoggerForModule("signer")
+
+// serviceName is the name of the RPC service exposed by the signer
+const serviceName = "signer"
+
+type GetPublicKeysArgs struct{}
+
+type GetPublicKeysResult struct {
+	PublicKey    common.Bytes `json:"public_key"`
+	BLSPublicKey common.Bytes `json:"bls_public_key"`
+}
+
+type SignArgs struct {
+	Payload common.Bytes `json:"payload"` // RLP encoded vote or block header
+}
+
+type SignTxArgs struct {
+	ChainID string       `json:"chain_id"`
+	Tx      common.Bytes `json:"tx"` // Encoded coinbase or double sign slash transaction
+}
+
+type SignResult struct {
+	Signature common.Bytes `json:"signature"`
+}
+
+type SignGuardianVoteArgs struct {
+	Block common.Hash `json:"block"`
+	Gcp   common.Hash `json:"gcp"`
+}
+
+type ProveVRFArgs struct {
+	Message common.Bytes `json:"message"`
+}
+
+type ProveVRFResult struct {
+	Output common.Hash  `json:"output"`
+	Proof  common.Bytes `json:"proof"`
+}
+
+type ProveBLSKeyArgs struct{}
+
+type ProveBLSKeyResult struct {
+	Pop       common.Bytes `json:"pop"`
+	Signature common.Bytes `json:"signature"`
+}
+
+// SignerService serves the signing requests of the node.
+type SignerService struct {
+	signer core.Signer
+}
+
+func (s *SignerService) GetPublicKeys(args *GetPublicKeysArgs, result *GetPublicKeysResult) error {
+	result.PublicKey = s.signer.PublicKey().ToBytes()
+	result.BLSPublicKey = s.signer.BLSPublicKey().ToBytes()
+	return nil
+}
+
+func (s *SignerService) SignVote(args *SignArgs, result *SignResult) error {
+	vote := &core.Vote{}
+	if err := rlp.DecodeBytes(args.Payload, vote); err != nil {
+		return fmt.Errorf("failed to decode vote: %v", err)
+	}
+	if vote.ID != s.signer.PublicKey().Address() {
+		return fmt.Errorf("vote is not from the signer: %v", vote.ID.Hex())
+	}
+	if err := s.signer.SignVote(vote); err != nil {
+		return err
+	}
+	logger.WithFields(log.Fields{"vote": vote}).Info("Signed vote")
+	result.Signature = vote.Signature.ToBytes()
+	return nil
+}
+
+func (s *SignerService) SignProposal(args *SignArgs, result *SignResult) error {
+	header := &core.BlockHeader{}
+	if err := rlp.DecodeBytes(args.Payload, header); err != nil {
+		return fmt.Errorf("failed to decode block header: %v", err)
+	}
+	if header.Proposer != s.signer.PublicKey().Address() {
+		return fmt.Errorf("block is not proposed by the signer: %v", header.Proposer.Hex())
+	}
+	if err := s.signer.SignProposal(header); err != nil {
+		return err
+	}
+	logger.WithFields(log.Fields{"height": header.Height, "epoch": header.Epoch}).Info("Signed proposal")
+	result.Signature = header.Signature.ToBytes()
+	return nil
+}
+
+func (s *SignerService) SignTx(args *SignTxArgs, result *SignResult) error {
+	proposer, _, err := decodeProposerTx(args.ChainID, args.Tx)
+	if err != nil {
+		return err
+	}
+	if proposer != s.signer.PublicKey().Address() {
+		return fmt.Errorf("transaction is not issued by the signer: %v", proposer.Hex())
+	}
+	sig, err := s.signer.SignTx(args.ChainID, args.Tx)
+	if err != nil {
+		return err
+	}
+	logger.WithFields(log.Fields{"chainID": args.ChainID}).Info("Signed transaction")
+	result.Signature = sig.ToBytes()
+	return nil
+}
+
+func (s *SignerService) SignGuardianVote(args *SignGuardianVoteArgs, result *SignResult) error {
+	vote := &core.AggregatedVotes{
+		Block:      args.Block,
+		Gcp:        args.Gcp,
+		Multiplies: make([]uint32, 1),
+		Signature:  bls.NewAggregateSignature(),
+	}
+	if err := s.signer.SignGuardianVote(vote, 0); err != nil {
+		return err
+	}
+	logger.WithFields(log.Fields{"block": args.Block.Hex()}).Info("Signed guardian vote")
+	result.Signature = vote.Signature.ToBytes()
+	return nil
+}
+
+func (s *SignerService) ProveVRF(args *ProveVRFArgs, result *ProveVRFResult) error {
+	output, proof, err := s.signer.ProveVRF(args.Message)
+	if err != nil {
+		return err
+	}
+	result.Output = output
+	result.Proof = proof
+	return nil
+}
+
+func (s *SignerService) ProveBLSKey(args *ProveBLSKeyArgs, result *ProveBLSKeyResult) error {
+	pop, sig, err := s.signer.ProveBLSKey()
+	if err != nil {
+		return err
+	}
+	result.Pop = pop.ToBytes()
+	result.Signature = sig.ToBytes()
+	return nil
+}
+
+// Server is the external signer process serving the node over a mutually authenticated connection.
+type Server struct {
+	listener net.Listener
+	rpc      *rpc.Server
+
+	wg *sync.WaitGroup
+}
+
+// NewServer creates a new instance of Server listening at the given address, either
+// tcp://host:port or unix:///path/to/socket.
+func NewServer(signer core.Signer, address string, tlsConfig TLSConfig) (*Server, error) {
+	config, err := tlsConfig.serverConfig()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := listen(address, config)
+	if err != nil {
+		return nil, err
+	}
+	s := rpc.NewServer()
+	if err := s.RegisterName(serviceName, &SignerService{signer: signer}); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &Server{
+		listener: listener,
+		rpc:      s,
+		wg:       &sync.WaitGroup{},
+	}, nil
+}
+
+// Addr returns the address the server is listening at.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Start starts serving the connections until the context is cancelled.
+func (s *Server) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go s.mainLoop()
+
+	go func() {
+		<-ctx.Done()
+		s.listener.Close()
+	}()
+}
+
+// Wait blocks until the server stops.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) mainLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Temporary() {
+				continue
+			}
+			return
+		}
+		logger.WithFields(log.Fields{"remote": conn.RemoteAddr()}).Info("Accepted node connection")
+		go s.rpc.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
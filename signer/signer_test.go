@@ -0,0 +1,321 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/scripttoken/script/common"
+	"github.com/scripttoken/script/core"
+	"github.com/scripttoken/script/crypto"
+	"github.com/scripttoken/script/crypto/bls"
+	"github.com/scripttoken/script/ledger/types"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T, dir string, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+
+	ca := &testCA{cert: cert, key: key, dir: dir}
+	writePEM(t, path.Join(dir, name+".crt"), "CERTIFICATE", der)
+	return ca
+}
+
+// issue creates a certificate for both client and server authentication, and returns its TLS config.
+func (ca *testCA) issue(t *testing.T, name string) TLSConfig {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	config := TLSConfig{
+		CACertFile: path.Join(ca.dir, ca.cert.Subject.CommonName+".crt"),
+		CertFile:   path.Join(ca.dir, name+".crt"),
+		KeyFile:    path.Join(ca.dir, name+".key"),
+		ServerName: "signer",
+	}
+	writePEM(t, config.CertFile, "CERTIFICATE", der)
+	writePEM(t, config.KeyFile, "EC PRIVATE KEY", keyDer)
+	return config
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	raw := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.Nil(t, ioutil.WriteFile(file, raw, 0600))
+}
+
+func TestRemoteSigner(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, dir, "ca")
+	serverTLS := ca.issue(t, "signer")
+	clientTLS := ca.issue(t, "node")
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	localSigner, err := NewLocalSigner(privKey)
+	require.Nil(err)
+
+	address := "unix://" + path.Join(dir, "signer.sock")
+	server, err := NewServer(localSigner, address, serverTLS)
+	require.Nil(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	server.Start(ctx)
+	defer func() {
+		cancel()
+		server.Wait()
+	}()
+
+	remoteSigner, err := NewRemoteSigner(address, clientTLS, 5*time.Second)
+	require.Nil(err)
+	require.Equal(localSigner.PublicKey().Address(), remoteSigner.PublicKey().Address())
+	require.Equal(localSigner.BLSPublicKey().ToBytes(), remoteSigner.BLSPublicKey().ToBytes())
+
+	// Votes
+	vote := core.Vote{
+		Block:  common.HexToHash("a1"),
+		Height: 10,
+		Epoch:  5,
+		ID:     privKey.PublicKey().Address(),
+	}
+	require.Nil(remoteSigner.SignVote(&vote))
+	require.True(vote.Validate().IsOK())
+
+	// Votes on behalf of other validators are refused
+	otherVote := vote
+	otherVote.ID = common.HexToAddress("0x1234")
+	require.NotNil(remoteSigner.SignVote(&otherVote))
+
+	// Proposals
+	header := &core.BlockHeader{
+		ChainID:   "testchain",
+		Epoch:     5,
+		Height:    10,
+		Parent:    common.HexToHash("a0"),
+		Timestamp: big.NewInt(1000),
+		Proposer:  privKey.PublicKey().Address(),
+	}
+	require.Nil(remoteSigner.SignProposal(header))
+	require.True(header.Signature.Verify(header.SignBytes(), header.Proposer))
+
+	// Transactions
+	coinbaseTx := &types.CoinbaseTx{
+		Proposer:    types.TxInput{Address: privKey.PublicKey().Address(), Sequence: 1},
+		Outputs:     []types.TxOutput{},
+		BlockHeight: 10,
+	}
+	rawTx, err := types.TxToBytes(coinbaseTx)
+	require.Nil(err)
+	sig, err := remoteSigner.SignTx("testchain", rawTx)
+	require.Nil(err)
+	require.True(sig.Verify(coinbaseTx.SignBytes("testchain"), privKey.PublicKey().Address()))
+
+	// Guardian votes
+	guardianVote := &core.AggregatedVotes{
+		Block:      common.HexToHash("a1"),
+		Gcp:        common.HexToHash("b1"),
+		Multiplies: make([]uint32, 3),
+		Signature:  bls.NewAggregateSignature(),
+	}
+	require.Nil(remoteSigner.SignGuardianVote(guardianVote, 1))
+	require.Equal([]uint32{0, 1, 0}, guardianVote.Multiplies)
+	require.True(guardianVote.Signature.Verify(guardianVote.SignBytes(), remoteSigner.BLSPublicKey()))
+
+	// VRF
+	output, proof, err := remoteSigner.ProveVRF(common.Bytes("seed"))
+	require.Nil(err)
+	verifiedOutput, err := privKey.PublicKey().VRFVerify(common.Bytes("seed"), proof)
+	require.Nil(err)
+	require.Equal(output, verifiedOutput)
+
+	// Guardian key
+	pop, popSig, err := remoteSigner.ProveBLSKey()
+	require.Nil(err)
+	require.True(popSig.Verify(pop.ToBytes(), privKey.PublicKey().Address()))
+}
+
+func TestRemoteSignerMutualAuthentication(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, dir, "ca")
+	serverTLS := ca.issue(t, "signer")
+	otherCA := newTestCA(t, dir, "other")
+	intruderTLS := otherCA.issue(t, "intruder")
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	localSigner, err := NewLocalSigner(privKey)
+	require.Nil(err)
+
+	address := "unix://" + path.Join(dir, "signer.sock")
+	server, err := NewServer(localSigner, address, serverTLS)
+	require.Nil(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	server.Start(ctx)
+	defer func() {
+		cancel()
+		server.Wait()
+	}()
+
+	// A node with a certificate from another CA is rejected by the signer
+	intruderTLS.CACertFile = serverTLS.CACertFile
+	_, err = NewRemoteSigner(address, intruderTLS, 5*time.Second)
+	require.NotNil(err)
+
+	// A signer with a certificate from another CA is rejected by the node
+	clientTLS := ca.issue(t, "node")
+	clientTLS.CACertFile = path.Join(dir, "other.crt")
+	_, err = NewRemoteSigner(address, clientTLS, 5*time.Second)
+	require.NotNil(err)
+
+	clientTLS.CACertFile = serverTLS.CACertFile
+	_, err = NewRemoteSigner(address, clientTLS, 5*time.Second)
+	require.Nil(err)
+
+	// Certificates are required
+	_, err = NewRemoteSigner(address, TLSConfig{}, 5*time.Second)
+	require.NotNil(err)
+}
+
+func TestSignerServiceSignTx(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	localSigner, err := NewLocalSigner(privKey)
+	require.Nil(err)
+	service := &SignerService{signer: localSigner}
+	address := privKey.PublicKey().Address()
+
+	coinbaseTx := &types.CoinbaseTx{
+		Proposer:    types.TxInput{Address: address, Sequence: 1},
+		Outputs:     []types.TxOutput{},
+		BlockHeight: 10,
+	}
+	rawTx, err := types.TxToBytes(coinbaseTx)
+	require.Nil(err)
+	result := &SignResult{}
+	require.Nil(service.SignTx(&SignTxArgs{ChainID: "testchain", Tx: rawTx}, result))
+	sig, err := crypto.SignatureFromBytes(result.Signature)
+	require.Nil(err)
+	require.True(sig.Verify(coinbaseTx.SignBytes("testchain"), address))
+
+	// Only the transactions issued by the signer as block proposer are signed
+	otherCoinbaseTx := &types.CoinbaseTx{
+		Proposer:    types.TxInput{Address: common.HexToAddress("0x1234"), Sequence: 1},
+		Outputs:     []types.TxOutput{},
+		BlockHeight: 10,
+	}
+	rawTx, err = types.TxToBytes(otherCoinbaseTx)
+	require.Nil(err)
+	require.NotNil(service.SignTx(&SignTxArgs{ChainID: "testchain", Tx: rawTx}, &SignResult{}))
+
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: address, Coins: types.NewCoins(0, 1000000000000), Sequence: 1}},
+		Outputs: []types.TxOutput{{Address: common.HexToAddress("0x1234"), Coins: types.NewCoins(0, 0)}},
+	}
+	rawTx, err = types.TxToBytes(sendTx)
+	require.Nil(err)
+	require.NotNil(service.SignTx(&SignTxArgs{ChainID: "testchain", Tx: rawTx}, &SignResult{}))
+
+	// Arbitrary bytes are not signed
+	require.NotNil(service.SignTx(&SignTxArgs{ChainID: "testchain", Tx: common.Bytes("arbitrary message")}, &SignResult{}))
+}
+
+// forgingSigner returns a proof of possession of another BLS key.
+type forgingSigner struct {
+	*LocalSigner
+	other *LocalSigner
+}
+
+func (s *forgingSigner) ProveBLSKey() (*bls.Signature, *crypto.Signature, error) {
+	pop := s.other.blsKey.PopProve()
+	sig, err := s.privKey.Sign(pop.ToBytes())
+	return pop, sig, err
+}
+
+func TestRemoteSignerVerifiesBLSKeyProof(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "signer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, dir, "ca")
+	serverTLS := ca.issue(t, "signer")
+	clientTLS := ca.issue(t, "node")
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	localSigner, err := NewLocalSigner(privKey)
+	require.Nil(err)
+	otherKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	otherSigner, err := NewLocalSigner(otherKey)
+	require.Nil(err)
+
+	address := "unix://" + path.Join(dir, "signer.sock")
+	server, err := NewServer(&forgingSigner{LocalSigner: localSigner, other: otherSigner}, address, serverTLS)
+	require.Nil(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	server.Start(ctx)
+	defer func() {
+		cancel()
+		server.Wait()
+	}()
+
+	remoteSigner, err := NewRemoteSigner(address, clientTLS, 5*time.Second)
+	require.Nil(err)
+	_, _, err = remoteSigner.ProveBLSKey()
+	require.NotNil(err)
+}
@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+)
+
+// TLSConfig holds the certificates for the mutual TLS authentication between the node and the
+// signer. Both sides present a certificate issued by the same CA, and reject peers whose
+// certificate is not issued by it.
+type TLSConfig struct {
+	CACertFile string // Certificate of the CA issuing the node and the signer certificates
+	CertFile   string // Certificate presented to the peer
+	KeyFile    string // Private key of the certificate
+	ServerName string // Name in the signer certificate, default to the host of the signer address
+}
+
+func (c TLSConfig) load() (tls.Certificate, *x509.CertPool, error) {
+	if c.CACertFile == "" || c.CertFile == "" || c.KeyFile == "" {
+		return tls.Certificate{}, nil, fmt.Errorf("CA certificate, certificate and key are required for mutual authentication")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load certificate %v: %v", c.CertFile, err)
+	}
+	caCert, err := ioutil.ReadFile(c.CACertFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read CA certificate %v: %v", c.CACertFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return tls.Certificate{}, nil, fmt.Errorf("no certificate found in %v", c.CACertFile)
+	}
+	return cert, pool, nil
+}
+
+func (c TLSConfig) serverConfig() (*tls.Config, error) {
+	cert, pool, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func (c TLSConfig) clientConfig(address string) (*tls.Config, error) {
+	cert, pool, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	serverName := c.ServerName
+	if serverName == "" {
+		network, addr, err := parseAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		serverName = "localhost"
+		if network == "tcp" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				serverName = host
+			}
+		}
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// parseAddress parses the signer address, either tcp://host:port or unix:///path/to/socket.
+func parseAddress(address string) (network string, addr string, err error) {
+	parts := strings.SplitN(address, "://", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid signer address: %v", address)
+	}
+	switch parts[0] {
+	case "tcp", "unix":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("unsupported signer address scheme: %v", parts[0])
+	}
+}
+
+func listen(address string, config *tls.Config) (net.Listener, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		// Remove the socket left by a previous run
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err := os.Chmod(addr, 0600); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return tls.NewListener(listener, config), nil
+}
+
+func dial(address string, config *tls.Config, dialer *net.Dialer) (net.Conn, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return tls.DialWithDialer(dialer, network, addr, config)
+}
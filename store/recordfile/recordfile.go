@@ -0,0 +1,157 @@
+package recordfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scripttoken/script/rlp"
+	log "github.com/sirupsen/logrus"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "store"})
+
+// ErrClosed is returned when appending to a file which is not open.
+var ErrClosed = errors.New("record file is not open")
+
+// File is an append-only file of RLP encoded records, used for the node-local data which must
+// survive restarts, e.g. the mempool journal, the signing history or the consensus WAL. Each
+// record is prefixed with its length, so that a record partially written at the end of the file,
+// e.g. due to a crash, is detected when reading. The file is opened for appending by Rewrite, which
+// also drops such a partial record.
+type File struct {
+	path   string
+	writer *os.File // nil until the file is rewritten
+	size   int64    // Size of the file in bytes
+}
+
+// New creates a File at the given path. The file is neither created nor opened.
+func New(path string) *File {
+	return &File{
+		path: path,
+	}
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Size returns the size of the file in bytes, as of the last Rewrite and the records appended since.
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// Read calls decode for each record of the file, in order. A missing file has no records. A
+// partially written record at the end of the file, e.g. due to a crash, is ignored.
+func (f *File) Read(decode func(stream *rlp.Stream) error) error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream := rlp.NewStream(file, 0)
+	for {
+		err := decode(stream)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			logger.Warnf("Failed to read the record file %v: %v", f.path, err)
+			return nil
+		}
+	}
+}
+
+// Append writes the record at the end of the file. The record is only guaranteed to be on disk
+// once Sync returns. If the record cannot be written in full, the file is truncated back so that
+// the next records remain readable.
+func (f *File) Append(record interface{}) error {
+	if f.writer == nil {
+		return ErrClosed
+	}
+	raw, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	if _, err := f.writer.Write(raw); err != nil {
+		if terr := f.writer.Truncate(f.size); terr != nil {
+			logger.Errorf("Failed to truncate the record file %v: %v", f.path, terr)
+		}
+		return err
+	}
+	f.size += int64(len(raw))
+	return nil
+}
+
+// Sync commits the appended records to disk.
+func (f *File) Sync() error {
+	if f.writer == nil {
+		return ErrClosed
+	}
+	return f.writer.Sync()
+}
+
+// Rewrite atomically replaces the content of the file with the given records, and reopens the
+// file for appending.
+func (f *File) Rewrite(records []interface{}) error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmpPath := f.path + ".new"
+	replacement, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	size := int64(0)
+	for _, record := range records {
+		raw, err := rlp.EncodeToBytes(record)
+		if err == nil {
+			_, err = replacement.Write(raw)
+		}
+		if err != nil {
+			replacement.Close()
+			return err
+		}
+		size += int64(len(raw))
+	}
+	// Make sure the content is on disk before it replaces the file
+	if err = replacement.Sync(); err != nil {
+		replacement.Close()
+		return err
+	}
+	if err = replacement.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, f.path); err != nil {
+		return err
+	}
+
+	writer, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	f.writer = writer
+	f.size = size
+	return nil
+}
+
+// Close closes the file. The records can still be read, but no longer appended until the next
+// Rewrite.
+func (f *File) Close() error {
+	var err error
+	if f.writer != nil {
+		err = f.writer.Close()
+		f.writer = nil
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package recordfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scripttoken/script/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, file *File) []string {
+	records := []string{}
+	err := file.Read(func(stream *rlp.Stream) error {
+		record, err := stream.Bytes()
+		if err != nil {
+			return err
+		}
+		records = append(records, string(record))
+		return nil
+	})
+	assert.Nil(t, err)
+	return records
+}
+
+func TestRecordFileRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "records", "file")
+	file := New(path)
+
+	assert.Empty(readAll(t, file))
+	assert.Equal(ErrClosed, file.Append([]byte("r1")))
+
+	assert.Nil(file.Rewrite([]interface{}{[]byte("r1"), []byte("r2")}))
+	assert.Nil(file.Append([]byte("r3")))
+	assert.Nil(file.Sync())
+	assert.Equal([]string{"r1", "r2", "r3"}, readAll(t, file))
+
+	info, err := os.Stat(path)
+	assert.Nil(err)
+	assert.Equal(info.Size(), file.Size())
+
+	// The rewrite only keeps the given records
+	assert.Nil(file.Rewrite([]interface{}{[]byte("r3")}))
+	assert.Nil(file.Append([]byte("r4")))
+	assert.Nil(file.Close())
+	assert.Equal([]string{"r3", "r4"}, readAll(t, file))
+	assert.Equal(ErrClosed, file.Append([]byte("r5")))
+
+	_, err = os.Stat(path + ".new")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestRecordFilePartialRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "file")
+	file := New(path)
+	assert.Nil(file.Rewrite([]interface{}{[]byte("r1"), []byte("a much longer record")}))
+	assert.Nil(file.Close())
+
+	// Simulate a crash in the middle of writing the last record
+	info, err := os.Stat(path)
+	assert.Nil(err)
+	assert.Nil(os.Truncate(path, info.Size()-5))
+	records := readAll(t, file)
+	assert.Equal([]string{"r1"}, records)
+
+	// Rewriting the readable records drops the partial one, so that the next records remain readable
+	reopened := New(path)
+	assert.Nil(reopened.Rewrite([]interface{}{[]byte(records[0])}))
+	assert.Nil(reopened.Append([]byte("r2")))
+	assert.Nil(reopened.Close())
+	assert.Equal([]string{"r1", "r2"}, readAll(t, reopened))
+}
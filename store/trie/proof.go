@@ -103,6 +103,10 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb database.Putter)
 // key in a trie with the given root hash. VerifyProof returns an error if the
 // proof contains invalid trie nodes or the wrong value.
 func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (value []byte, nodes int, err error) {
+	if rootHash == (common.Hash{}) || rootHash == emptyRoot {
+		// The empty trie doesn't contain any key.
+		return nil, 0, nil
+	}
 	key = keybytesToHex(key)
 	wantHash := rootHash
 	for i := 0; ; i++ {